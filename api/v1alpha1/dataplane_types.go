@@ -17,8 +17,10 @@ limitations under the License.
 package v1alpha1
 
 import (
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func init() {
@@ -43,25 +45,67 @@ type Dataplane struct {
 	Spec DataplaneSpec `json:"spec,omitempty"`
 }
 
+// DataplaneResourceType is the type of the Kubernetes resource used to run stunnerd.
+// +kubebuilder:validation:Enum=Deployment;DaemonSet
+type DataplaneResourceType string
+
+const (
+	// DataplaneResourceDeployment runs stunnerd in a Deployment.
+	DataplaneResourceDeployment DataplaneResourceType = "Deployment"
+	// DataplaneResourceDaemonSet runs stunnerd in a DaemonSet, with exactly one stunnerd pod
+	// per node.
+	DataplaneResourceDaemonSet DataplaneResourceType = "DaemonSet"
+)
+
 // this must be kept in sync with Renderer.createDeployment and Updater.upsertDeployment
 
 // DataplaneSpec describes the prefixes reachable via a Dataplane.
 type DataplaneSpec struct {
-	// // Dataplane template. The `default` template spawns a single stunnerd container with the
-	// // running config mapped into the pod from the ConfigMap. The `config-watcher` template
-	// // uses a separate sidecar container to watch the ConfigMap, which can be faster than the
-	// // default but requires additional RBAC permissions.
-	// //
-	// // +optional
-	// // +kubebuilder:default:="default"
-	// Template string `json:"template,omitempty"`
+	// Dataplane template. The `default` template spawns a single stunnerd container that
+	// obtains its running config from the config discovery service of the operator. The
+	// `config-watcher` template mounts the rendered ConfigMap into the pod using a separate
+	// sidecar container that watches the ConfigMap, which is useful when stunnerd cannot reach
+	// the config discovery service. The operator creates a ServiceAccount (unless one is
+	// specified in ServiceAccountName), a Role and a RoleBinding for the sidecar to be able to
+	// watch the ConfigMap. Only supported in the managed dataplane mode: in the legacy mode the
+	// dataplane is deployed by the user and this field is ignored. Legacy dataplanes can run
+	// their own config-watcher sidecar to watch the ConfigMap rendered by the operator.
+	//
+	// +optional
+	// +kubebuilder:default:="default"
+	// +kubebuilder:validation:Enum=default;config-watcher
+	Template string `json:"template,omitempty"`
+
+	// DataplaneResource defines the Kubernetes resource kind to use to deploy the dataplane,
+	// can be either Deployment (default) or DaemonSet. A DaemonSet runs exactly one stunnerd
+	// pod per node, which is useful for implementing public TURN servers with host
+	// networking.
+	//
+	// +optional
+	// +kubebuilder:default:=Deployment
+	DataplaneResource *DataplaneResourceType `json:"dataplaneResource,omitempty"`
 
 	// Number of desired pods. This is a pointer to distinguish between explicit zero and not
-	// specified. Defaults to 1.
+	// specified. Defaults to 1. Ignored for DaemonSet dataplanes and when autoscaling is
+	// enabled.
 	//
 	// +optional
 	Replicas *int32 `json:"replicas,omitempty"`
 
+	// Autoscaling, if specified, makes the operator create a HorizontalPodAutoscaler for the
+	// stunnerd Deployment of each Gateway. The replica count of the Deployment is then
+	// controlled by the autoscaler. Ignored for DaemonSet dataplanes.
+	//
+	// +optional
+	Autoscaling *DataplaneAutoscaling `json:"autoscaling,omitempty"`
+
+	// DisruptionBudget, if specified, makes the operator create a PodDisruptionBudget for the
+	// stunnerd pods of each Gateway, which limits the number of pods taken down at once by
+	// voluntary disruptions like node drains.
+	//
+	// +optional
+	DisruptionBudget *DataplaneDisruptionBudget `json:"disruptionBudget,omitempty"`
+
 	// Container image name.
 	//
 	// +optional
@@ -72,6 +116,12 @@ type DataplaneSpec struct {
 	// +optional
 	ImagePullPolicy *corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
 
+	// ImagePullSecrets is an optional list of references to secrets in the same namespace to
+	// use for pulling the stunnerd image.
+	//
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
 	// Entrypoint array. Defaults: "stunnerd".
 	//
 	// +optional
@@ -87,11 +137,38 @@ type DataplaneSpec struct {
 	// +optional
 	Env []corev1.EnvVar `json:"env,omitempty"`
 
+	// Additional volume mounts for the stunnerd container. The volumes must be listed in
+	// Volumes.
+	//
+	// +optional
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+
 	// Resources required by stunnerd.
 	//
 	// +optional
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 
+	// ContainerSecurityContext holds container-level security attributes for the stunnerd
+	// container.
+	//
+	// +optional
+	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
+
+	// Sidecars is a list of additional containers to run in the stunnerd pods.
+	//
+	// +optional
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+
+	// Additional volumes for the stunnerd pods.
+	//
+	// +optional
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+
+	// Custom annotations to add to the stunnerd pods.
+	//
+	// +optional
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+
 	// Optional duration in seconds the stunnerd needs to terminate gracefully. Defaults to 3600 seconds.
 	//
 	// +optional
@@ -118,15 +195,85 @@ type DataplaneSpec struct {
 	// +optional
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
 
+	// NodeSelector is a selector which must match a node's labels for the stunnerd pods to be
+	// scheduled on that node.
+	//
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// TopologySpreadConstraints describes how the stunnerd pods ought to spread across
+	// topology domains.
+	//
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// If specified, the priority class of the stunnerd pods.
+	//
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// ServiceAccountName is the name of the ServiceAccount to use to run the stunnerd pods.
+	//
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
 	// If specified, the health-check port.
 	//
 	// +optional
 	HealthCheckPort *int `json:"healthCheckPort,omitempty"`
 
-	// // If specified, the metrics collection port.
-	// //
-	// // +optional
-	// MetricsEndpointPort *int `json:"metricsEndpointPort,omitempty"`
+	// If specified, the metrics collection port. The port is exposed on the stunnerd
+	// container as a named port called "metrics". Note that the metrics endpoint must also be
+	// enabled in the GatewayConfig.
+	//
+	// +optional
+	MetricsEndpointPort *int `json:"metricsEndpointPort,omitempty"`
+}
+
+// DataplaneAutoscaling specifies the HorizontalPodAutoscaler for a dataplane.
+type DataplaneAutoscaling struct {
+	// MinReplicas is the lower limit for the number of replicas. Defaults to 1.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// MaxReplicas is the upper limit for the number of replicas. Cannot be less than
+	// MinReplicas.
+	//
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// Metrics contains the specifications for which to use to calculate the desired replica
+	// count. Defaults to 80% average CPU utilization.
+	//
+	// +optional
+	Metrics []autoscalingv2.MetricSpec `json:"metrics,omitempty"`
+
+	// Behavior configures the scaling behavior of the target in both Up and Down
+	// directions. Use this to slow down scale-downs, which terminate stunnerd pods with active
+	// TURN allocations.
+	//
+	// +optional
+	Behavior *autoscalingv2.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
+}
+
+// DataplaneDisruptionBudget specifies the PodDisruptionBudget for a dataplane. At most one of
+// MinAvailable and MaxUnavailable can be set.
+type DataplaneDisruptionBudget struct {
+	// MinAvailable is the number or percentage of stunnerd pods that must remain available
+	// after an eviction.
+	//
+	// +optional
+	// +kubebuilder:validation:XIntOrString
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	// MaxUnavailable is the number or percentage of stunnerd pods that can be unavailable
+	// after an eviction.
+	//
+	// +optional
+	// +kubebuilder:validation:XIntOrString
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -36,7 +36,9 @@ type GatewayConfigSpec struct {
 	// with STUNner.
 	//
 	// The realm must consist of lower case alphanumeric characters or '-', and must start and
-	// end with an alphanumeric character. No other punctuation is allowed.
+	// end with an alphanumeric character. No other punctuation is allowed. A GatewayConfig
+	// attached to a Gateway overrides the realm of the GatewayClass only if set to a non-default
+	// value.
 	//
 	// +optional
 	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
@@ -115,6 +117,15 @@ type GatewayConfigSpec struct {
 	// +optional
 	AuthRef *gwapiv1b1.SecretObjectReference `json:"authRef,omitempty"`
 
+	// SharedSecretRotation lets the operator manage the shared secret for "longterm"
+	// authentication. The operator generates a random shared secret, rotates it periodically
+	// and writes the active secret back into the Secret referenced by AuthRef (creating the
+	// Secret if it does not exist), so that signaling servers can use it to mint TURN
+	// credentials. Requires AuthRef to be set.
+	//
+	// +optional
+	SharedSecretRotation *SharedSecretRotation `json:"sharedSecretRotation,omitempty"`
+
 	// LoadBalancerServiceAnnotations is a list of annotations that will go into the
 	// LoadBalancer services created automatically by the operator to wrap Gateways.
 	//
@@ -149,15 +160,115 @@ type GatewayConfigSpec struct {
 
 	// Dataplane defines the TURN server to set up for the STUNner Gateways using this
 	// GatewayConfig. Can be used to select the stunnerd image repo and version or deploy into
-	// the host-network namespace.
+	// the host-network namespace. A GatewayConfig attached to a Gateway overrides the dataplane
+	// of the GatewayClass only if set to a non-default value.
 	//
 	// +optional
 	// +kubebuilder:default:="default"
 	Dataplane *string `json:"dataplane,omitempty"`
 }
 
+// SharedSecretRotation specifies the schedule to rotate the shared secret used for "longterm"
+// authentication.
+type SharedSecretRotation struct {
+	// Interval is the time between two subsequent rotations of the shared secret.
+	Interval metav1.Duration `json:"interval"`
+
+	// Overlap is the time window after a rotation during which the new shared secret is
+	// published in the auth Secret under the key "next-secret" while the dataplane keeps using
+	// the current one under the key "secret", so that clients can pick up the new secret in
+	// time. When the overlap window closes the new secret replaces the one under the key
+	// "secret" and the dataplane switches to it. Default is AuthLifetime, or 1 hour if
+	// AuthLifetime is not set. The overlap window never exceeds the rotation interval.
+	//
+	// +optional
+	Overlap *metav1.Duration `json:"overlap,omitempty"`
+}
+
+// GatewayConfigConditionType is a type of condition associated with a GatewayConfig.
+type GatewayConfigConditionType string
+
+// GatewayConfigConditionReason defines the set of reasons that explain why a particular
+// GatewayConfig condition type has been raised.
+type GatewayConfigConditionReason string
+
+const (
+	// This condition is true when the GatewayConfig is semantically valid and the operator
+	// could render a dataplane configuration from it.
+	//
+	// Possible reasons for this condition to be true are:
+	//
+	// * "Accepted"
+	//
+	// Possible reasons for this condition to be false are:
+	//
+	// * "Invalid"
+	GatewayConfigConditionAccepted GatewayConfigConditionType = "Accepted"
+
+	// This reason is used with the "Accepted" condition when the condition is true.
+	GatewayConfigReasonAccepted GatewayConfigConditionReason = "Accepted"
+
+	// This reason is used with the "Accepted" condition when the GatewayConfig is invalid,
+	// e.g., the authentication settings are missing or inconsistent.
+	GatewayConfigReasonInvalid GatewayConfigConditionReason = "Invalid"
+)
+
+const (
+	// This condition is true when all the references in the GatewayConfig (the external
+	// authentication Secret and the Dataplane) have been successfully resolved.
+	//
+	// Possible reasons for this condition to be true are:
+	//
+	// * "ResolvedRefs"
+	//
+	// Possible reasons for this condition to be false are:
+	//
+	// * "InvalidAuthRef"
+	// * "InvalidDataplaneRef"
+	GatewayConfigConditionResolvedRefs GatewayConfigConditionType = "ResolvedRefs"
+
+	// This reason is used with the "ResolvedRefs" condition when the condition is true.
+	GatewayConfigReasonResolvedRefs GatewayConfigConditionReason = "ResolvedRefs"
+
+	// This reason is used with the "ResolvedRefs" condition when the external authentication
+	// Secret is missing or invalid.
+	GatewayConfigReasonInvalidAuthRef GatewayConfigConditionReason = "InvalidAuthRef"
+
+	// This reason is used with the "ResolvedRefs" condition when the Dataplane referenced by
+	// the GatewayConfig does not exist.
+	GatewayConfigReasonInvalidDataplaneRef GatewayConfigConditionReason = "InvalidDataplaneRef"
+)
+
+// GatewayConfigStatus defines the observed state of GatewayConfig
+type GatewayConfigStatus struct {
+	// Conditions describe the current conditions of the GatewayConfig.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=8
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// GatewayClasses lists the names of the GatewayClasses that use this GatewayConfig.
+	//
+	// +optional
+	GatewayClasses []string `json:"gatewayClasses,omitempty"`
+
+	// Gateways lists the Gateways (in the form namespace/name) that use this GatewayConfig.
+	//
+	// +optional
+	Gateways []string `json:"gateways,omitempty"`
+
+	// RenderGeneration is the generation of the last dataplane render that observed a new
+	// generation of this GatewayConfig or changed its status. Renders that leave both the
+	// GatewayConfig and its status unchanged do not update this field.
+	//
+	// +optional
+	RenderGeneration int64 `json:"renderGeneration,omitempty"`
+}
+
 //+kubebuilder:object:root=true
-// //+kubebuilder:subresource:status
+//+kubebuilder:subresource:status
 //+kubebuilder:resource:categories=stunner,shortName=gtwconf
 //+kubebuilder:printcolumn:name="Realm",type=string,JSONPath=`.spec.realm`
 //+kubebuilder:printcolumn:name="Auth",type=string,JSONPath=`.spec.authType`
@@ -168,8 +279,8 @@ type GatewayConfig struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec GatewayConfigSpec `json:"spec,omitempty"`
-	// Status GatewayConfigStatus `json:"status,omitempty"`
+	Spec   GatewayConfigSpec   `json:"spec,omitempty"`
+	Status GatewayConfigStatus `json:"status,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -9,12 +9,13 @@ import (
 )
 
 var (
-	StunnerdImage       = "l7mp/stunnerd:latest"
-	ConfigWatcherName   = "config-watcher"
-	ConfigWatcherImage  = "kiwigrid/k8s-sidecar:latest"
-	ConfigVolumeName    = "stunnerd-config-volume"
-	TerminationGrace    = int64(3600)
-	LivenessProbeAction = corev1.HTTPGetAction{
+	StunnerdImage         = "l7mp/stunnerd:latest"
+	ConfigWatcherName     = "config-watcher"
+	ConfigWatcherImage    = "kiwigrid/k8s-sidecar:latest"
+	ConfigVolumeName      = "stunnerd-config-volume"
+	ConfigVolumeMountPath = "/etc/stunnerd"
+	TerminationGrace      = int64(3600)
+	LivenessProbeAction   = corev1.HTTPGetAction{
 		Path:   "/live",
 		Port:   apiutil.FromInt(8086),
 		Scheme: "HTTP",
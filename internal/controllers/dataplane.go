@@ -91,7 +91,7 @@ func (r *dataplaneReconciler) Reconcile(ctx context.Context, req reconcile.Reque
 	store.Dataplanes.Reset(dataplaneList)
 	r.log.V(2).Info("reset Dataplane store", "configs", store.Dataplanes.String())
 
-	r.eventCh <- event.NewEventRender()
+	r.eventCh <- event.NewEventRender(req.NamespacedName)
 
 	return reconcile.Result{}, nil
 }
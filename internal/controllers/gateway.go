@@ -12,7 +12,9 @@ import (
 
 	"github.com/go-logr/logr"
 	appv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/types"
@@ -117,11 +119,41 @@ func RegisterGatewayController(mgr manager.Manager, ch chan event.Event, log log
 		if err := c.Watch(
 			source.Kind(mgr.GetCache(), &appv1.Deployment{}),
 			&handler.EnqueueRequestForObject{},
-			predicate.NewPredicateFuncs(r.validateDeploymentForReconcile),
+			predicate.NewPredicateFuncs(r.validateDataplaneForReconcile),
 		); err != nil {
 			return err
 		}
 		r.log.Info("watching deployment objects")
+
+		// watch DaemonSet objects referenced by one of our Gateways
+		if err := c.Watch(
+			source.Kind(mgr.GetCache(), &appv1.DaemonSet{}),
+			&handler.EnqueueRequestForObject{},
+			predicate.NewPredicateFuncs(r.validateDataplaneForReconcile),
+		); err != nil {
+			return err
+		}
+		r.log.Info("watching daemonset objects")
+
+		// watch HPAs referenced by one of our Gateways
+		if err := c.Watch(
+			source.Kind(mgr.GetCache(), &autoscalingv2.HorizontalPodAutoscaler{}),
+			&handler.EnqueueRequestForObject{},
+			predicate.NewPredicateFuncs(r.validateDataplaneForReconcile),
+		); err != nil {
+			return err
+		}
+		r.log.Info("watching horizontal pod autoscaler objects")
+
+		// watch PDBs referenced by one of our Gateways
+		if err := c.Watch(
+			source.Kind(mgr.GetCache(), &policyv1.PodDisruptionBudget{}),
+			&handler.EnqueueRequestForObject{},
+			predicate.NewPredicateFuncs(r.validateDataplaneForReconcile),
+		); err != nil {
+			return err
+		}
+		r.log.Info("watching pod disruption budget objects")
 	}
 
 	// NOTE: LoadBalancer Service resources are watched by the UDPRoute controller (together
@@ -140,6 +172,9 @@ func (r *gatewayReconciler) Reconcile(ctx context.Context, req reconcile.Request
 	gatewayList := []client.Object{}
 	secretList := []client.Object{}
 	deploymentList := []client.Object{}
+	daemonSetList := []client.Object{}
+	hpaList := []client.Object{}
+	pdbList := []client.Object{}
 
 	// find Gateways managed by this controller
 	gwClasses := &gwapiv1b1.GatewayClassList{}
@@ -220,7 +255,8 @@ func (r *gatewayReconciler) Reconcile(ctx context.Context, req reconcile.Request
 						continue
 					}
 
-					// TODO: check for ReferenceGrants
+					// cross-namespace references are checked against the
+					// ReferenceGrants during rendering
 
 					r.log.V(2).Info("found Secret", "name", store.GetObjectKey(&gc))
 					secretList = append(secretList, &secret)
@@ -235,6 +271,21 @@ func (r *gatewayReconciler) Reconcile(ctx context.Context, req reconcile.Request
 				if err := r.Get(context.Background(), deploymentName, dp); err == nil {
 					deploymentList = append(deploymentList, dp)
 				}
+
+				ds := &appv1.DaemonSet{}
+				if err := r.Get(context.Background(), deploymentName, ds); err == nil {
+					daemonSetList = append(daemonSetList, ds)
+				}
+
+				hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+				if err := r.Get(context.Background(), deploymentName, hpa); err == nil {
+					hpaList = append(hpaList, hpa)
+				}
+
+				pdb := &policyv1.PodDisruptionBudget{}
+				if err := r.Get(context.Background(), deploymentName, pdb); err == nil {
+					pdbList = append(pdbList, pdb)
+				}
 			}
 		}
 	}
@@ -252,7 +303,18 @@ func (r *gatewayReconciler) Reconcile(ctx context.Context, req reconcile.Request
 	store.Deployments.Reset(deploymentList)
 	r.log.V(2).Info("reset Deployment store", "deployments", store.Deployments.String())
 
-	r.eventCh <- event.NewEventRender()
+	store.DaemonSets.Reset(daemonSetList)
+	r.log.V(2).Info("reset DaemonSet store", "daemonsets", store.DaemonSets.String())
+
+	store.HorizontalPodAutoscalers.Reset(hpaList)
+	r.log.V(2).Info("reset HorizontalPodAutoscaler store", "hpas",
+		store.HorizontalPodAutoscalers.String())
+
+	store.PodDisruptionBudgets.Reset(pdbList)
+	r.log.V(2).Info("reset PodDisruptionBudget store", "pdbs",
+		store.PodDisruptionBudgets.String())
+
+	r.eventCh <- event.NewEventRender(req.NamespacedName)
 
 	return reconcile.Result{}, nil
 }
@@ -317,16 +379,14 @@ func (r *gatewayReconciler) validateSecretForReconcile(obj client.Object) bool {
 	return false
 }
 
-// validateDeploymentForReconcile checks whether there is a Gateway with the same name as the
-// Deployment and the Deployment is owned by us.
-func (r *gatewayReconciler) validateDeploymentForReconcile(obj client.Object) bool {
-	// we don't watch Deployments in legacy mode
+// validateDataplaneForReconcile checks whether there is a Gateway with the same name as the
+// dataplane Deployment, DaemonSet, HPA or PDB and the object is owned by us.
+func (r *gatewayReconciler) validateDataplaneForReconcile(deployment client.Object) bool {
+	// we don't watch Deployments/DaemonSets in legacy mode
 	if config.DataplaneMode != config.DataplaneModeManaged {
 		return false
 	}
 
-	deployment := obj.(*appv1.Deployment)
-
 	// is deployment owned by us?
 	val, ok := deployment.GetLabels()[opdefault.OwnedByLabelKey]
 	if !ok || val != opdefault.OwnedByLabelValue {
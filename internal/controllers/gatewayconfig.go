@@ -153,7 +153,7 @@ func (r *gatewayConfigReconciler) Reconcile(ctx context.Context, req reconcile.R
 	store.AuthSecrets.Reset(authSecretList)
 	r.log.V(2).Info("reset AuthSecret store", "secrets", store.AuthSecrets.String())
 
-	r.eventCh <- event.NewEventRender()
+	r.eventCh <- event.NewEventRender(req.NamespacedName)
 
 	return reconcile.Result{}, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	// "errors"
 	"fmt"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -102,8 +103,10 @@ func (r *nodeReconciler) Reconcile(ctx context.Context, req reconcile.Request) (
 	if found && stored != nil && store.GetNamespacedName(stored) == req.NamespacedName {
 		log.V(1).Info("modifying locally stored node")
 
-		oldAddr := store.GetExternalAddress(stored)
-		newAddr := store.GetExternalAddress(&node)
+		// consider all external addresses so that we catch changes to the address of
+		// either IP family in dual-stack clusters
+		oldAddr := strings.Join(store.GetExternalAddresses(stored), ",")
+		newAddr := strings.Join(store.GetExternalAddresses(&node), ",")
 
 		// address remains the same
 		if oldAddr == newAddr {
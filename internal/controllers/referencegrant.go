@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/l7mp/stunner-gateway-operator/internal/event"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+)
+
+type referenceGrantReconciler struct {
+	client.Client
+	eventCh chan event.Event
+	log     logr.Logger
+}
+
+// RegisterReferenceGrantController registers a reconciler for ReferenceGrant objects.
+func RegisterReferenceGrantController(mgr manager.Manager, ch chan event.Event, log logr.Logger) error {
+	r := &referenceGrantReconciler{
+		Client:  mgr.GetClient(),
+		eventCh: ch,
+		log:     log.WithName("referencegrant-controller"),
+	}
+
+	c, err := controller.New("referencegrant", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	r.log.Info("created referencegrant controller")
+
+	if err := c.Watch(
+		source.Kind(mgr.GetCache(), &gwapiv1b1.ReferenceGrant{}),
+		&handler.EnqueueRequestForObject{},
+		// trigger when the ReferenceGrant spec changes
+		predicate.GenerationChangedPredicate{},
+	); err != nil {
+		return err
+	}
+	r.log.Info("watching referencegrant objects")
+
+	return nil
+}
+
+// Reconcile handles an update to a ReferenceGrant.
+func (r *referenceGrantReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := r.log.WithValues("resource", req.String())
+	log.Info("reconciling")
+
+	grantList := []client.Object{}
+
+	grants := &gwapiv1b1.ReferenceGrantList{}
+	if err := r.List(ctx, grants); err != nil {
+		r.log.Info("no ReferenceGrants found")
+		return reconcile.Result{}, err
+	}
+
+	for _, grant := range grants.Items {
+		grant := grant
+		r.log.V(1).Info("processing ReferenceGrant", "name", store.GetObjectKey(&grant))
+
+		grantList = append(grantList, &grant)
+	}
+
+	store.ReferenceGrants.Reset(grantList)
+	r.log.V(2).Info("reset ReferenceGrant store", "reference-grants", store.ReferenceGrants.String())
+
+	r.eventCh <- event.NewEventRender(req.NamespacedName)
+
+	return reconcile.Result{}, nil
+}
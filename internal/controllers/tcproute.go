@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/l7mp/stunner-gateway-operator/internal/event"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+)
+
+const (
+	serviceTCPRouteIndex       = "serviceTCPRouteIndex"
+	staticServiceTCPRouteIndex = "staticServiceTCPRouteIndex"
+)
+
+// RegisterTCPRouteController registers a controller for TCPRoute objects. The reconciler is
+// shared with the UDPRoute controller, which also watches the Services, Endpoints and
+// StaticServices referenced by TCPRoutes. Must be registered before the UDPRoute controller so
+// that the TCPRoute indices are available for the backend watchers.
+func RegisterTCPRouteController(mgr manager.Manager, ch chan event.Event, log logr.Logger) error {
+	ctx := context.Background()
+	r := &routeReconciler{
+		Client:  mgr.GetClient(),
+		eventCh: ch,
+		log:     log.WithName("tcproute-controller"),
+	}
+
+	c, err := controller.New("tcproute", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	r.log.Info("created tcproute controller")
+
+	// watch TCPRoute objects
+	if err := c.Watch(
+		source.Kind(mgr.GetCache(), &gwapiv1a2.TCPRoute{}),
+		&handler.EnqueueRequestForObject{},
+		predicate.GenerationChangedPredicate{},
+	); err != nil {
+		return err
+	}
+	r.log.Info("watching tcproute objects")
+
+	// index TCPRoute objects as per the referenced Services
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &gwapiv1a2.TCPRoute{},
+		serviceTCPRouteIndex, serviceTCPRouteIndexFunc); err != nil {
+		return err
+	}
+
+	// index TCPRoute objects as per the referenced StaticServices
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &gwapiv1a2.TCPRoute{},
+		staticServiceTCPRouteIndex, staticServiceTCPRouteIndexFunc); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func serviceTCPRouteIndexFunc(o client.Object) []string {
+	tcproute := o.(*gwapiv1a2.TCPRoute)
+	var services []string
+
+	for _, rule := range tcproute.Spec.Rules {
+		for _, backend := range rule.BackendRefs {
+			if !store.IsReferenceService(&backend) {
+				continue
+			}
+
+			if backend.Kind == nil || string(*backend.Kind) == "Service" {
+				// if no explicit Service namespace is provided, use the TCPRoute
+				// namespace to lookup the provided Service
+				namespace := tcproute.GetNamespace()
+				if backend.Namespace != nil {
+					namespace = string(*backend.Namespace)
+				}
+
+				services = append(services,
+					types.NamespacedName{
+						Namespace: namespace,
+						Name:      string(backend.Name),
+					}.String(),
+				)
+			}
+		}
+	}
+
+	return services
+}
+
+func staticServiceTCPRouteIndexFunc(o client.Object) []string {
+	tcproute := o.(*gwapiv1a2.TCPRoute)
+	var staticServices []string
+
+	for _, rule := range tcproute.Spec.Rules {
+		for _, backend := range rule.BackendRefs {
+			backend := backend
+
+			if !store.IsReferenceStaticService(&backend) {
+				continue
+			}
+
+			// if no explicit StaticService namespace is provided, use the TCPRoute
+			// namespace to lookup the provided static service
+			namespace := tcproute.GetNamespace()
+			if backend.Namespace != nil {
+				namespace = string(*backend.Namespace)
+			}
+
+			staticServices = append(staticServices,
+				types.NamespacedName{
+					Namespace: namespace,
+					Name:      string(backend.Name),
+				}.String(),
+			)
+		}
+	}
+
+	return staticServices
+}
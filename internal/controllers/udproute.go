@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
@@ -30,12 +31,13 @@ import (
 )
 
 const (
-	serviceTCPRouteIndex       = "serviceTCPRouteIndex"
 	serviceUDPRouteIndex       = "serviceUDPRouteIndex"
 	staticServiceUDPRouteIndex = "staticServiceUDPRouteIndex"
 )
 
-type udpRouteReconciler struct {
+// routeReconciler is shared between the UDPRoute and the TCPRoute controllers: each reconcile
+// loads all routes of both kinds, along with the backends thereof, into the local store
+type routeReconciler struct {
 	client.Client
 	eventCh chan event.Event
 	log     logr.Logger
@@ -43,7 +45,7 @@ type udpRouteReconciler struct {
 
 func RegisterUDPRouteController(mgr manager.Manager, ch chan event.Event, log logr.Logger) error {
 	ctx := context.Background()
-	r := &udpRouteReconciler{
+	r := &routeReconciler{
 		Client:  mgr.GetClient(),
 		eventCh: ch,
 		log:     log.WithName("udproute-controller"),
@@ -106,16 +108,16 @@ func RegisterUDPRouteController(mgr manager.Manager, ch chan event.Event, log lo
 	}
 	r.log.Info("watching service objects")
 
-	// watch EndPoints object references by one of the ref'd Services
+	// watch EndpointSlice objects of the ref'd Services
 	if config.EnableEndpointDiscovery {
 		if err := c.Watch(
-			source.Kind(mgr.GetCache(), &corev1.Endpoints{}),
+			source.Kind(mgr.GetCache(), &discoveryv1.EndpointSlice{}),
 			&handler.EnqueueRequestForObject{},
 			predicate.NewPredicateFuncs(r.validateBackendForReconcile),
 		); err != nil {
 			return err
 		}
-		r.log.Info("watching endpoint objects")
+		r.log.Info("watching endpointslice objects")
 	}
 
 	// watch StaticService objects referenced by one of our UDPRoutes
@@ -131,16 +133,17 @@ func RegisterUDPRouteController(mgr manager.Manager, ch chan event.Event, log lo
 	return nil
 }
 
-// Reconcile handles an update to a UDPRoute or a Service/Endpoints referenced by an UDPRoute.
-func (r *udpRouteReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+// Reconcile handles an update to a UDPRoute/TCPRoute or a Service/Endpoints referenced by a route.
+func (r *routeReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
 	log := r.log.WithValues("resource", req.String())
 	log.Info("reconciling")
 
-	routeList := []client.Object{}
+	udpRouteList := []client.Object{}
+	tcpRouteList := []client.Object{}
 	namespaceList := []client.Object{}
 	svcList := []client.Object{}
 	ssvcList := []client.Object{}
-	endpointsList := []client.Object{}
+	endpointSliceList := []client.Object{}
 
 	// find all related-services that we use as LoadBalancers for Gateways (i.e., have label
 	// "app:stunner")
@@ -154,108 +157,138 @@ func (r *udpRouteReconciler) Reconcile(ctx context.Context, req reconcile.Reques
 	}
 
 	// find all UDPRoutes
-	routes := &gwapiv1a2.UDPRouteList{}
-	if err := r.List(ctx, routes); err != nil {
+	udpRoutes := &gwapiv1a2.UDPRouteList{}
+	if err := r.List(ctx, udpRoutes); err != nil {
 		r.log.Info("no UDPRoutes found")
 		return reconcile.Result{}, err
 	}
 
-	for _, udproute := range routes.Items {
+	for _, udproute := range udpRoutes.Items {
 		udproute := udproute
 		r.log.V(1).Info("processing UDPRoute", "name", store.GetObjectKey(&udproute))
 
-		routeList = append(routeList, &udproute)
+		udpRouteList = append(udpRouteList, &udproute)
 
 		for _, rule := range udproute.Spec.Rules {
-			for _, ref := range rule.BackendRefs {
-				ref := ref
-
-				// is this a static service?
-				if store.IsReferenceStaticService(&ref) {
-					if svc := r.getStaticServiceForBackend(ctx, &udproute, &ref); svc != nil {
-						ssvcList = append(ssvcList, svc)
-					}
-					continue
-				}
+			r.collectBackends(ctx, &udproute, rule.BackendRefs, &svcList, &ssvcList, &endpointSliceList)
+		}
 
-				if store.IsReferenceService(&ref) {
-					if svc := r.getServiceForBackend(ctx, &udproute, &ref); svc != nil {
-						svcList = append(svcList, svc)
-					}
-
-					if config.EnableEndpointDiscovery {
-						if e := r.getEndpointsForBackend(ctx, &udproute, &ref); e != nil {
-							endpointsList = append(endpointsList, e)
-						}
-					}
-					continue
-				}
-			}
+		if namespace := r.getNamespaceForRoute(ctx, &udproute); namespace != nil {
+			namespaceList = append(namespaceList, namespace)
 		}
+	}
 
-		nsName := udproute.GetNamespace()
-		r.log.V(2).Info("looking for the namespace of UDPRoute", "name", nsName)
-		namespace := corev1.Namespace{}
-		if err := r.Get(ctx, types.NamespacedName{Name: nsName}, &namespace); err != nil {
-			r.log.Error(err, "error getting namespace for udproute", "udproute",
-				store.GetObjectKey(&udproute), "namespace-name", nsName)
-			continue
+	// find all TCPRoutes
+	tcpRoutes := &gwapiv1a2.TCPRouteList{}
+	if err := r.List(ctx, tcpRoutes); err != nil {
+		r.log.Info("no TCPRoutes found")
+		return reconcile.Result{}, err
+	}
+
+	for _, tcproute := range tcpRoutes.Items {
+		tcproute := tcproute
+		r.log.V(1).Info("processing TCPRoute", "name", store.GetObjectKey(&tcproute))
+
+		tcpRouteList = append(tcpRouteList, &tcproute)
+
+		for _, rule := range tcproute.Spec.Rules {
+			r.collectBackends(ctx, &tcproute, rule.BackendRefs, &svcList, &ssvcList, &endpointSliceList)
 		}
 
-		namespaceList = append(namespaceList, &namespace)
+		if namespace := r.getNamespaceForRoute(ctx, &tcproute); namespace != nil {
+			namespaceList = append(namespaceList, namespace)
+		}
 	}
 
-	store.UDPRoutes.Reset(routeList)
+	store.UDPRoutes.Reset(udpRouteList)
 	r.log.V(2).Info("reset UDPRoute store", "udproutes", store.UDPRoutes.String())
 
+	store.TCPRoutes.Reset(tcpRouteList)
+	r.log.V(2).Info("reset TCPRoute store", "tcproutes", store.TCPRoutes.String())
+
 	store.Namespaces.Reset(namespaceList)
 	r.log.V(2).Info("reset Namespace store", "namespaces", store.Namespaces.String())
 
 	store.Services.Reset(svcList)
 	r.log.V(2).Info("reset Service store", "services", store.Services.String())
 
-	store.Endpoints.Reset(endpointsList)
-	r.log.V(2).Info("reset Endpoints store", "endpoints", store.Endpoints.String())
+	store.EndpointSlices.Reset(endpointSliceList)
+	r.log.V(2).Info("reset EndpointSlice store", "endpointslices", store.EndpointSlices.String())
 
 	store.StaticServices.Reset(ssvcList)
 	r.log.V(2).Info("reset StaticService store", "static-services", store.StaticServices.String())
 
-	r.eventCh <- event.NewEventRender()
+	r.eventCh <- event.NewEventRender(req.NamespacedName)
 
 	return reconcile.Result{}, nil
 }
 
-// validateBackendForReconcile checks whether the Service belongs to a valid UDPRoute.
-func (r *udpRouteReconciler) validateBackendForReconcile(o client.Object) bool {
-	// are we given a service or an endpoints object?
+// collectBackends loads the Services, StaticServices and EndpointSlices referenced by a route rule
+func (r *routeReconciler) collectBackends(ctx context.Context, ro client.Object, refs []gwapiv1b1.BackendRef, svcList, ssvcList, endpointSliceList *[]client.Object) {
+	for _, ref := range refs {
+		ref := ref
+
+		// is this a static service?
+		if store.IsReferenceStaticService(&ref) {
+			if svc := r.getStaticServiceForBackend(ctx, ro, &ref); svc != nil {
+				*ssvcList = append(*ssvcList, svc)
+			}
+			continue
+		}
+
+		if store.IsReferenceService(&ref) {
+			if svc := r.getServiceForBackend(ctx, ro, &ref); svc != nil {
+				*svcList = append(*svcList, svc)
+			}
+
+			if config.EnableEndpointDiscovery {
+				*endpointSliceList = append(*endpointSliceList,
+					r.getEndpointSlicesForBackend(ctx, ro, &ref)...)
+			}
+			continue
+		}
+	}
+}
+
+// getNamespaceForRoute finds the Namespace of a route
+func (r *routeReconciler) getNamespaceForRoute(ctx context.Context, ro client.Object) *corev1.Namespace {
+	nsName := ro.GetNamespace()
+	r.log.V(2).Info("looking for the namespace of route", "name", nsName)
+	namespace := corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: nsName}, &namespace); err != nil {
+		r.log.Error(err, "error getting namespace for route", "route",
+			store.GetObjectKey(ro), "namespace-name", nsName)
+		return nil
+	}
+
+	return &namespace
+}
+
+// validateBackendForReconcile checks whether the Service belongs to a valid UDPRoute or TCPRoute.
+func (r *routeReconciler) validateBackendForReconcile(o client.Object) bool {
+	// are we given a service or an endpointslice object?
 	key := ""
 	if svc, ok := o.(*corev1.Service); ok {
 		key = store.GetObjectKey(svc)
-	} else if e, ok := o.(*corev1.Endpoints); ok {
-		// endpoints and services are of the same name
-		key = store.GetObjectKey(e)
+	} else if e, ok := o.(*discoveryv1.EndpointSlice); ok {
+		// endpointslices refer to their service via a label
+		name, ok := e.GetLabels()[discoveryv1.LabelServiceName]
+		if !ok {
+			return false
+		}
+		key = store.GetObjectKey(&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: e.GetNamespace(), Name: name},
+		})
 	} else {
 		return false
 	}
 
 	// find the routes referring to this service
-	routeList := &gwapiv1a2.UDPRouteList{}
-	if err := r.List(context.Background(), routeList, &client.ListOptions{
-		FieldSelector: fields.OneTermEqualSelector(serviceUDPRouteIndex, key),
-	}); err != nil {
-		r.log.Error(err, "unable to find associated udproutes", "service", key)
-		return false
-	}
-
-	if len(routeList.Items) == 0 {
-		return false
-	}
-
-	return true
+	return r.hasRouteForIndex(serviceUDPRouteIndex, serviceTCPRouteIndex, key)
 }
 
-// validateStaticServiceForReconcile checks whether a Static Service belongs to a valid UDPRoute.
-func (r *udpRouteReconciler) validateStaticServiceForReconcile(o client.Object) bool {
+// validateStaticServiceForReconcile checks whether a Static Service belongs to a valid UDPRoute or TCPRoute.
+func (r *routeReconciler) validateStaticServiceForReconcile(o client.Object) bool {
 	// are we given a service or an endpoints object?
 	key := ""
 	if svc, ok := o.(*stnrv1a1.StaticService); ok {
@@ -265,28 +298,42 @@ func (r *udpRouteReconciler) validateStaticServiceForReconcile(o client.Object)
 	}
 
 	// find the routes referring to this static service
-	routeList := &gwapiv1a2.UDPRouteList{}
-	if err := r.List(context.Background(), routeList, &client.ListOptions{
-		FieldSelector: fields.OneTermEqualSelector(staticServiceUDPRouteIndex, key),
+	return r.hasRouteForIndex(staticServiceUDPRouteIndex, staticServiceTCPRouteIndex, key)
+}
+
+// hasRouteForIndex checks whether there is at least one UDPRoute or TCPRoute that refers to the
+// object with the given key, using the given UDPRoute and TCPRoute indices
+func (r *routeReconciler) hasRouteForIndex(udpIndex, tcpIndex, key string) bool {
+	udpRouteList := &gwapiv1a2.UDPRouteList{}
+	if err := r.List(context.Background(), udpRouteList, &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(udpIndex, key),
 	}); err != nil {
-		r.log.Error(err, "unable to find associated udproutes", "static-service", key)
+		r.log.Error(err, "unable to find associated udproutes", "index", udpIndex, "key", key)
 		return false
 	}
 
-	if len(routeList.Items) == 0 {
+	if len(udpRouteList.Items) > 0 {
+		return true
+	}
+
+	tcpRouteList := &gwapiv1a2.TCPRouteList{}
+	if err := r.List(context.Background(), tcpRouteList, &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(tcpIndex, key),
+	}); err != nil {
+		r.log.Error(err, "unable to find associated tcproutes", "index", tcpIndex, "key", key)
 		return false
 	}
 
-	return true
+	return len(tcpRouteList.Items) > 0
 }
 
 // getServiceForBackend finds the Service associated with a backendRef
-func (r *udpRouteReconciler) getServiceForBackend(ctx context.Context, udproute *gwapiv1a2.UDPRoute, ref *gwapiv1b1.BackendRef) *corev1.Service {
+func (r *routeReconciler) getServiceForBackend(ctx context.Context, ro client.Object, ref *gwapiv1b1.BackendRef) *corev1.Service {
 	svc := corev1.Service{}
 
-	// if no explicit Service namespace is provided, use the UDPRoute namespace to lookup the
+	// if no explicit Service namespace is provided, use the route namespace to lookup the
 	// Service
-	namespace := udproute.GetNamespace()
+	namespace := ro.GetNamespace()
 	if ref.Namespace != nil {
 		namespace = string(*ref.Namespace)
 	}
@@ -302,8 +349,8 @@ func (r *udpRouteReconciler) getServiceForBackend(ctx context.Context, udproute
 			return nil
 		}
 
-		r.log.Info("no Service found for UDPRoute backend", "udproute",
-			store.GetObjectKey(udproute), "namespace", namespace,
+		r.log.Info("no Service found for route backend", "route",
+			store.GetObjectKey(ro), "namespace", namespace,
 			"name", string(ref.Name))
 		return nil
 	}
@@ -311,44 +358,49 @@ func (r *udpRouteReconciler) getServiceForBackend(ctx context.Context, udproute
 	return &svc
 }
 
-// getEndpointsForBackend finds the Endpoints associated with a backendRef
-func (r *udpRouteReconciler) getEndpointsForBackend(ctx context.Context, udproute *gwapiv1a2.UDPRoute, ref *gwapiv1b1.BackendRef) *corev1.Endpoints {
-	e := corev1.Endpoints{}
+// getEndpointSlicesForBackend finds all the EndpointSlices associated with a backendRef: large
+// Services may be split into many EndpointSlices
+func (r *routeReconciler) getEndpointSlicesForBackend(ctx context.Context, ro client.Object, ref *gwapiv1b1.BackendRef) []client.Object {
+	ret := []client.Object{}
 
-	// if no explicit Endpoints namespace is provided, use the UDPRoute namespace to lookup the
-	// Endpoints
-	namespace := udproute.GetNamespace()
+	// if no explicit Service namespace is provided, use the route namespace to lookup the
+	// EndpointSlices
+	namespace := ro.GetNamespace()
 	if ref.Namespace != nil {
 		namespace = string(*ref.Namespace)
 	}
 
-	if err := r.Get(ctx,
-		types.NamespacedName{Namespace: namespace, Name: string(ref.Name)},
-		&e,
+	esList := discoveryv1.EndpointSliceList{}
+	if err := r.List(ctx, &esList, client.InNamespace(namespace),
+		client.MatchingLabels{discoveryv1.LabelServiceName: string(ref.Name)},
 	); err != nil {
 		// not fatal
-		if !apierrors.IsNotFound(err) {
-			r.log.Error(err, "error getting Endpoints", "namespace", namespace,
-				"name", string(ref.Name))
-			return nil
-		}
+		r.log.Error(err, "error listing EndpointSlices", "namespace", namespace,
+			"service", string(ref.Name))
+		return ret
+	}
 
-		r.log.Info("no Endpoints found for UDPRoute backend", "udproute",
-			store.GetObjectKey(udproute), "namespace", namespace,
-			"name", string(ref.Name))
-		return nil
+	if len(esList.Items) == 0 {
+		r.log.Info("no EndpointSlices found for route backend", "route",
+			store.GetObjectKey(ro), "namespace", namespace,
+			"service", string(ref.Name))
+		return ret
+	}
+
+	for i := range esList.Items {
+		ret = append(ret, &esList.Items[i])
 	}
 
-	return &e
+	return ret
 }
 
 // getStaticServiceForBackend finds the StaticService associated with a backendRef
-func (r *udpRouteReconciler) getStaticServiceForBackend(ctx context.Context, udproute *gwapiv1a2.UDPRoute, ref *gwapiv1b1.BackendRef) *stnrv1a1.StaticService {
+func (r *routeReconciler) getStaticServiceForBackend(ctx context.Context, ro client.Object, ref *gwapiv1b1.BackendRef) *stnrv1a1.StaticService {
 	svc := stnrv1a1.StaticService{}
 
-	// if no explicit StaticService namespace is provided, use the UDPRoute namespace to lookup the
+	// if no explicit StaticService namespace is provided, use the route namespace to lookup the
 	// StaticService
-	namespace := udproute.GetNamespace()
+	namespace := ro.GetNamespace()
 	if ref.Namespace != nil {
 		namespace = string(*ref.Namespace)
 	}
@@ -364,8 +416,8 @@ func (r *udpRouteReconciler) getStaticServiceForBackend(ctx context.Context, udp
 			return nil
 		}
 
-		r.log.Info("no StaticService found for UDPRoute backend", "udproute",
-			store.GetObjectKey(udproute), "namespace", namespace,
+		r.log.Info("no StaticService found for route backend", "route",
+			store.GetObjectKey(ro), "namespace", namespace,
 			"name", string(ref.Name))
 		return nil
 	}
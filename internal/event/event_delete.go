@@ -15,6 +15,7 @@ const (
 	EventKindGatewayConfig
 	EventKindGateway
 	EventKindUDPRoute
+	EventKindTCPRoute
 	EventKindService
 	EventKindNode
 	EventKindEndpoint
@@ -32,6 +33,8 @@ func (a EventKind) String() string {
 		return "EventKindGateway"
 	case EventKindUDPRoute:
 		return "UDPRoute"
+	case EventKindTCPRoute:
+		return "TCPRoute"
 	case EventKindService:
 		return "Service"
 	case EventKindNode:
@@ -1,26 +1,62 @@
 package event
 
-// import (
-// "fmt"
-// )
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+)
 
 // render event
 
 type EventRender struct {
 	Type EventType
-	// Reason string
-	// Params map[string]string
+	// Objects holds the keys of the objects whose change triggered the render event. Nil
+	// means that anything may have changed and the whole configuration must be re-rendered.
+	Objects []types.NamespacedName
 }
 
-// NewEvent returns an empty event
-func NewEventRender() *EventRender {
-	return &EventRender{Type: EventTypeRender}
+// NewEventRender returns a new render event for the given changed objects. Calling it without
+// any object key requests a full render.
+func NewEventRender(objects ...types.NamespacedName) *EventRender {
+	e := &EventRender{Type: EventTypeRender}
+	if len(objects) > 0 {
+		e.Objects = append([]types.NamespacedName{}, objects...)
+	}
+	return e
 }
 
 func (e *EventRender) GetType() EventType {
 	return e.Type
 }
 
+// IsFull returns true if the event requests a full render.
+func (e *EventRender) IsFull() bool {
+	return e.Objects == nil
+}
+
+// Merge adds the changed objects of another render event to the event. The result is a full
+// render event if any of the events requests a full render.
+func (e *EventRender) Merge(other *EventRender) {
+	if e.IsFull() || other.IsFull() {
+		e.Objects = nil
+		return
+	}
+
+	seen := make(map[types.NamespacedName]bool, len(e.Objects))
+	for _, n := range e.Objects {
+		seen[n] = true
+	}
+	for _, n := range other.Objects {
+		if !seen[n] {
+			seen[n] = true
+			e.Objects = append(e.Objects, n)
+		}
+	}
+}
+
 func (e *EventRender) String() string {
-	return e.Type.String()
+	if e.IsFull() {
+		return e.Type.String()
+	}
+	return fmt.Sprintf("%s: objects: %d", e.Type.String(), len(e.Objects))
 }
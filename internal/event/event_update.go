@@ -8,12 +8,21 @@ import (
 
 // render event
 type UpdateConf struct {
-	GatewayClasses *store.GatewayClassStore
-	Gateways       *store.GatewayStore
-	UDPRoutes      *store.UDPRouteStore
-	Services       *store.ServiceStore
-	ConfigMaps     *store.ConfigMapStore
-	Deployments    *store.DeploymentStore
+	GatewayClasses  *store.GatewayClassStore
+	GatewayConfigs  *store.GatewayConfigStore
+	Gateways        *store.GatewayStore
+	UDPRoutes       *store.UDPRouteStore
+	TCPRoutes       *store.TCPRouteStore
+	Services        *store.ServiceStore
+	ConfigMaps      *store.ConfigMapStore
+	Secrets         *store.SecretStore
+	Deployments     *store.DeploymentStore
+	DaemonSets      *store.DaemonSetStore
+	HPAs            *store.HorizontalPodAutoscalerStore
+	PDBs            *store.PodDisruptionBudgetStore
+	ServiceAccounts *store.ServiceAccountStore
+	Roles           *store.RoleStore
+	RoleBindings    *store.RoleBindingStore
 }
 
 type EventUpdate struct {
@@ -21,6 +30,10 @@ type EventUpdate struct {
 	UpsertQueue UpdateConf
 	DeleteQueue UpdateConf
 	Generation  int
+	// Incremental is set if the update contains only the changed objects. Consumers that keep
+	// the full state (e.g., the config discovery server) must not remove objects missing from
+	// an incremental update, removed objects are listed in the delete queue instead.
+	Incremental bool
 }
 
 // NewEvent returns an empty event
@@ -28,36 +41,104 @@ func NewEventUpdate(generation int) *EventUpdate {
 	return &EventUpdate{
 		Type: EventTypeUpdate,
 		UpsertQueue: UpdateConf{
-			GatewayClasses: store.NewGatewayClassStore(),
-			Gateways:       store.NewGatewayStore(),
-			UDPRoutes:      store.NewUDPRouteStore(),
-			Services:       store.NewServiceStore(),
-			ConfigMaps:     store.NewConfigMapStore(),
-			Deployments:    store.NewDeploymentStore(),
+			GatewayClasses:  store.NewGatewayClassStore(),
+			GatewayConfigs:  store.NewGatewayConfigStore(),
+			Gateways:        store.NewGatewayStore(),
+			UDPRoutes:       store.NewUDPRouteStore(),
+			TCPRoutes:       store.NewTCPRouteStore(),
+			Services:        store.NewServiceStore(),
+			ConfigMaps:      store.NewConfigMapStore(),
+			Secrets:         store.NewSecretStore(),
+			Deployments:     store.NewDeploymentStore(),
+			DaemonSets:      store.NewDaemonSetStore(),
+			HPAs:            store.NewHorizontalPodAutoscalerStore(),
+			PDBs:            store.NewPodDisruptionBudgetStore(),
+			ServiceAccounts: store.NewServiceAccountStore(),
+			Roles:           store.NewRoleStore(),
+			RoleBindings:    store.NewRoleBindingStore(),
 		},
 		DeleteQueue: UpdateConf{
-			GatewayClasses: store.NewGatewayClassStore(),
-			Gateways:       store.NewGatewayStore(),
-			UDPRoutes:      store.NewUDPRouteStore(),
-			Services:       store.NewServiceStore(),
-			ConfigMaps:     store.NewConfigMapStore(),
-			Deployments:    store.NewDeploymentStore(),
+			GatewayClasses:  store.NewGatewayClassStore(),
+			GatewayConfigs:  store.NewGatewayConfigStore(),
+			Gateways:        store.NewGatewayStore(),
+			UDPRoutes:       store.NewUDPRouteStore(),
+			TCPRoutes:       store.NewTCPRouteStore(),
+			Services:        store.NewServiceStore(),
+			ConfigMaps:      store.NewConfigMapStore(),
+			Secrets:         store.NewSecretStore(),
+			Deployments:     store.NewDeploymentStore(),
+			DaemonSets:      store.NewDaemonSetStore(),
+			HPAs:            store.NewHorizontalPodAutoscalerStore(),
+			PDBs:            store.NewPodDisruptionBudgetStore(),
+			ServiceAccounts: store.NewServiceAccountStore(),
+			Roles:           store.NewRoleStore(),
+			RoleBindings:    store.NewRoleBindingStore(),
 		},
 		Generation: generation,
 	}
 }
 
+// stores returns the object stores of an update queue.
+func (q *UpdateConf) stores() []store.Store {
+	return []store.Store{
+		q.GatewayClasses, q.GatewayConfigs, q.Gateways, q.UDPRoutes, q.TCPRoutes, q.Services,
+		q.ConfigMaps, q.Secrets, q.Deployments, q.DaemonSets, q.HPAs, q.PDBs, q.ServiceAccounts,
+		q.Roles, q.RoleBindings,
+	}
+}
+
+// Merge coalesces a later update into the update. An object upserted or deleted in the later
+// update overrides any earlier operation on the same object. The merged update takes the
+// generation of the later update and it is incremental if any of the updates is incremental.
+func (e *EventUpdate) Merge(later *EventUpdate) {
+	upserts, deletes := e.UpsertQueue.stores(), e.DeleteQueue.stores()
+	laterUpserts, laterDeletes := later.UpsertQueue.stores(), later.DeleteQueue.stores()
+
+	for i := range upserts {
+		for _, o := range laterUpserts[i].Objects() {
+			upserts[i].Upsert(o)
+			deletes[i].Remove(store.GetNamespacedName(o))
+		}
+		for _, o := range laterDeletes[i].Objects() {
+			deletes[i].Upsert(o)
+			upserts[i].Remove(store.GetNamespacedName(o))
+		}
+	}
+
+	if later.Generation > e.Generation {
+		e.Generation = later.Generation
+	}
+	e.Incremental = e.Incremental || later.Incremental
+}
+
+// Len returns the number of objects in the upsert and the delete queues.
+func (e *EventUpdate) Len() int {
+	n := 0
+	for _, s := range append(e.UpsertQueue.stores(), e.DeleteQueue.stores()...) {
+		n += s.Len()
+	}
+	return n
+}
+
 func (e *EventUpdate) GetType() EventType {
 	return e.Type
 }
 
 func (e *EventUpdate) String() string {
-	return fmt.Sprintf("%s (gen: %d): upsert-queue: gway-cls: %d, gway: %d, route: %d, svc: %d, confmap: %d, dp: %d / "+
-		"delete-queue: gway-cls: %d, gway: %d, route: %d, svc: %d, confmap: %d, dp: %d", e.Type.String(),
-		e.Generation, e.UpsertQueue.GatewayClasses.Len(), e.UpsertQueue.Gateways.Len(),
-		e.UpsertQueue.UDPRoutes.Len(), e.UpsertQueue.Services.Len(),
-		e.UpsertQueue.ConfigMaps.Len(), e.UpsertQueue.Deployments.Len(),
-		e.DeleteQueue.GatewayClasses.Len(), e.DeleteQueue.Gateways.Len(),
-		e.DeleteQueue.UDPRoutes.Len(), e.DeleteQueue.Services.Len(),
-		e.DeleteQueue.ConfigMaps.Len(), e.DeleteQueue.Deployments.Len())
+	return fmt.Sprintf("%s (gen: %d): upsert-queue: gway-cls: %d, gway-conf: %d, gway: %d, udp-route: %d, "+
+		"tcp-route: %d, svc: %d, confmap: %d, secret: %d, dp: %d, ds: %d, hpa: %d, pdb: %d, sa: %d, role: %d, "+
+		"rolebinding: %d / delete-queue: gway-cls: %d, gway-conf: %d, gway: %d, udp-route: %d, "+
+		"tcp-route: %d, svc: %d, confmap: %d, secret: %d, dp: %d, ds: %d, hpa: %d, pdb: %d, sa: %d, role: %d, "+
+		"rolebinding: %d", e.Type.String(),
+		e.Generation, e.UpsertQueue.GatewayClasses.Len(), e.UpsertQueue.GatewayConfigs.Len(),
+		e.UpsertQueue.Gateways.Len(),
+		e.UpsertQueue.UDPRoutes.Len(), e.UpsertQueue.TCPRoutes.Len(), e.UpsertQueue.Services.Len(),
+		e.UpsertQueue.ConfigMaps.Len(), e.UpsertQueue.Secrets.Len(), e.UpsertQueue.Deployments.Len(), e.UpsertQueue.DaemonSets.Len(),
+		e.UpsertQueue.HPAs.Len(), e.UpsertQueue.PDBs.Len(), e.UpsertQueue.ServiceAccounts.Len(),
+		e.UpsertQueue.Roles.Len(), e.UpsertQueue.RoleBindings.Len(),
+		e.DeleteQueue.GatewayClasses.Len(), e.DeleteQueue.GatewayConfigs.Len(), e.DeleteQueue.Gateways.Len(),
+		e.DeleteQueue.UDPRoutes.Len(), e.DeleteQueue.TCPRoutes.Len(), e.DeleteQueue.Services.Len(),
+		e.DeleteQueue.ConfigMaps.Len(), e.DeleteQueue.Secrets.Len(), e.DeleteQueue.Deployments.Len(), e.DeleteQueue.DaemonSets.Len(),
+		e.DeleteQueue.HPAs.Len(), e.DeleteQueue.PDBs.Len(), e.DeleteQueue.ServiceAccounts.Len(),
+		e.DeleteQueue.Roles.Len(), e.DeleteQueue.RoleBindings.Len())
 }
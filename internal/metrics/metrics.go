@@ -0,0 +1,149 @@
+// Package metrics defines the Prometheus metrics exported by the operator. All metrics are
+// registered on the controller-runtime metrics registry so they are served on the metrics
+// endpoint of the manager.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "stunner_gateway_operator"
+
+// Label values for the updater metrics.
+const (
+	OperationUpsert = "upsert"
+	OperationDelete = "delete"
+	ResultSuccess   = "success"
+	ResultError     = "error"
+	ResultSkipped   = "skipped"
+)
+
+var (
+	// RenderTotal counts the render runs per dataplane mode.
+	RenderTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "renderer",
+			Name:      "renders_total",
+			Help:      "Number of render runs per dataplane mode.",
+		},
+		[]string{"mode"},
+	)
+
+	// RenderDuration measures the time it takes to render the configs per dataplane mode.
+	RenderDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "renderer",
+			Name:      "render_duration_seconds",
+			Help:      "Duration of render runs per dataplane mode.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+		},
+		[]string{"mode"},
+	)
+
+	// ThrottledRendersTotal counts the render requests suppressed by the operator's rate
+	// limiter.
+	ThrottledRendersTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "operator",
+			Name:      "throttled_renders_total",
+			Help:      "Number of render requests throttled by the operator.",
+		},
+	)
+
+	// UpdaterOperationsTotal counts the upserts and deletes run by the updater per object
+	// kind and result.
+	UpdaterOperationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "updater",
+			Name:      "operations_total",
+			Help:      "Number of upsert and delete operations per object kind and result, including the upserts skipped because the object has not changed.",
+		},
+		[]string{"kind", "operation", "result"},
+	)
+
+	// UpdaterRetriesTotal counts the operations retried by the updater per object kind.
+	UpdaterRetriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "updater",
+			Name:      "retries_total",
+			Help:      "Number of retried upsert and delete operations per object kind.",
+		},
+		[]string{"kind"},
+	)
+
+	// UpdaterFailedObjects is the number of objects the updater could not write even after
+	// retrying.
+	UpdaterFailedObjects = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "updater",
+			Name:      "failed_objects",
+			Help:      "Number of objects whose last update failed after retries.",
+		},
+	)
+
+	// CDSClients is the number of clients connected to the config discovery server.
+	CDSClients = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "cds",
+			Name:      "clients",
+			Help:      "Number of clients connected to the config discovery server.",
+		},
+	)
+
+	// CDSConfigPushesTotal counts the configs pushed to config discovery clients.
+	CDSConfigPushesTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cds",
+			Name:      "config_pushes_total",
+			Help:      "Number of configs pushed to config discovery clients.",
+		},
+	)
+
+	// CDSConfigPushFailuresTotal counts the failed config pushes to config discovery clients.
+	CDSConfigPushFailuresTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cds",
+			Name:      "config_push_failures_total",
+			Help:      "Number of failed config pushes to config discovery clients.",
+		},
+	)
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		RenderTotal,
+		RenderDuration,
+		ThrottledRendersTotal,
+		UpdaterOperationsTotal,
+		UpdaterRetriesTotal,
+		UpdaterFailedObjects,
+		CDSClients,
+		CDSConfigPushesTotal,
+		CDSConfigPushFailuresTotal,
+	)
+}
+
+// ObserveUpdate records the result of an updater operation.
+func ObserveUpdate(kind, operation string, err error) {
+	result := ResultSuccess
+	if err != nil {
+		result = ResultError
+	}
+	UpdaterOperationsTotal.WithLabelValues(kind, operation, result).Inc()
+}
+
+// ObserveSkippedUpdate records an upsert skipped by the updater because the object has not
+// changed.
+func ObserveSkippedUpdate(kind string) {
+	UpdaterOperationsTotal.WithLabelValues(kind, OperationUpsert, ResultSkipped).Inc()
+}
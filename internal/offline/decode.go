@@ -0,0 +1,297 @@
+package offline
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	appv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	stnrv1a1 "github.com/l7mp/stunner-gateway-operator/api/v1alpha1"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+)
+
+// readManifests reads the Kubernetes objects from a list of files and directories, "-" stands
+// for the standard input. Directories are read non-recursively, taking only the files with a
+// YAML or JSON extension.
+func readManifests(paths []string, stdin io.Reader, scheme *runtime.Scheme) ([]client.Object, error) {
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+	ret := []client.Object{}
+
+	for _, path := range paths {
+		if path == "-" {
+			objs, err := decodeManifests(stdin, decoder)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read standard input: %w", err)
+			}
+			ret = append(ret, objs...)
+			continue
+		}
+
+		files, err := listManifestFiles(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			f, err := os.Open(file)
+			if err != nil {
+				return nil, err
+			}
+			objs, err := decodeManifests(f, decoder)
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("cannot read file %q: %w", file, err)
+			}
+			ret = append(ret, objs...)
+		}
+	}
+
+	return ret, nil
+}
+
+func listManifestFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".yaml", ".yml", ".json":
+			files = append(files, filepath.Join(path, e.Name()))
+		}
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// decodeManifests decodes a stream of YAML or JSON documents. Lists (e.g., the output of "kubectl
+// get -o yaml") are flattened.
+func decodeManifests(r io.Reader, decoder runtime.Decoder) ([]client.Object, error) {
+	reader := yaml.NewYAMLReader(bufio.NewReader(r))
+	ret := []client.Object{}
+
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		objs, err := decodeObject(doc, decoder)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, objs...)
+	}
+
+	return ret, nil
+}
+
+func decodeObject(doc []byte, decoder runtime.Decoder) ([]client.Object, error) {
+	obj, _, err := decoder.Decode(doc, nil, nil)
+	if err != nil {
+		// skip empty documents, e.g., a document containing only comments
+		if runtime.IsMissingKind(err) && isEmptyDocument(doc) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if list, ok := obj.(*corev1.List); ok {
+		ret := []client.Object{}
+		for _, item := range list.Items {
+			objs, err := decodeObject(item.Raw, decoder)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, objs...)
+		}
+		return ret, nil
+	}
+
+	o, ok := obj.(client.Object)
+	if !ok {
+		return nil, fmt.Errorf("unsupported object %s", obj.GetObjectKind().GroupVersionKind())
+	}
+
+	return []client.Object{o}, nil
+}
+
+func isEmptyDocument(doc []byte) bool {
+	var content map[string]any
+	if err := yaml.Unmarshal(doc, &content); err != nil {
+		return false
+	}
+	return len(content) == 0
+}
+
+// resetStores flushes the global stores.
+func resetStores() {
+	for _, s := range []store.Store{
+		store.GatewayClasses, store.GatewayConfigs, store.Gateways, store.UDPRoutes,
+		store.TCPRoutes, store.Services, store.Nodes, store.EndpointSlices, store.Secrets,
+		store.AuthSecrets, store.Namespaces, store.StaticServices, store.ReferenceGrants,
+		store.Dataplanes, store.ConfigMaps, store.Deployments, store.DaemonSets,
+		store.HorizontalPodAutoscalers, store.PodDisruptionBudgets,
+	} {
+		s.Flush()
+	}
+}
+
+// loadStores fills the global stores with the objects, returning the objects that cannot be
+// used by the renderer.
+func loadStores(objs []client.Object) []client.Object {
+	ignored := []client.Object{}
+
+	for _, o := range objs {
+		switch obj := o.(type) {
+		case *gwapiv1b1.GatewayClass:
+			store.GatewayClasses.Upsert(obj)
+		case *stnrv1a1.GatewayConfig:
+			store.GatewayConfigs.Upsert(obj)
+		case *gwapiv1b1.Gateway:
+			store.Gateways.Upsert(obj)
+		case *gwapiv1a2.UDPRoute:
+			store.UDPRoutes.Upsert(obj)
+		case *gwapiv1a2.TCPRoute:
+			store.TCPRoutes.Upsert(obj)
+		case *corev1.Service:
+			store.Services.Upsert(obj)
+		case *discoveryv1.EndpointSlice:
+			store.EndpointSlices.Upsert(obj)
+		case *corev1.Endpoints:
+			for _, slice := range convertEndpoints(obj) {
+				store.EndpointSlices.Upsert(slice)
+			}
+		case *corev1.Node:
+			store.Nodes.Upsert(obj)
+		case *corev1.Namespace:
+			store.Namespaces.Upsert(obj)
+		case *corev1.Secret:
+			// stringData is merged into data by the API server
+			for k, v := range obj.StringData {
+				if obj.Data == nil {
+					obj.Data = map[string][]byte{}
+				}
+				obj.Data[k] = []byte(v)
+			}
+			obj.StringData = nil
+			// the same Secret may hold a TLS certificate or auth credentials
+			store.Secrets.Upsert(obj)
+			store.AuthSecrets.Upsert(obj)
+		case *stnrv1a1.StaticService:
+			store.StaticServices.Upsert(obj)
+		case *stnrv1a1.Dataplane:
+			store.Dataplanes.Upsert(obj)
+		case *gwapiv1b1.ReferenceGrant:
+			store.ReferenceGrants.Upsert(obj)
+		case *corev1.ConfigMap:
+			store.ConfigMaps.Upsert(obj)
+		case *appv1.Deployment:
+			store.Deployments.Upsert(obj)
+		case *appv1.DaemonSet:
+			store.DaemonSets.Upsert(obj)
+		default:
+			ignored = append(ignored, o)
+		}
+	}
+
+	return ignored
+}
+
+// convertEndpoints converts a legacy Endpoints object into EndpointSlices, one per subset and IP
+// family.
+func convertEndpoints(ep *corev1.Endpoints) []*discoveryv1.EndpointSlice {
+	ret := []*discoveryv1.EndpointSlice{}
+
+	for i, subset := range ep.Subsets {
+		ports := []discoveryv1.EndpointPort{}
+		for j := range subset.Ports {
+			p := subset.Ports[j]
+			ports = append(ports, discoveryv1.EndpointPort{
+				Name:     &p.Name,
+				Port:     &p.Port,
+				Protocol: &p.Protocol,
+			})
+		}
+
+		slices := map[discoveryv1.AddressType]*discoveryv1.EndpointSlice{}
+		add := func(addr corev1.EndpointAddress, ready bool) {
+			addressType := discoveryv1.AddressTypeIPv4
+			if ip := net.ParseIP(addr.IP); ip != nil && ip.To4() == nil {
+				addressType = discoveryv1.AddressTypeIPv6
+			}
+
+			slice, ok := slices[addressType]
+			if !ok {
+				slice = &discoveryv1.EndpointSlice{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: fmt.Sprintf("%s-%d-%s", ep.GetName(), i,
+							strings.ToLower(string(addressType))),
+						Namespace: ep.GetNamespace(),
+						Labels: map[string]string{
+							discoveryv1.LabelServiceName: ep.GetName(),
+						},
+					},
+					AddressType: addressType,
+					Ports:       ports,
+				}
+				slices[addressType] = slice
+				ret = append(ret, slice)
+			}
+
+			r := ready
+			slice.Endpoints = append(slice.Endpoints, discoveryv1.Endpoint{
+				Addresses:  []string{addr.IP},
+				Conditions: discoveryv1.EndpointConditions{Ready: &r},
+				NodeName:   addr.NodeName,
+			})
+		}
+
+		for _, addr := range subset.Addresses {
+			add(addr, true)
+		}
+		for _, addr := range subset.NotReadyAddresses {
+			add(addr, false)
+		}
+	}
+
+	return ret
+}
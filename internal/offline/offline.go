@@ -0,0 +1,360 @@
+// Package offline implements the "render" subcommand, which renders the stunnerd configs and the
+// dataplane resources from a set of Kubernetes manifests without a running cluster. This is
+// useful for checking GitOps changes in CI and for reproducing bug reports.
+package offline
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/yaml"
+
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	stnrv1a1 "github.com/l7mp/stunner-gateway-operator/api/v1alpha1"
+	"github.com/l7mp/stunner-gateway-operator/internal/config"
+	"github.com/l7mp/stunner-gateway-operator/internal/event"
+	"github.com/l7mp/stunner-gateway-operator/internal/renderer"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
+)
+
+const (
+	// ExitOK means the render was successful.
+	ExitOK = 0
+	// ExitStrictFailure means a resource was rejected by the renderer in strict mode.
+	ExitStrictFailure = 1
+	// ExitUsage means the command line or the input manifests were invalid.
+	ExitUsage = 2
+
+	// resourcesFileName is the file the rendered resources are written to in the output dir.
+	resourcesFileName = "resources.yaml"
+)
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// options are the command line options of the render command.
+type options struct {
+	files          stringList
+	controllerName string
+	dataplaneMode  string
+	enableEDS      bool
+	output         string
+	outputDir      string
+	strict         bool
+	verbosity      int
+}
+
+func newScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(gwapiv1a2.AddToScheme(scheme))
+	utilruntime.Must(gwapiv1b1.AddToScheme(scheme))
+	utilruntime.Must(stnrv1a1.AddToScheme(scheme))
+	return scheme
+}
+
+// Run runs the render command with the given arguments (without the command name) and returns
+// the exit code.
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	opts := options{}
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s render [flags] [FILE|DIR|-]...\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(stderr, "Render the stunnerd configs and the dataplane resources from "+
+			"Kubernetes manifests.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	fs.Var(&opts.files, "f", "Manifest file or directory to read, \"-\" for the standard input (can be repeated).")
+	fs.Var(&opts.files, "filename", "Same as -f.")
+	fs.StringVar(&opts.controllerName, "controller-name", opdefault.DefaultControllerName,
+		"The controller name to be used in the GatewayClass resource to bind it to this operator.")
+	fs.StringVar(&opts.dataplaneMode, "dataplane-mode", "legacy",
+		"Dataplane mode: either \"legacy\" or \"managed\".")
+	fs.BoolVar(&opts.enableEDS, "endpoint-discovery", opdefault.DefaultEnableEndpointDiscovery,
+		fmt.Sprintf("Enable endpoint discovery, default: %t.", opdefault.DefaultEnableEndpointDiscovery))
+	fs.StringVar(&opts.output, "o", "yaml", "Output format: either \"yaml\" or \"json\".")
+	fs.StringVar(&opts.output, "output", "yaml", "Same as -o.")
+	fs.StringVar(&opts.outputDir, "output-dir", "",
+		"Write the stunnerd configs and the rendered resources into this directory instead of the standard output.")
+	fs.BoolVar(&opts.strict, "strict", false,
+		"Exit with an error if any resource is not accepted, programmed or has unresolved references.")
+	fs.IntVar(&opts.verbosity, "v", 0, "Log verbosity: 0 logs only errors, higher values log more.")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	opts.files = append(opts.files, fs.Args()...)
+
+	if err := opts.validate(); err != nil {
+		fmt.Fprintf(stderr, "error: %s\n", err)
+		fs.Usage()
+		return ExitUsage
+	}
+
+	scheme := newScheme()
+	objs, err := readManifests(opts.files, stdin, scheme)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %s\n", err)
+		return ExitUsage
+	}
+
+	update := render(opts, scheme, objs, stderr)
+
+	for _, o := range deleted(update) {
+		fmt.Fprintf(stderr, "warning: %s %s would be deleted\n", kindOf(o, scheme),
+			store.GetObjectKey(o))
+	}
+
+	if opts.outputDir != "" {
+		err = writeOutputDir(opts.outputDir, update, scheme)
+	} else {
+		err = writeObjects(stdout, opts.output, upserted(update), scheme)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %s\n", err)
+		return ExitUsage
+	}
+
+	if failures := checkStatus(update); len(failures) > 0 {
+		for _, f := range failures {
+			fmt.Fprintf(stderr, "%s\n", f)
+		}
+		if opts.strict {
+			return ExitStrictFailure
+		}
+	}
+
+	return ExitOK
+}
+
+func (o *options) validate() error {
+	if len(o.files) == 0 {
+		return errors.New("no input: specify files or directories with -f, or \"-\" for the standard input")
+	}
+
+	switch strings.ToLower(o.dataplaneMode) {
+	case "legacy", "managed":
+	default:
+		return fmt.Errorf("invalid dataplane mode %q", o.dataplaneMode)
+	}
+
+	switch o.output {
+	case "yaml", "json":
+	default:
+		return fmt.Errorf("invalid output format %q", o.output)
+	}
+
+	return nil
+}
+
+// render loads the objects into the stores, runs the renderer and returns the resulting update.
+func render(opts options, scheme *runtime.Scheme, objs []client.Object, stderr io.Writer) *event.EventUpdate {
+	level := zapcore.ErrorLevel
+	if opts.verbosity > 0 {
+		level = zapcore.Level(1 - opts.verbosity)
+	}
+	logger := zap.New(zap.UseDevMode(true), zap.WriteTo(stderr), zap.Level(level))
+
+	config.ControllerName = opts.controllerName
+	config.DataplaneMode = config.NewDataplaneMode(opts.dataplaneMode)
+	config.EnableEndpointDiscovery = opts.enableEDS
+
+	resetStores()
+	for _, o := range loadStores(objs) {
+		fmt.Fprintf(stderr, "warning: ignoring unsupported object %s %s\n", kindOf(o, scheme),
+			store.GetObjectKey(o))
+	}
+
+	r := renderer.NewRenderer(renderer.RendererConfig{
+		Scheme:  scheme,
+		Offline: true,
+		Logger:  logger,
+	})
+
+	// the renderer may send more than one update per render
+	ch := make(chan event.Event)
+	r.SetOperatorChannel(ch)
+
+	update := event.NewEventUpdate(0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range ch {
+			if u, ok := e.(*event.EventUpdate); ok {
+				update.Merge(u)
+			}
+		}
+	}()
+
+	r.Render(event.NewEventRender())
+	close(ch)
+	<-done
+
+	return update
+}
+
+// upserted returns the objects in the upsert queue of an update, except Secrets, in a stable
+// order.
+func upserted(update *event.EventUpdate) []client.Object {
+	q := update.UpsertQueue
+	ret := []client.Object{}
+	for _, s := range []store.Store{
+		q.GatewayClasses, q.GatewayConfigs, q.Gateways, q.UDPRoutes, q.TCPRoutes,
+		q.ConfigMaps, q.Services, q.Deployments, q.DaemonSets, q.HPAs, q.PDBs,
+		q.ServiceAccounts, q.Roles, q.RoleBindings,
+	} {
+		ret = append(ret, sortObjects(s.Objects())...)
+	}
+	return ret
+}
+
+// deleted returns the objects in the delete queue of an update in a stable order.
+func deleted(update *event.EventUpdate) []client.Object {
+	q := update.DeleteQueue
+	ret := []client.Object{}
+	for _, s := range []store.Store{
+		q.GatewayClasses, q.GatewayConfigs, q.Gateways, q.UDPRoutes, q.TCPRoutes,
+		q.ConfigMaps, q.Services, q.Secrets, q.Deployments, q.DaemonSets, q.HPAs, q.PDBs,
+		q.ServiceAccounts, q.Roles, q.RoleBindings,
+	} {
+		ret = append(ret, sortObjects(s.Objects())...)
+	}
+	return ret
+}
+
+func sortObjects(objs []client.Object) []client.Object {
+	sort.Slice(objs, func(i, j int) bool {
+		return store.GetObjectKey(objs[i]) < store.GetObjectKey(objs[j])
+	})
+	return objs
+}
+
+func kindOf(o client.Object, scheme *runtime.Scheme) string {
+	gvk, err := apiutil.GVKForObject(o, scheme)
+	if err != nil {
+		return fmt.Sprintf("%T", o)
+	}
+	return gvk.Kind
+}
+
+// withTypeMeta returns a copy of an object with the apiVersion and kind set.
+func withTypeMeta(o client.Object, scheme *runtime.Scheme) (client.Object, error) {
+	gvk, err := apiutil.GVKForObject(o, scheme)
+	if err != nil {
+		return nil, err
+	}
+	ret := o.DeepCopyObject().(client.Object)
+	ret.GetObjectKind().SetGroupVersionKind(gvk)
+	return ret, nil
+}
+
+// writeObjects writes objects as a YAML stream or a JSON List.
+func writeObjects(w io.Writer, format string, objs []client.Object, scheme *runtime.Scheme) error {
+	typed := make([]client.Object, 0, len(objs))
+	for _, o := range objs {
+		t, err := withTypeMeta(o, scheme)
+		if err != nil {
+			return err
+		}
+		typed = append(typed, t)
+	}
+
+	if format == "json" {
+		list := map[string]any{"apiVersion": "v1", "kind": "List", "items": typed}
+		buf, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", buf)
+		return err
+	}
+
+	for _, o := range typed {
+		buf, err := yaml.Marshal(o)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeOutputDir writes each stunnerd config into a separate JSON file named after the ConfigMap
+// that holds it, plus all the rendered resources into a YAML file.
+func writeOutputDir(dir string, update *event.EventUpdate, scheme *runtime.Scheme) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for _, o := range sortObjects(update.UpsertQueue.ConfigMaps.Objects()) {
+		cm, ok := o.(*corev1.ConfigMap)
+		if !ok {
+			continue
+		}
+		conf, ok := cm.Data[opdefault.DefaultStunnerdConfigfileName]
+		if !ok {
+			continue
+		}
+
+		var buf strings.Builder
+		if err := indentJSON(&buf, conf); err != nil {
+			return fmt.Errorf("invalid stunnerd config in ConfigMap %s: %w",
+				store.GetObjectKey(o), err)
+		}
+
+		file := filepath.Join(dir, fmt.Sprintf("%s-%s.json", o.GetNamespace(), o.GetName()))
+		if err := os.WriteFile(file, []byte(buf.String()), 0o644); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Create(filepath.Join(dir, resourcesFileName))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return writeObjects(f, "yaml", upserted(update), scheme)
+}
+
+func indentJSON(w io.Writer, s string) error {
+	var content any
+	if err := json.Unmarshal([]byte(s), &content); err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", buf)
+	return err
+}
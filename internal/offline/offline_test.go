@@ -0,0 +1,209 @@
+package offline
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+
+	"github.com/l7mp/stunner-gateway-operator/internal/renderer"
+	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
+)
+
+const testManifests = `
+apiVersion: gateway.networking.k8s.io/v1beta1
+kind: GatewayClass
+metadata:
+  name: stunner-gatewayclass
+spec:
+  controllerName: "stunner.l7mp.io/gateway-operator"
+  parametersRef:
+    group: "stunner.l7mp.io"
+    kind: GatewayConfig
+    name: stunner-gatewayconfig
+    namespace: stunner
+---
+apiVersion: stunner.l7mp.io/v1alpha1
+kind: GatewayConfig
+metadata:
+  name: stunner-gatewayconfig
+  namespace: stunner
+spec:
+  realm: stunner.l7mp.io
+  authType: plaintext
+  userName: "user-1"
+  password: "pass-1"
+---
+# a comment-only document
+---
+apiVersion: gateway.networking.k8s.io/v1beta1
+kind: Gateway
+metadata:
+  name: udp-gateway
+  namespace: stunner
+spec:
+  gatewayClassName: stunner-gatewayclass
+  listeners:
+    - name: udp-listener
+      port: 3478
+      protocol: UDP
+---
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: gateway.networking.k8s.io/v1alpha2
+    kind: UDPRoute
+    metadata:
+      name: media-plane
+      namespace: stunner
+    spec:
+      parentRefs:
+        - name: udp-gateway
+      rules:
+        - backendRefs:
+            - name: media-plane
+  - apiVersion: v1
+    kind: Service
+    metadata:
+      name: media-plane
+      namespace: stunner
+    spec:
+      clusterIP: 10.0.0.1
+      ports:
+        - port: 9001
+          protocol: UDP
+  - apiVersion: v1
+    kind: Endpoints
+    metadata:
+      name: media-plane
+      namespace: stunner
+    subsets:
+      - addresses:
+          - ip: 10.1.0.1
+          - ip: 10.1.0.2
+        notReadyAddresses:
+          - ip: 2001:db8::1
+        ports:
+          - port: 9001
+            protocol: UDP
+`
+
+func TestOfflineRender(t *testing.T) {
+	t.Run("render from stdin", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := Run([]string{"--strict", "-"}, strings.NewReader(testManifests), &stdout, &stderr)
+		assert.Equal(t, ExitOK, code, "exit code: %s", stderr.String())
+
+		out := stdout.String()
+		assert.Contains(t, out, "kind: ConfigMap", "stunnerd config rendered")
+		assert.Contains(t, out, opdefault.DefaultStunnerdConfigfileName+":", "stunnerd config key")
+		assert.Contains(t, out, "udp-listener", "listener rendered")
+		assert.Contains(t, out, "10.1.0.2", "endpoints rendered")
+		assert.Contains(t, out, "type: Programmed", "gateway status")
+		assert.Contains(t, out, "type: ResolvedRefs", "route status")
+	})
+
+	t.Run("output dir", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "manifests.yaml")
+		assert.NoError(t, os.WriteFile(file, []byte(testManifests), 0o644))
+
+		out := filepath.Join(dir, "out")
+		var stdout, stderr bytes.Buffer
+		code := Run([]string{"-f", file, "--output-dir", out}, nil, &stdout, &stderr)
+		assert.Equal(t, ExitOK, code, "exit code: %s", stderr.String())
+		assert.Empty(t, stdout.String(), "no output on stdout")
+
+		conf, err := os.ReadFile(filepath.Join(out, "stunner-stunnerd-config.json"))
+		assert.NoError(t, err, "stunnerd config written")
+		assert.Contains(t, string(conf), "\"realm\": \"stunner.l7mp.io\"", "realm")
+
+		_, err = os.Stat(filepath.Join(out, resourcesFileName))
+		assert.NoError(t, err, "resources written")
+	})
+
+	t.Run("strict mode", func(t *testing.T) {
+		// cross-namespace backend without a ReferenceGrant
+		manifests := strings.Replace(testManifests, "            - name: media-plane\n",
+			"            - name: media-plane\n              namespace: default\n", 1)
+		var stdout, stderr bytes.Buffer
+		code := Run([]string{"--strict", "-"}, strings.NewReader(manifests), &stdout, &stderr)
+		assert.Equal(t, ExitStrictFailure, code, "exit code")
+		assert.Contains(t, stderr.String(), "UDPRoute stunner/media-plane parent udp-gateway: ResolvedRefs=False",
+			"failure reported")
+	})
+
+	t.Run("shared-secret rotation", func(t *testing.T) {
+		manifests := strings.Replace(testManifests, `  authType: plaintext
+  userName: "user-1"
+  password: "pass-1"
+`, `  authRef:
+    name: auth-secret
+  sharedSecretRotation:
+    interval: 1h
+`, 1)
+
+		// no Secret: a deterministic placeholder is rendered
+		var stdout, stderr bytes.Buffer
+		code := Run([]string{"-"}, strings.NewReader(manifests), &stdout, &stderr)
+		assert.Equal(t, ExitOK, code, "exit code: %s", stderr.String())
+		assert.Contains(t, stdout.String(), renderer.OfflineSharedSecret, "placeholder rendered")
+		assert.NotContains(t, stdout.String(), "kind: Secret", "no Secret in output")
+
+		// the shared secret of the input Secret is used as is, even if overdue for rotation
+		manifests += `---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: auth-secret
+  namespace: stunner
+  annotations:
+    ` + opdefault.SharedSecretRotatedAtAnnotationKey + `: "2020-01-01T00:00:00Z"
+type: Opaque
+stringData:
+  type: longterm
+  secret: input-secret
+`
+		stdout.Reset()
+		code = Run([]string{"-"}, strings.NewReader(manifests), &stdout, &stderr)
+		assert.Equal(t, ExitOK, code, "exit code: %s", stderr.String())
+		assert.Contains(t, stdout.String(), "input-secret", "input secret rendered")
+		assert.NotContains(t, stdout.String(), renderer.OfflineSharedSecret, "no placeholder")
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, ExitUsage, Run([]string{}, nil, &stdout, &stderr), "no input")
+		assert.Equal(t, ExitUsage, Run([]string{"--dataplane-mode", "dummy", "-"},
+			strings.NewReader(testManifests), &stdout, &stderr), "invalid mode")
+		assert.Equal(t, ExitUsage, Run([]string{"-"}, strings.NewReader("kind: Dummy\n"),
+			&stdout, &stderr), "invalid manifest")
+	})
+}
+
+func TestConvertEndpoints(t *testing.T) {
+	ep := &corev1.Endpoints{}
+	ep.SetName("svc")
+	ep.SetNamespace("ns")
+	ep.Subsets = []corev1.EndpointSubset{{
+		Addresses:         []corev1.EndpointAddress{{IP: "10.0.0.1"}},
+		NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.2"}, {IP: "2001:db8::1"}},
+		Ports:             []corev1.EndpointPort{{Port: 1, Protocol: corev1.ProtocolUDP}},
+	}}
+
+	slices := convertEndpoints(ep)
+	assert.Len(t, slices, 2, "one slice per IP family")
+
+	v4 := slices[0]
+	assert.Equal(t, discoveryv1.AddressTypeIPv4, v4.AddressType, "address type")
+	assert.Equal(t, "svc", v4.GetLabels()[discoveryv1.LabelServiceName], "service label")
+	assert.Len(t, v4.Endpoints, 2, "endpoints")
+	assert.True(t, *v4.Endpoints[0].Conditions.Ready, "ready")
+	assert.False(t, *v4.Endpoints[1].Conditions.Ready, "not ready")
+	assert.Equal(t, discoveryv1.AddressTypeIPv6, slices[1].AddressType, "address type")
+}
@@ -0,0 +1,100 @@
+package offline
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/l7mp/stunner-gateway-operator/internal/event"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+)
+
+// checkedConditions are the status conditions that must not be false for a resource to be
+// considered healthy.
+var checkedConditions = []string{
+	string(gwapiv1b1.GatewayConditionAccepted),
+	string(gwapiv1b1.GatewayConditionProgrammed),
+	string(gwapiv1b1.RouteConditionResolvedRefs),
+}
+
+// checkStatus returns a description of each failed status condition in the rendered
+// GatewayClasses, Gateways and routes.
+func checkStatus(update *event.EventUpdate) []string {
+	ret := []string{}
+	q := update.UpsertQueue
+
+	for _, o := range sortObjects(q.GatewayClasses.Objects()) {
+		gc, ok := o.(*gwapiv1b1.GatewayClass)
+		if !ok {
+			continue
+		}
+		ret = append(ret, failedConditions("GatewayClass "+store.GetObjectKey(gc),
+			gc.Status.Conditions)...)
+	}
+
+	for _, o := range sortObjects(q.Gateways.Objects()) {
+		gw, ok := o.(*gwapiv1b1.Gateway)
+		if !ok {
+			continue
+		}
+		name := "Gateway " + store.GetObjectKey(gw)
+		ret = append(ret, failedConditions(name, gw.Status.Conditions)...)
+		for _, l := range gw.Status.Listeners {
+			ret = append(ret, failedConditions(fmt.Sprintf("%s listener %s", name, l.Name),
+				l.Conditions)...)
+		}
+	}
+
+	for _, o := range sortObjects(q.UDPRoutes.Objects()) {
+		ro, ok := o.(*gwapiv1a2.UDPRoute)
+		if !ok {
+			continue
+		}
+		ret = append(ret, failedParents("UDPRoute "+store.GetObjectKey(ro),
+			ro.Status.Parents)...)
+	}
+
+	for _, o := range sortObjects(q.TCPRoutes.Objects()) {
+		ro, ok := o.(*gwapiv1a2.TCPRoute)
+		if !ok {
+			continue
+		}
+		ret = append(ret, failedParents("TCPRoute "+store.GetObjectKey(ro),
+			ro.Status.Parents)...)
+	}
+
+	return ret
+}
+
+func failedParents(name string, parents []gwapiv1a2.RouteParentStatus) []string {
+	ret := []string{}
+	for _, p := range parents {
+		ret = append(ret, failedConditions(fmt.Sprintf("%s parent %s", name, p.ParentRef.Name),
+			p.Conditions)...)
+	}
+	return ret
+}
+
+func failedConditions(name string, conds []metav1.Condition) []string {
+	ret := []string{}
+	for _, c := range conds {
+		if c.Status != metav1.ConditionFalse {
+			continue
+		}
+		// there is no load balancer to assign a public address offline
+		if c.Type == string(gwapiv1b1.GatewayConditionProgrammed) &&
+			c.Reason == string(gwapiv1b1.GatewayReasonAddressNotAssigned) {
+			continue
+		}
+		for _, t := range checkedConditions {
+			if c.Type == t {
+				ret = append(ret, fmt.Sprintf("%s: %s=%s (%s): %s", name, c.Type, c.Status,
+					c.Reason, c.Message))
+			}
+		}
+	}
+	return ret
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/l7mp/stunner-gateway-operator/internal/config"
 	"github.com/l7mp/stunner-gateway-operator/internal/controllers"
 	"github.com/l7mp/stunner-gateway-operator/internal/event"
+	"github.com/l7mp/stunner-gateway-operator/internal/metrics"
 )
 
 // clusterTimeout is a timeout for connections to the Kubernetes API
@@ -41,6 +42,7 @@ type OperatorConfig struct {
 	RenderCh       chan event.Event
 	ConfigCh       chan event.Event
 	UpdaterCh      chan event.Event
+	DryRun         bool
 	Logger         logr.Logger
 }
 
@@ -49,6 +51,7 @@ type Operator struct {
 	mgr                                       manager.Manager
 	renderCh, operatorCh, updaterCh, configCh chan event.Event
 	manager                                   manager.Manager
+	dryRun                                    bool
 	log, logger                               logr.Logger
 }
 
@@ -62,6 +65,7 @@ func NewOperator(cfg OperatorConfig) *Operator {
 		operatorCh: make(chan event.Event, channelBufferSize),
 		updaterCh:  cfg.UpdaterCh,
 		configCh:   cfg.ConfigCh,
+		dryRun:     cfg.DryRun,
 		logger:     cfg.Logger,
 	}
 }
@@ -90,6 +94,16 @@ func (o *Operator) Start(ctx context.Context) error {
 		return fmt.Errorf("cannot register gateway controller: %w", err)
 	}
 
+	log.V(3).Info("starting ReferenceGrant controller")
+	if err := controllers.RegisterReferenceGrantController(o.mgr, o.operatorCh, o.logger); err != nil {
+		return fmt.Errorf("cannot register referencegrant controller: %w", err)
+	}
+
+	log.V(3).Info("starting TCPRoute controller")
+	if err := controllers.RegisterTCPRouteController(o.mgr, o.operatorCh, o.logger); err != nil {
+		return fmt.Errorf("cannot register tcproute controller: %w", err)
+	}
+
 	log.V(3).Info("starting UDPRoute controller")
 	if err := controllers.RegisterUDPRouteController(o.mgr, o.operatorCh, o.logger); err != nil {
 		return fmt.Errorf("cannot register udproute controller: %w", err)
@@ -111,6 +125,8 @@ func (o *Operator) eventLoop(ctx context.Context) {
 	throttler := time.NewTicker(config.ThrottleTimeout)
 	throttler.Stop()
 	throttling := false
+	// collects the changed objects of the render requests received while throttling
+	var pending *event.EventRender
 
 	for {
 		select {
@@ -121,14 +137,23 @@ func (o *Operator) eventLoop(ctx context.Context) {
 				// pass through to the updater
 				o.updaterCh <- e
 
-				// notify the config discovery server
-				o.configCh <- e
+				// notify the config discovery server, unless in dry-run mode where no
+				// config may reach the dataplane
+				if !o.dryRun {
+					o.configCh <- e
+				}
 
 			case event.EventTypeRender:
 				// rate-limit rendering requests before passing on to the renderer
+				if pending == nil {
+					pending = event.NewEventRender(e.(*event.EventRender).Objects...)
+				} else {
+					pending.Merge(e.(*event.EventRender))
+				}
 
 				// render request in progress: do nothing
 				if throttling {
+					metrics.ThrottledRendersTotal.Inc()
 					o.log.V(3).Info("rendering request throttled", "event",
 						e.String())
 					continue
@@ -147,7 +172,11 @@ func (o *Operator) eventLoop(ctx context.Context) {
 			}
 
 		case <-throttler.C:
-			o.renderCh <- event.NewEventRender()
+			if pending == nil {
+				pending = event.NewEventRender()
+			}
+			o.renderCh <- pending
+			pending = nil
 			throttling = false
 			throttler.Stop()
 
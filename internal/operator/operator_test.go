@@ -0,0 +1,51 @@
+package operator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/l7mp/stunner-gateway-operator/internal/event"
+)
+
+func TestUpdateForwarding(t *testing.T) {
+	for _, dryRun := range []bool{false, true} {
+		updaterCh, configCh := make(chan event.Event, 10), make(chan event.Event, 10)
+		o := NewOperator(OperatorConfig{
+			RenderCh:  make(chan event.Event, 10),
+			ConfigCh:  configCh,
+			UpdaterCh: updaterCh,
+			DryRun:    dryRun,
+			Logger:    logr.Discard(),
+		})
+		o.log = logr.Discard()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go o.eventLoop(ctx)
+
+		o.GetOperatorChannel() <- event.NewEventUpdate(1)
+		o.GetOperatorChannel() <- event.NewEventUpdate(2)
+
+		for _, gen := range []int{1, 2} {
+			select {
+			case e := <-updaterCh:
+				assert.Equal(t, gen, e.(*event.EventUpdate).Generation, "updater generation")
+			case <-time.After(time.Second):
+				assert.Fail(t, "update not forwarded to the updater", "dry-run: %t", dryRun)
+			}
+		}
+
+		if dryRun {
+			// no config may reach the config discovery server in dry-run mode
+			assert.Len(t, configCh, 0, "no update forwarded to the CDS server")
+		} else {
+			assert.Eventually(t, func() bool { return len(configCh) == 2 }, time.Second,
+				10*time.Millisecond, "updates forwarded to the CDS server")
+		}
+
+		cancel()
+	}
+}
@@ -19,6 +19,11 @@ func (r *Renderer) renderAuth(c *RenderContext) (*stnrconfv1a1.AuthConfig, error
 		return r.renderExternalAuth(c)
 	}
 
+	if c.gwConf.Spec.SharedSecretRotation != nil {
+		r.log.Info("shared-secret rotation requires an auth Secret reference, ignoring",
+			"gateway-config", store.GetObjectKey(gwConf))
+	}
+
 	return r.renderInlineAuth(c)
 }
 
@@ -93,23 +98,31 @@ func (r *Renderer) renderExternalAuth(c *RenderContext) (*stnrconfv1a1.AuthConfi
 		return nil, NewCriticalError(ExternalAuthCredentialsNotFound)
 	}
 
+	// with shared-secret rotation enabled the operator creates the Secret if it does not exist
+	rotate := gwConf.Spec.SharedSecretRotation != nil
 	secret := store.AuthSecrets.GetObject(n)
-	if secret == nil {
+	if secret == nil && !rotate {
 		// report concrete error here, return a critical error
 		r.log.Info("auth Secret not found", "gateway-config", store.GetObjectKey(c.gwConf),
 			"ref", dumpSecretRef(ref, gwConf.GetNamespace()), "name", n)
 		return nil, NewCriticalError(ExternalAuthCredentialsNotFound)
 	}
 
-	if secret.Type != corev1.SecretTypeOpaque {
+	if secret != nil && secret.Type != corev1.SecretTypeOpaque {
 		r.log.Info("expecting Secret of type \"Opaque\" (trying to use Secret anyway)",
 			"gateway-config", store.GetObjectKey(c.gwConf), "secret", n.String())
 	}
 
 	var hint *string
-	if stype, ok := secret.Data["type"]; ok {
-		stype := string(stype)
-		hint = &stype
+	if secret != nil {
+		if stype, ok := secret.Data["type"]; ok {
+			stype := string(stype)
+			hint = &stype
+		}
+	}
+	if hint == nil && rotate {
+		longterm := "longterm"
+		hint = &longterm
 	}
 
 	atype, err := getAuthType(hint)
@@ -130,6 +143,16 @@ func (r *Renderer) renderExternalAuth(c *RenderContext) (*stnrconfv1a1.AuthConfi
 		auth.Credentials["password"] = string(password)
 
 	case stnrconfv1a1.AuthTypeLongTerm:
+		if rotate {
+			sharedSecret, err := r.rotateSharedSecret(c, n, secret)
+			if err != nil {
+				return nil, err
+			}
+
+			auth.Credentials["secret"] = sharedSecret
+			break
+		}
+
 		sharedSecret, sharedSecretOk := secret.Data["secret"]
 		// accept long form
 		if !sharedSecretOk {
@@ -143,6 +166,11 @@ func (r *Renderer) renderExternalAuth(c *RenderContext) (*stnrconfv1a1.AuthConfi
 		auth.Credentials["secret"] = string(sharedSecret)
 	}
 
+	if rotate && atype != stnrconfv1a1.AuthTypeLongTerm {
+		r.log.Info("shared-secret rotation is supported only for longterm authentication, "+
+			"ignoring", "gateway-config", store.GetObjectKey(c.gwConf), "secret", n.String())
+	}
+
 	auth.Type = atype.String()
 
 	// validate so that defaults get filled in
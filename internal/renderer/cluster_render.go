@@ -2,9 +2,11 @@ package renderer
 
 import (
 	"fmt"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
@@ -16,148 +18,109 @@ import (
 	"github.com/l7mp/stunner-gateway-operator/internal/store"
 )
 
-func (r *Renderer) renderCluster(ro *gwapiv1a2.UDPRoute) (*stnrconfv1a1.ClusterConfig, error) {
-	r.log.V(4).Info("renderCluster", "route", store.GetObjectKey(ro))
+// renderCluster renders a STUNner cluster from a route.
+func (r *Renderer) renderCluster(ro client.Object) (*stnrconfv1a1.ClusterConfig, error) {
+	cluster, _, err := r.renderClusterWithStatus(ro)
+	return cluster, err
+}
+
+// renderClusterWithStatus renders a STUNner cluster from all the rules of a route and returns a
+// per-rule status reporting on the backends used.
+func (r *Renderer) renderClusterWithStatus(ro client.Object) (*stnrconfv1a1.ClusterConfig, []ruleStatus, error) {
+	r.log.V(4).Info("renderCluster", "route", store.GetObjectKey(ro), "kind", getRouteKind(ro))
 
 	// track down the backendref
-	rs := ro.Spec.Rules
+	rs := getRouteBackendRefs(ro)
 	if len(rs) == 0 {
-		return nil, NewCriticalError(NoRuleFound)
-	}
-
-	if len(rs) > 1 {
-		r.log.V(1).Info("renderCluster: too many rules (%d) in route %q, "+
-			"considering only the first one", len(rs), store.GetObjectKey(ro))
+		return nil, nil, NewCriticalError(NoRuleFound)
 	}
 
 	eps := []string{}
+	epSet := map[string]bool{}
+	rules := make([]ruleStatus, len(rs))
 
 	// the rest of the errors are not critical, but we still need to keep track of each in
 	// order to set the ResolvedRefs Route status: last error is reported only
 	var routeError error
 
-	ctype, prevCType := stnrconfv1a1.ClusterTypeStatic, stnrconfv1a1.ClusterTypeUnknown
-	for _, b := range rs[0].BackendRefs {
-		b := b
-
-		if b.Group != nil && string(*b.Group) != corev1.GroupName &&
-			string(*b.Group) != stnrv1a1.GroupVersion.Group {
-			routeError = NewNonCriticalError(InvalidBackendGroup)
-			r.log.V(2).Info("renderCluster: invalid backend Group", "route",
-				store.GetObjectKey(ro), "backendRef", dumpBackendRef(&b), "group",
-				*b.Group, "error", routeError.Error())
-			continue
-		}
-
-		if b.Kind != nil && string(*b.Kind) != "Service" && string(*b.Kind) != "StaticService" {
-			routeError = NewNonCriticalError(InvalidBackendKind)
-			r.log.V(2).Info("renderCluster: invalid backend Kind", "route",
-				store.GetObjectKey(ro), "backendRef", dumpBackendRef(&b), "kind", *b.Kind,
-				"error", routeError)
-			continue
-		}
-
-		// default is the local namespace of the route
-		ns := ro.GetNamespace()
-		if b.Namespace != nil {
-			ns = string(*b.Namespace)
-		}
-
-		ep := []string{}
-		switch ref := &b; {
-		case store.IsReferenceService(ref):
-			var errEDS error
-
-			// get endpoints (checks EDS inline)
-			if config.EnableEndpointDiscovery {
-				epEDS, ctypeEDS, err := getEndpointsForService(ref, ns)
-				if err != nil {
-					r.log.V(1).Info("renderCluster: error rendering Endpoints for Service backend",
-						"route", store.GetObjectKey(ro), "backendRef", dumpBackendRef(ref),
-						"error", err)
-					errEDS = err
-					routeError = err
-				} else {
-					ep = append(ep, epEDS...)
-					ctype = ctypeEDS
-				}
+	// ctype is the type of the backends used so far
+	ctype, backends := stnrconfv1a1.ClusterTypeUnknown, 0
+	for i, rule := range rs {
+		for _, b := range rule {
+			b := b
+			backends++
+
+			bs := backendStatus{ref: dumpBackendName(&b, ro.GetNamespace()), weight: getBackendWeight(&b)}
+
+			// weight 0 means the backend must not be used
+			if bs.weight == 0 {
+				bs.reason = "weight is zero"
+				rules[i].backends = append(rules[i].backends, bs)
+				r.log.V(2).Info("renderCluster: ignoring backend with zero weight", "route",
+					store.GetObjectKey(ro), "rule", i, "backendRef", dumpBackendRef(&b))
+				continue
 			}
 
-			// the clusterIP or STRICT_DNS cluster if EDS is disabled
-			epCluster, ctypeCluster, errCluster := getClusterRouteForService(ref, ns)
-			if errCluster != nil {
-				r.log.V(1).Info("renderCluster: error rendering service-route (ClusterIP/DNS route) for Service backend",
-					"route", store.GetObjectKey(ro), "backendRef", dumpBackendRef(ref),
-					"error", errCluster)
-				routeError = errCluster
-			} else {
-				ep = append(ep, epCluster...)
-				ctype = ctypeCluster
+			btype := stnrconfv1a1.ClusterTypeStatic
+			ep, err := r.renderBackend(ro, &b, &btype)
+			if err != nil {
+				routeError = err
+				bs.reason = err.Error()
 			}
 
-			if errCluster != nil && errEDS != nil {
-				// both attempts failed: skip backend
-				r.log.V(1).Info("renderCluster: skipping Service backend", "route",
-					store.GetObjectKey(ro), "backendRef", dumpBackendRef(ref),
-					"reason", routeError)
-				routeError = NewNonCriticalError(BackendNotFound)
+			if ep == nil {
+				// backend could not be resolved
+				rules[i].backends = append(rules[i].backends, bs)
 				continue
 			}
 
-		case store.IsReferenceStaticService(ref):
-			var err error
-			ep, ctype, err = getEndpointsForStaticService(ref, ns)
-			if err != nil {
-				routeError = err
-				r.log.Info("renderCluster: error rendering endpoints for StaticService backend",
-					"route", store.GetObjectKey(ro), "backendRef", dumpBackendRef(ref),
-					"error", routeError)
+			if ctype != stnrconfv1a1.ClusterTypeUnknown && ctype != btype {
+				routeError = NewNonCriticalError(InconsitentClusterType)
+				bs.reason = routeError.Error()
+				rules[i].backends = append(rules[i].backends, bs)
+				r.log.Info("renderCluster: inconsistent cluster type", "route",
+					store.GetObjectKey(ro), "backendRef", dumpBackendRef(&b),
+					"cluster-type", ctype.String(), "backend-cluster-type", btype.String())
 				continue
 			}
-		default:
-			// error could also be InvalidBackendGroup: both are reported with the same
-			// reason in the route status
-			routeError = NewNonCriticalError(InvalidBackendKind)
-			r.log.Info("renderCluster: invalid backend Kind and/or Group", "route", store.GetObjectKey(ro),
-				"backendRef", dumpBackendRef(&b), "error", routeError)
-			continue
-		}
 
-		if IsNonCriticalError(routeError, BackendNotFound) {
-			r.log.Info("renderCluster: skipping backend", "route", store.GetObjectKey(ro),
-				"backendRef", dumpBackendRef(&b))
-			continue
-		}
-
-		if prevCType != stnrconfv1a1.ClusterTypeUnknown && prevCType != ctype {
-			routeError = NewNonCriticalError(InconsitentClusterType)
-			r.log.Info("renderCluster: inconsistent cluster type", "route",
-				store.GetObjectKey(ro), "backendRef", dumpBackendRef(&b),
-				"prevous-ctype", fmt.Sprintf("%#v", prevCType))
-			continue
-		}
+			r.log.V(2).Info("renderCluster: adding Endpoints for backend", "route",
+				store.GetObjectKey(ro), "rule", i, "backendRef", dumpBackendRef(&b),
+				"weight", bs.weight, "cluster-type", btype.String(), "endpoints", ep)
 
-		r.log.V(2).Info("renderCluster: adding Endpoints for backend", "route",
-			store.GetObjectKey(ro), "backendRef", dumpBackendRef(&b), "cluster-type",
-			ctype.String(), "endpoints", ep)
+			// the same backend may appear in multiple rules
+			for _, e := range ep {
+				if !epSet[e] {
+					eps = append(eps, e)
+					epSet[e] = true
+				}
+			}
+			ctype = btype
 
-		eps = append(eps, ep...)
-		prevCType = ctype
+			bs.used = true
+			rules[i].backends = append(rules[i].backends, bs)
+		}
 	}
 
+	// no backend contributes any endpoints (all missing or weight zero): render an empty
+	// cluster but report the route's backends unresolved
 	if ctype == stnrconfv1a1.ClusterTypeUnknown {
-		return nil, NewNonCriticalError(BackendNotFound)
+		ctype = stnrconfv1a1.ClusterTypeStatic
+		if routeError == nil && backends > 0 {
+			routeError = NewNonCriticalError(BackendNotFound)
+		}
 	}
 
 	cluster := stnrconfv1a1.ClusterConfig{
 		Name:      store.GetObjectKey(ro),
 		Type:      ctype.String(),
+		Protocol:  getClusterProtocol(ro).String(),
 		Endpoints: eps,
 	}
 
 	// validate so that defaults get filled in
 	if err := cluster.Validate(); err != nil {
-		return nil, err
+		return nil, rules, err
 	}
 
 	backendStatus := "None"
@@ -165,9 +128,118 @@ func (r *Renderer) renderCluster(ro *gwapiv1a2.UDPRoute) (*stnrconfv1a1.ClusterC
 		backendStatus = routeError.Error()
 	}
 	r.log.V(2).Info("renderCluster ready", "route", store.GetObjectKey(ro), "result",
-		fmt.Sprintf("%#v", cluster), "backend-error", backendStatus)
+		fmt.Sprintf("%#v", cluster), "backend-error", backendStatus, "rule-status",
+		dumpRuleStatus(rules))
 
-	return &cluster, routeError
+	return &cluster, rules, routeError
+}
+
+// renderBackend renders the endpoints for a single backend reference and sets the cluster type:
+// returns a nil endpoint list if the backend must be skipped and a non-nil error if the backend
+// could be resolved only partially
+func (r *Renderer) renderBackend(ro client.Object, b *gwapiv1b1.BackendRef, ctype *stnrconfv1a1.ClusterType) ([]string, error) {
+	if b.Group != nil && string(*b.Group) != corev1.GroupName &&
+		string(*b.Group) != stnrv1a1.GroupVersion.Group {
+		err := NewNonCriticalError(InvalidBackendGroup)
+		r.log.V(2).Info("renderCluster: invalid backend Group", "route",
+			store.GetObjectKey(ro), "backendRef", dumpBackendRef(b), "group",
+			*b.Group, "error", err.Error())
+		return nil, err
+	}
+
+	if b.Kind != nil && string(*b.Kind) != "Service" && string(*b.Kind) != "StaticService" {
+		err := NewNonCriticalError(InvalidBackendKind)
+		r.log.V(2).Info("renderCluster: invalid backend Kind", "route",
+			store.GetObjectKey(ro), "backendRef", dumpBackendRef(b), "kind", *b.Kind,
+			"error", err)
+		return nil, err
+	}
+
+	// default is the local namespace of the route
+	ns := ro.GetNamespace()
+	if b.Namespace != nil {
+		ns = string(*b.Namespace)
+	}
+
+	// cross-namespace references must be permitted by a ReferenceGrant
+	if !isRefPermitted(getRouteRef(ro), getBackendRef(b, ns)) {
+		err := NewNonCriticalError(RefNotPermitted)
+		r.log.Info("renderCluster: cross-namespace backend reference not permitted by any "+
+			"ReferenceGrant", "route", store.GetObjectKey(ro), "backendRef",
+			dumpBackendRef(b), "error", err)
+		return nil, err
+	}
+
+	ep := []string{}
+	switch {
+	case store.IsReferenceService(b):
+		var errEDS, routeError error
+
+		// get endpoints (checks EDS inline)
+		if config.EnableEndpointDiscovery {
+			epEDS, ctypeEDS, err := getEndpointsForService(b, ns)
+			if err != nil {
+				r.log.V(1).Info("renderCluster: error rendering Endpoints for Service backend",
+					"route", store.GetObjectKey(ro), "backendRef", dumpBackendRef(b),
+					"error", err)
+				errEDS = err
+				routeError = err
+			} else {
+				ep = append(ep, epEDS...)
+				*ctype = ctypeEDS
+			}
+		}
+
+		// the clusterIP or STRICT_DNS cluster if EDS is disabled
+		epCluster, ctypeCluster, errCluster := getClusterRouteForService(b, ns)
+		if errCluster != nil {
+			r.log.V(1).Info("renderCluster: error rendering service-route (ClusterIP/DNS route) for Service backend",
+				"route", store.GetObjectKey(ro), "backendRef", dumpBackendRef(b),
+				"error", errCluster)
+			routeError = errCluster
+		} else {
+			ep = append(ep, epCluster...)
+			*ctype = ctypeCluster
+		}
+
+		if errCluster != nil && errEDS != nil {
+			// both attempts failed: skip backend
+			r.log.V(1).Info("renderCluster: skipping Service backend", "route",
+				store.GetObjectKey(ro), "backendRef", dumpBackendRef(b),
+				"reason", routeError)
+			return nil, NewNonCriticalError(BackendNotFound)
+		}
+
+		return ep, routeError
+
+	case store.IsReferenceStaticService(b):
+		var err error
+		ep, *ctype, err = getEndpointsForStaticService(b, ns)
+		if err != nil {
+			r.log.Info("renderCluster: error rendering endpoints for StaticService backend",
+				"route", store.GetObjectKey(ro), "backendRef", dumpBackendRef(b),
+				"error", err)
+			return nil, err
+		}
+
+	default:
+		// error could also be InvalidBackendGroup: both are reported with the same
+		// reason in the route status
+		err := NewNonCriticalError(InvalidBackendKind)
+		r.log.Info("renderCluster: invalid backend Kind and/or Group", "route", store.GetObjectKey(ro),
+			"backendRef", dumpBackendRef(b), "error", err)
+		return nil, err
+	}
+
+	return ep, nil
+}
+
+// TCPRoutes are rendered into TCP clusters, everything else defaults to UDP
+func getClusterProtocol(ro client.Object) stnrconfv1a1.ClusterProtocol {
+	if _, ok := ro.(*gwapiv1a2.TCPRoute); ok {
+		return stnrconfv1a1.ClusterProtocolTCP
+	}
+	return stnrconfv1a1.ClusterProtocolUDP
 }
 
 func getEndpointsForService(b *gwapiv1b1.BackendRef, ns string) ([]string, stnrconfv1a1.ClusterType, error) {
@@ -240,3 +312,66 @@ func getEndpointsForStaticService(b *gwapiv1b1.BackendRef, ns string) ([]string,
 
 	return ep, stnrconfv1a1.ClusterTypeStatic, nil
 }
+
+// backendStatus reports on the use of a backend in a route rule
+type backendStatus struct {
+	ref    string
+	weight int32
+	used   bool
+	reason string
+}
+
+// ruleStatus reports on the backends of a route rule
+type ruleStatus struct {
+	backends []backendStatus
+}
+
+func (s ruleStatus) String() string {
+	bs := []string{}
+	for _, b := range s.backends {
+		status := "used"
+		if !b.used {
+			status = fmt.Sprintf("ignored: %s", b.reason)
+		} else if b.reason != "" {
+			status = fmt.Sprintf("used: %s", b.reason)
+		}
+		bs = append(bs, fmt.Sprintf("%s (weight: %d, %s)", b.ref, b.weight, status))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(bs, ", "))
+}
+
+func dumpRuleStatus(rules []ruleStatus) string {
+	rs := []string{}
+	for i, r := range rules {
+		rs = append(rs, fmt.Sprintf("rule %d: %s", i, r.String()))
+	}
+	return strings.Join(rs, "; ")
+}
+
+// getBackendRef returns the target of a backend reference for checking ReferenceGrants
+func getBackendRef(b *gwapiv1b1.BackendRef, ns string) objectRef {
+	ref := objectRef{group: corev1.GroupName, kind: "Service", namespace: ns, name: string(b.Name)}
+	if b.Group != nil {
+		ref.group = string(*b.Group)
+	}
+	if b.Kind != nil {
+		ref.kind = string(*b.Kind)
+	}
+	return ref
+}
+
+// getBackendWeight returns the weight of a backend: the default weight is 1
+func getBackendWeight(b *gwapiv1b1.BackendRef) int32 {
+	if b.Weight == nil {
+		return 1
+	}
+	return *b.Weight
+}
+
+func dumpBackendName(b *gwapiv1b1.BackendRef, defaultNs string) string {
+	ns := defaultNs
+	if b.Namespace != nil {
+		ns = string(*b.Namespace)
+	}
+	return types.NamespacedName{Namespace: ns, Name: string(b.Name)}.String()
+}
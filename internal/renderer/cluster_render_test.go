@@ -7,6 +7,9 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	meta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
@@ -27,7 +30,7 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				s1 := testutils.TestSvc.DeepCopy()
 				s1.Spec.ClusterIP = "1.1.1.1"
@@ -54,7 +57,7 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				gw := testutils.TestGw.DeepCopy()
 				gw.Spec.GatewayClassName = gwapiv1b1.ObjectName("dummy")
@@ -77,7 +80,7 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {},
 			tester: func(t *testing.T, r *Renderer) {
 				rs := store.UDPRoutes.GetAll()
@@ -114,7 +117,7 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				udp := testutils.TestUDPRoute.DeepCopy()
 				udp.SetName("udproute-wrong")
@@ -153,7 +156,7 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				udp := testutils.TestUDPRoute.DeepCopy()
 				udp.SetName("udproute-wrong")
@@ -196,7 +199,7 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				udp := testutils.TestUDPRoute.DeepCopy()
 				kind := gwapiv1b1.Kind("dummy")
@@ -238,7 +241,8 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
+			rgs:  []gwapiv1b1.ReferenceGrant{testutils.TestReferenceGrant},
 			prep: func(c *renderTestConfig) {
 				udp := testutils.TestUDPRoute.DeepCopy()
 				ns := gwapiv1b1.Namespace("dummy")
@@ -280,10 +284,14 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				udp := testutils.TestUDPRoute.DeepCopy()
 				ns := gwapiv1b1.Namespace("dummy-ns")
+				// cross-namespace backend references must be permitted
+				rg := testutils.TestReferenceGrant.DeepCopy()
+				rg.SetNamespace(string(ns))
+				c.rgs = []gwapiv1b1.ReferenceGrant{*rg}
 				udp.Spec.Rules[0].BackendRefs = make([]gwapiv1b1.BackendRef, 3)
 				udp.Spec.Rules[0].BackendRefs[0].Namespace = &ns
 				udp.Spec.Rules[0].BackendRefs[0].Name = "dummy"
@@ -327,7 +335,7 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				s := testutils.TestSvc.DeepCopy()
 				s.Spec.ClusterIP = "4.3.2.1"
@@ -370,7 +378,7 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {},
 			tester: func(t *testing.T, r *Renderer) {
 				rs := store.UDPRoutes.GetAll()
@@ -412,7 +420,7 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				s := testutils.TestSvc.DeepCopy()
 				s.Spec.ClusterIP = "4.3.2.1"
@@ -456,7 +464,7 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				s := testutils.TestSvc.DeepCopy()
 				s.Spec.ClusterIP = "None"
@@ -502,7 +510,7 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				udp := testutils.TestUDPRoute.DeepCopy()
 				udp.SetName("udproute-wrong")
@@ -541,7 +549,7 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				udp := testutils.TestUDPRoute.DeepCopy()
 				udp.SetName("udproute-wrong")
@@ -584,7 +592,7 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				udp := testutils.TestUDPRoute.DeepCopy()
 				kind := gwapiv1b1.Kind("dummy")
@@ -627,10 +635,14 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				udp := testutils.TestUDPRoute.DeepCopy()
 				ns := gwapiv1b1.Namespace("dummy")
+				// cross-namespace backend references must be permitted
+				rg := testutils.TestReferenceGrant.DeepCopy()
+				rg.SetNamespace(string(ns))
+				c.rgs = []gwapiv1b1.ReferenceGrant{*rg}
 				udp.Spec.Rules[0].BackendRefs[0].Namespace = &ns
 				c.rs = []gwapiv1a2.UDPRoute{*udp}
 
@@ -640,9 +652,9 @@ func TestRenderClusterRender(t *testing.T) {
 				s1.Spec.ClusterIP = "1.1.1.1"
 				c.svcs = []corev1.Service{*s1}
 
-				e := testutils.TestEndpoint.DeepCopy()
+				e := testutils.TestEndpointSlice.DeepCopy()
 				e.SetNamespace("dummy")
-				c.eps = []corev1.Endpoints{*e}
+				c.eps = []discoveryv1.EndpointSlice{*e}
 			},
 			tester: func(t *testing.T, r *Renderer) {
 				rs := store.UDPRoutes.GetAll()
@@ -683,10 +695,14 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				udp := testutils.TestUDPRoute.DeepCopy()
 				ns := gwapiv1b1.Namespace("dummy-ns")
+				// cross-namespace backend references must be permitted
+				rg := testutils.TestReferenceGrant.DeepCopy()
+				rg.SetNamespace(string(ns))
+				c.rgs = []gwapiv1b1.ReferenceGrant{*rg}
 				udp.Spec.Rules[0].BackendRefs = make([]gwapiv1b1.BackendRef, 3)
 				udp.Spec.Rules[0].BackendRefs[0].Namespace = &ns
 				udp.Spec.Rules[0].BackendRefs[0].Name = "dummy"
@@ -714,18 +730,18 @@ func TestRenderClusterRender(t *testing.T) {
 
 				c.svcs = []corev1.Service{*s1, *s2, *s3}
 
-				e1 := testutils.TestEndpoint.DeepCopy()
+				e1 := testutils.TestEndpointSlice.DeepCopy()
 				e1.SetNamespace("dummy-ns")
-				e1.SetName("testservice-ok-1")
-
-				e2 := testutils.TestEndpoint.DeepCopy()
-				e2.SetName("testservice-ok-2")
-				e2.Subsets = []corev1.EndpointSubset{{
-					Addresses: []corev1.EndpointAddress{{
-						IP: "1.2.3.8",
-					}},
+				e1.SetName("testservice-ok-1-slice")
+				e1.SetLabels(map[string]string{discoveryv1.LabelServiceName: "testservice-ok-1"})
+
+				e2 := testutils.TestEndpointSlice.DeepCopy()
+				e2.SetName("testservice-ok-2-slice")
+				e2.SetLabels(map[string]string{discoveryv1.LabelServiceName: "testservice-ok-2"})
+				e2.Endpoints = []discoveryv1.Endpoint{{
+					Addresses: []string{"1.2.3.8"},
 				}}
-				c.eps = []corev1.Endpoints{*e1, *e2}
+				c.eps = []discoveryv1.EndpointSlice{*e1, *e2}
 
 			},
 			tester: func(t *testing.T, r *Renderer) {
@@ -767,10 +783,14 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				udp := testutils.TestUDPRoute.DeepCopy()
 				ns := gwapiv1b1.Namespace("dummy-ns")
+				// cross-namespace backend references must be permitted
+				rg := testutils.TestReferenceGrant.DeepCopy()
+				rg.SetNamespace(string(ns))
+				c.rgs = []gwapiv1b1.ReferenceGrant{*rg}
 				udp.Spec.Rules[0].BackendRefs = []gwapiv1b1.BackendRef{
 					{
 						BackendObjectReference: gwapiv1b1.BackendObjectReference{
@@ -807,10 +827,11 @@ func TestRenderClusterRender(t *testing.T) {
 				s2.Spec.ClusterIP = "1.1.1.1"
 				c.svcs = []corev1.Service{*s1, *s2}
 
-				e := testutils.TestEndpoint.DeepCopy()
+				e := testutils.TestEndpointSlice.DeepCopy()
 				e.SetNamespace("dummy-ns")
-				e.SetName("testservice-ok-1")
-				c.eps = []corev1.Endpoints{*e}
+				e.SetName("testservice-ok-1-slice")
+				e.SetLabels(map[string]string{discoveryv1.LabelServiceName: "testservice-ok-1"})
+				c.eps = []discoveryv1.EndpointSlice{*e}
 
 			},
 			tester: func(t *testing.T, r *Renderer) {
@@ -916,7 +937,7 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:   []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:    []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs:  []corev1.Service{testutils.TestSvc},
-			eps:   []corev1.Endpoints{testutils.TestEndpoint},
+			eps:   []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			ssvcs: []stnrv1a1.StaticService{testutils.TestStaticSvc},
 			prep: func(c *renderTestConfig) {
 				group := gwapiv1b1.Group(stnrv1a1.GroupVersion.Group)
@@ -961,7 +982,7 @@ func TestRenderClusterRender(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				group := gwapiv1b1.Group(stnrv1a1.GroupVersion.Group)
 				kind := gwapiv1b1.Kind("StaticService")
@@ -1015,6 +1036,228 @@ func TestRenderClusterRender(t *testing.T) {
 				assert.Contains(t, rc.Endpoints, "1.2.3.6", "Service endpoint ip-3")
 				assert.Contains(t, rc.Endpoints, "1.2.3.7", "Service endpoint ip-4")
 
+				// restore
+				config.EnableEndpointDiscovery = opdefault.DefaultEnableEndpointDiscovery
+				config.EnableRelayToClusterIP = opdefault.DefaultEnableRelayToClusterIP
+			},
+		},
+		{
+			name:  "multiple rules and weights",
+			cls:   []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:   []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:   []gwapiv1b1.Gateway{testutils.TestGw},
+			svcs:  []corev1.Service{testutils.TestSvc},
+			eps:   []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
+			ssvcs: []stnrv1a1.StaticService{testutils.TestStaticSvc},
+			prep: func(c *renderTestConfig) {
+				group := gwapiv1b1.Group(stnrv1a1.GroupVersion.Group)
+				kind := gwapiv1b1.Kind("StaticService")
+				w0, w10 := int32(0), int32(10)
+				udp := testutils.TestUDPRoute.DeepCopy()
+				udp.Spec.Rules = []gwapiv1a2.UDPRouteRule{{
+					BackendRefs: []gwapiv1b1.BackendRef{{
+						BackendObjectReference: gwapiv1b1.BackendObjectReference{
+							Name: "testservice-ok",
+						},
+						Weight: &w10,
+					}, {
+						BackendObjectReference: gwapiv1b1.BackendObjectReference{
+							Name: "dummy-svc",
+						},
+					}},
+				}, {
+					BackendRefs: []gwapiv1b1.BackendRef{{
+						BackendObjectReference: gwapiv1b1.BackendObjectReference{
+							Group: &group,
+							Kind:  &kind,
+							Name:  "teststaticservice-ok",
+						},
+						Weight: &w0,
+					}, {
+						BackendObjectReference: gwapiv1b1.BackendObjectReference{
+							Name: "testservice-ok",
+						},
+					}},
+				}}
+				c.rs = []gwapiv1a2.UDPRoute{*udp}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				rs := store.UDPRoutes.GetAll()
+				assert.Len(t, rs, 1, "route len")
+
+				config.EnableEndpointDiscovery = true
+				config.EnableRelayToClusterIP = false
+
+				rc, rules, err := r.renderClusterWithStatus(rs[0])
+				assert.Error(t, err, "render cluster")
+				assert.True(t, IsNonCritical(err), "non-critical error")
+
+				assert.Equal(t, "testnamespace/udproute-ok", rc.Name, "cluster name")
+				assert.Equal(t, "STATIC", rc.Type, "cluster type")
+				// zero-weight static service ignored, duplicate service endpoints merged
+				assert.Len(t, rc.Endpoints, 4, "endpoints len")
+				assert.NotContains(t, rc.Endpoints, "10.11.12.13", "StaticService ignored")
+				assert.Contains(t, rc.Endpoints, "1.2.3.4", "Service endpoint ip-1")
+				assert.Contains(t, rc.Endpoints, "1.2.3.7", "Service endpoint ip-4")
+
+				assert.Len(t, rules, 2, "rule status len")
+				assert.Len(t, rules[0].backends, 2, "rule 0 backends")
+				assert.Equal(t, "testnamespace/testservice-ok", rules[0].backends[0].ref, "rule 0 backend 0 ref")
+				assert.Equal(t, int32(10), rules[0].backends[0].weight, "rule 0 backend 0 weight")
+				assert.True(t, rules[0].backends[0].used, "rule 0 backend 0 used")
+				assert.Equal(t, "testnamespace/dummy-svc", rules[0].backends[1].ref, "rule 0 backend 1 ref")
+				// no endpoints found but we still have the ClusterIP route
+				assert.True(t, rules[0].backends[1].used, "rule 0 backend 1 used")
+				assert.NotEmpty(t, rules[0].backends[1].reason, "rule 0 backend 1 error reported")
+				assert.Len(t, rules[1].backends, 2, "rule 1 backends")
+				assert.Equal(t, int32(0), rules[1].backends[0].weight, "rule 1 backend 0 weight")
+				assert.False(t, rules[1].backends[0].used, "rule 1 backend 0 ignored")
+				assert.Equal(t, int32(1), rules[1].backends[1].weight, "rule 1 backend 1 default weight")
+				assert.True(t, rules[1].backends[1].used, "rule 1 backend 1 used")
+
+				// per-rule status is reported in the route status
+				ro := rs[0]
+				initRouteStatus(ro)
+				p := ro.Spec.ParentRefs[0]
+				setRouteConditionStatus(ro, &p, config.ControllerName, true, err)
+				setRouteRuleStatus(ro, rules)
+				d := meta.FindStatusCondition(ro.Status.Parents[0].Conditions,
+					string(gwapiv1b1.RouteConditionResolvedRefs))
+				assert.NotNil(t, d, "resolved-refs cond found")
+				assert.Contains(t, d.Message, "rule 0: [testnamespace/testservice-ok (weight: 10, used)",
+					"rule 0 status")
+				assert.Contains(t, d.Message, "testnamespace/teststaticservice-ok (weight: 0, ignored: weight is zero)",
+					"rule 1 status")
+
+				// restore
+				config.EnableEndpointDiscovery = opdefault.DefaultEnableEndpointDiscovery
+				config.EnableRelayToClusterIP = opdefault.DefaultEnableRelayToClusterIP
+			},
+		},
+		{
+			name:  "inconsistent cluster type - type of accepted backends",
+			cls:   []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:   []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:   []gwapiv1b1.Gateway{testutils.TestGw},
+			svcs:  []corev1.Service{testutils.TestSvc},
+			ssvcs: []stnrv1a1.StaticService{testutils.TestStaticSvc},
+			prep: func(c *renderTestConfig) {
+				group := gwapiv1b1.Group(stnrv1a1.GroupVersion.Group)
+				kind := gwapiv1b1.Kind("StaticService")
+				udp := testutils.TestUDPRoute.DeepCopy()
+				udp.Spec.Rules = []gwapiv1a2.UDPRouteRule{{
+					BackendRefs: []gwapiv1b1.BackendRef{{
+						BackendObjectReference: gwapiv1b1.BackendObjectReference{
+							Name: "testservice-ok",
+						},
+					}, {
+						BackendObjectReference: gwapiv1b1.BackendObjectReference{
+							Group: &group,
+							Kind:  &kind,
+							Name:  "teststaticservice-ok",
+						},
+					}},
+				}}
+				c.rs = []gwapiv1a2.UDPRoute{*udp}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				rs := store.UDPRoutes.GetAll()
+				assert.Len(t, rs, 1, "route len")
+
+				// Service renders into a STRICT_DNS cluster, StaticService into STATIC
+				config.EnableEndpointDiscovery = false
+				config.EnableRelayToClusterIP = false
+
+				rc, rules, err := r.renderClusterWithStatus(rs[0])
+				assert.Error(t, err, "render cluster")
+				assert.True(t, IsNonCriticalError(err, InconsitentClusterType), "error type")
+
+				assert.Equal(t, "STRICT_DNS", rc.Type, "cluster type of the accepted backend")
+				assert.Equal(t, []string{"testservice-ok.testnamespace.svc.cluster.local"},
+					rc.Endpoints, "endpoints")
+				assert.Len(t, rules, 1, "rule status len")
+				assert.True(t, rules[0].backends[0].used, "Service used")
+				assert.False(t, rules[0].backends[1].used, "StaticService ignored")
+
+				// restore
+				config.EnableEndpointDiscovery = opdefault.DefaultEnableEndpointDiscovery
+				config.EnableRelayToClusterIP = opdefault.DefaultEnableRelayToClusterIP
+			},
+		},
+		{
+			name: "all backends with zero weight errs",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			svcs: []corev1.Service{testutils.TestSvc},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
+			prep: func(c *renderTestConfig) {
+				w0 := int32(0)
+				udp := testutils.TestUDPRoute.DeepCopy()
+				udp.Spec.Rules[0].BackendRefs[0].Weight = &w0
+				c.rs = []gwapiv1a2.UDPRoute{*udp}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				rs := store.UDPRoutes.GetAll()
+				assert.Len(t, rs, 1, "route len")
+				ro := rs[0]
+
+				rc, rules, err := r.renderClusterWithStatus(ro)
+				assert.Error(t, err, "render cluster")
+				assert.True(t, IsNonCriticalError(err, BackendNotFound), "backend not found")
+				assert.Equal(t, "STATIC", rc.Type, "cluster type")
+				assert.Len(t, rc.Endpoints, 0, "endpoints len")
+				assert.False(t, rules[0].backends[0].used, "backend ignored")
+
+				initRouteStatus(ro)
+				p := ro.Spec.ParentRefs[0]
+				setRouteConditionStatus(ro, &p, config.ControllerName, true, err)
+				d := meta.FindStatusCondition(ro.Status.Parents[0].Conditions,
+					string(gwapiv1b1.RouteConditionResolvedRefs))
+				assert.NotNil(t, d, "resolved-refs cond found")
+				assert.Equal(t, metav1.ConditionFalse, d.Status, "resolved-refs status")
+			},
+		},
+		{
+			name: "cross-namespace backend - no ReferenceGrant",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
+			svcs: []corev1.Service{testutils.TestSvc},
+			prep: func(c *renderTestConfig) {
+				udp := testutils.TestUDPRoute.DeepCopy()
+				ns := gwapiv1b1.Namespace("dummy")
+				udp.Spec.Rules[0].BackendRefs[0].Namespace = &ns
+				c.rs = []gwapiv1a2.UDPRoute{*udp}
+
+				// grant for another kind: does not apply
+				rg := testutils.TestReferenceGrant.DeepCopy()
+				rg.Spec.To[0].Kind = "StaticService"
+				c.rgs = []gwapiv1b1.ReferenceGrant{*rg}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				rs := store.UDPRoutes.GetAll()
+				assert.Len(t, rs, 1, "route len")
+				ro := rs[0]
+
+				config.EnableEndpointDiscovery = false
+				config.EnableRelayToClusterIP = false
+
+				_, err := r.renderCluster(ro)
+				assert.Error(t, err, "render cluster")
+				assert.True(t, IsNonCriticalError(err, RefNotPermitted), "ref not permitted")
+
+				initRouteStatus(ro)
+				p := ro.Spec.ParentRefs[0]
+				setRouteConditionStatus(ro, &p, config.ControllerName, true, err)
+				d := meta.FindStatusCondition(ro.Status.Parents[0].Conditions,
+					string(gwapiv1b1.RouteConditionResolvedRefs))
+				assert.NotNil(t, d, "resolved-refs cond found")
+				assert.Equal(t, metav1.ConditionFalse, d.Status, "resolved-refs status")
+				assert.Equal(t, string(gwapiv1b1.RouteReasonRefNotPermitted), d.Reason,
+					"resolved-refs reason")
+
 				// restore
 				config.EnableEndpointDiscovery = opdefault.DefaultEnableEndpointDiscovery
 				config.EnableRelayToClusterIP = opdefault.DefaultEnableRelayToClusterIP
@@ -4,13 +4,18 @@ import (
 	"fmt"
 	"net"
 	"net/url"
+	"path"
 
 	appv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	apiutil "k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
@@ -46,24 +51,31 @@ func (r *Renderer) createDeployment(c *RenderContext) (*appv1.Deployment, error)
 		return nil, err
 	}
 
-	// var deployment *appv1.Deployment
+	var deployment *appv1.Deployment
 
-	// switch dataplane.Spec.Template {
-	// case config.ConfigWatcherName:
-	// 	// dataplane with config-watcher sidecar
-	// 	deployment = configWatcherDataplaneTemplate(gw)
-	// default:
-	// 	// standard dataplane: single stunnerd pod with included config file watcher
-	// 	deployment = defaultDataplaneTemplate(gw)
-	// }
-
-	deployment := defaultDataplaneTemplate(c, gw)
+	switch dataplane.Spec.Template {
+	case config.ConfigWatcherName:
+		// dataplane with config-watcher sidecar
+		deployment = configWatcherDataplaneTemplate(c, gw)
+		if dataplane.Spec.ServiceAccountName == "" {
+			deployment.Spec.Template.Spec.ServiceAccountName = configWatcherName(gw)
+		}
+	default:
+		// standard dataplane: single stunnerd pod with config discovery
+		deployment = defaultDataplaneTemplate(c, gw)
+	}
 
 	// post process
 
-	// copy spec
-	if dataplane.Spec.Replicas != nil {
-		deployment.Spec.Replicas = dataplane.Spec.Replicas
+	// copy spec: the replica count is left to the HPA when autoscaling is enabled
+	if dataplane.Spec.Autoscaling == nil {
+		if dataplane.Spec.Replicas != nil {
+			deployment.Spec.Replicas = dataplane.Spec.Replicas
+		} else if store.HorizontalPodAutoscalers.GetObject(store.GetNamespacedName(gw)) != nil {
+			// the HPA is being removed: do not keep the replica count it last set
+			replicas := opdefault.DefaultDataplaneReplicas
+			deployment.Spec.Replicas = &replicas
+		}
 	}
 
 	// grace
@@ -90,8 +102,12 @@ func (r *Renderer) createDeployment(c *RenderContext) (*appv1.Deployment, error)
 			copy(c.Command, dataplane.Spec.Command)
 		}
 		if len(dataplane.Spec.Args) != 0 {
-			c.Args = make([]string, len(dataplane.Spec.Args))
-			copy(c.Args, dataplane.Spec.Args)
+			c.Args = []string{}
+			if dataplane.Spec.Template == config.ConfigWatcherName {
+				// stunnerd must read the config file mounted by the sidecar
+				c.Args = append(c.Args, configWatcherArgs()...)
+			}
+			c.Args = append(c.Args, dataplane.Spec.Args...)
 		}
 		if len(dataplane.Spec.Env) != 0 {
 			// append
@@ -103,6 +119,22 @@ func (r *Renderer) createDeployment(c *RenderContext) (*appv1.Deployment, error)
 		if dataplane.Spec.Resources != nil {
 			dataplane.Spec.Resources.DeepCopyInto(&c.Resources)
 		}
+		if len(dataplane.Spec.VolumeMounts) != 0 {
+			// append
+			for i := range dataplane.Spec.VolumeMounts {
+				c.VolumeMounts = append(c.VolumeMounts, *dataplane.Spec.VolumeMounts[i].DeepCopy())
+			}
+		}
+		if dataplane.Spec.ContainerSecurityContext != nil {
+			c.SecurityContext = dataplane.Spec.ContainerSecurityContext.DeepCopy()
+		}
+		if dataplane.Spec.MetricsEndpointPort != nil {
+			c.Ports = append(c.Ports, corev1.ContainerPort{
+				Name:          "metrics",
+				ContainerPort: int32(*dataplane.Spec.MetricsEndpointPort),
+				Protocol:      corev1.ProtocolTCP,
+			})
+		}
 
 		found = true
 	}
@@ -110,7 +142,10 @@ func (r *Renderer) createDeployment(c *RenderContext) (*appv1.Deployment, error)
 	if !found {
 		r.log.Info("cannot find stunnerd container in dataplane Deployment template",
 			"deployment", store.DumpObject(deployment))
-		return nil, NewCriticalError(RenderingError)
+		err := NewCriticalError(RenderingError)
+		r.recordError(dataplane, err, "no %q container in dataplane template",
+			opdefault.DefaultStunnerdInstanceName)
+		return nil, err
 	}
 
 	// hostnetwork
@@ -131,6 +166,58 @@ func (r *Renderer) createDeployment(c *RenderContext) (*appv1.Deployment, error)
 		podSpec.SecurityContext = dataplane.Spec.SecurityContext
 	}
 
+	// scheduling
+	if dataplane.Spec.NodeSelector != nil {
+		podSpec.NodeSelector = dataplane.Spec.NodeSelector
+	}
+
+	if dataplane.Spec.TopologySpreadConstraints != nil {
+		podSpec.TopologySpreadConstraints = dataplane.Spec.TopologySpreadConstraints
+	}
+
+	if dataplane.Spec.PriorityClassName != "" {
+		podSpec.PriorityClassName = dataplane.Spec.PriorityClassName
+	}
+
+	// service account
+	if dataplane.Spec.ServiceAccountName != "" {
+		podSpec.ServiceAccountName = dataplane.Spec.ServiceAccountName
+	}
+
+	// image pull secrets
+	if dataplane.Spec.ImagePullSecrets != nil {
+		podSpec.ImagePullSecrets = dataplane.Spec.ImagePullSecrets
+	}
+
+	// volumes: append
+	for i := range dataplane.Spec.Volumes {
+		podSpec.Volumes = append(podSpec.Volumes, *dataplane.Spec.Volumes[i].DeepCopy())
+	}
+
+	// sidecars: append
+	for i := range dataplane.Spec.Sidecars {
+		if dataplane.Spec.Sidecars[i].Name == opdefault.DefaultStunnerdInstanceName {
+			r.log.Info("ignoring sidecar container with reserved name",
+				"dataplane", store.GetObjectKey(dataplane),
+				"container", dataplane.Spec.Sidecars[i].Name)
+			continue
+		}
+		podSpec.Containers = append(podSpec.Containers, *dataplane.Spec.Sidecars[i].DeepCopy())
+	}
+
+	// pod annotations: the annotations set by the operator take precedence
+	if len(dataplane.Spec.PodAnnotations) != 0 {
+		podMeta := &deployment.Spec.Template.ObjectMeta
+		annotations := make(map[string]string)
+		for k, v := range dataplane.Spec.PodAnnotations {
+			annotations[k] = v
+		}
+		for k, v := range podMeta.GetAnnotations() {
+			annotations[k] = v
+		}
+		podMeta.SetAnnotations(annotations)
+	}
+
 	// owned by the Gateway
 	if err := controllerutil.SetOwnerReference(gw, deployment, r.scheme); err != nil {
 		r.log.Error(err, "cannot set owner reference", "owner", store.GetObjectKey(gw),
@@ -149,6 +236,123 @@ func (r *Renderer) createDeployment(c *RenderContext) (*appv1.Deployment, error)
 	return deployment, nil
 }
 
+// createDaemonSet creates a new DaemonSet for a managed Gateway. The DaemonSet is rendered from
+// the same template as the Deployment (see createDeployment), except that it does not have a
+// replica count.
+func (r *Renderer) createDaemonSet(c *RenderContext) (*appv1.DaemonSet, error) {
+	dp, err := r.createDeployment(c)
+	if err != nil {
+		return nil, err
+	}
+
+	ds := &appv1.DaemonSet{
+		ObjectMeta: *dp.ObjectMeta.DeepCopy(),
+		Spec: appv1.DaemonSetSpec{
+			Selector: dp.Spec.Selector.DeepCopy(),
+			Template: *dp.Spec.Template.DeepCopy(),
+		},
+	}
+
+	return ds, nil
+}
+
+// createHPA creates a HorizontalPodAutoscaler for the Deployment of a managed Gateway.
+func (r *Renderer) createHPA(c *RenderContext, dataplane *stnrv1a1.Dataplane) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	gw := c.gws.GetFirst()
+	if gw == nil || dataplane.Spec.Autoscaling == nil {
+		return nil, NewCriticalError(RenderingError)
+	}
+
+	as := dataplane.Spec.Autoscaling
+	dp := defaultDeploymentSkeleton(gw)
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: dp.ObjectMeta,
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: appv1.SchemeGroupVersion.String(),
+				Kind:       "Deployment",
+				Name:       dp.GetName(),
+			},
+			MinReplicas: as.MinReplicas,
+			MaxReplicas: as.MaxReplicas,
+		},
+	}
+
+	if len(as.Metrics) != 0 {
+		hpa.Spec.Metrics = make([]autoscalingv2.MetricSpec, len(as.Metrics))
+		for i := range as.Metrics {
+			as.Metrics[i].DeepCopyInto(&hpa.Spec.Metrics[i])
+		}
+	}
+
+	if as.Behavior != nil {
+		hpa.Spec.Behavior = as.Behavior.DeepCopy()
+	}
+
+	if err := controllerutil.SetOwnerReference(gw, hpa, r.scheme); err != nil {
+		r.log.Error(err, "cannot set owner reference", "owner", store.GetObjectKey(gw),
+			"reference", store.GetObjectKey(hpa))
+		return nil, NewCriticalError(RenderingError)
+	}
+
+	return hpa, nil
+}
+
+// createPDB creates a PodDisruptionBudget for the stunnerd pods of a managed Gateway.
+func (r *Renderer) createPDB(c *RenderContext, dataplane *stnrv1a1.Dataplane) (*policyv1.PodDisruptionBudget, error) {
+	gw := c.gws.GetFirst()
+	if gw == nil || dataplane.Spec.DisruptionBudget == nil {
+		return nil, NewCriticalError(RenderingError)
+	}
+
+	db := dataplane.Spec.DisruptionBudget
+	dp := defaultDeploymentSkeleton(gw)
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: dp.ObjectMeta,
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector: dp.Spec.Selector,
+		},
+	}
+
+	if db.MinAvailable != nil {
+		v := *db.MinAvailable
+		pdb.Spec.MinAvailable = &v
+	}
+
+	if db.MaxUnavailable != nil {
+		v := *db.MaxUnavailable
+		pdb.Spec.MaxUnavailable = &v
+	}
+
+	if err := controllerutil.SetOwnerReference(gw, pdb, r.scheme); err != nil {
+		r.log.Error(err, "cannot set owner reference", "owner", store.GetObjectKey(gw),
+			"reference", store.GetObjectKey(pdb))
+		return nil, NewCriticalError(RenderingError)
+	}
+
+	return pdb, nil
+}
+
+// removeHPA schedules the HorizontalPodAutoscaler of a managed Gateway for deletion, if any.
+func (r *Renderer) removeHPA(c *RenderContext) {
+	gw := c.gws.GetFirst()
+	if gw == nil || store.HorizontalPodAutoscalers.GetObject(store.GetNamespacedName(gw)) == nil {
+		return
+	}
+	hpa := defaultHPASkeleton(gw)
+	c.update.DeleteQueue.HPAs.Upsert(&hpa)
+}
+
+// removePDB schedules the PodDisruptionBudget of a managed Gateway for deletion, if any.
+func (r *Renderer) removePDB(c *RenderContext) {
+	gw := c.gws.GetFirst()
+	if gw == nil || store.PodDisruptionBudgets.GetObject(store.GetNamespacedName(gw)) == nil {
+		return
+	}
+	pdb := defaultPDBSkeleton(gw)
+	c.update.DeleteQueue.PDBs.Upsert(&pdb)
+}
+
 func getDataplane(c *RenderContext) (*stnrv1a1.Dataplane, error) {
 	dataplaneName := opdefault.DefaultDataplaneName
 	if c.gwConf != nil && c.gwConf.Spec.Dataplane != nil {
@@ -278,6 +482,41 @@ func defaultDeploymentSkeleton(gateway *gwapiv1b1.Gateway) appv1.Deployment {
 	return dp
 }
 
+// defaultDaemonSetSkeleton returns a DaemonSet that can be used to identify the DaemonSet of a
+// managed Gateway.
+func defaultDaemonSetSkeleton(gateway *gwapiv1b1.Gateway) appv1.DaemonSet {
+	dp := defaultDeploymentSkeleton(gateway)
+	return appv1.DaemonSet{
+		ObjectMeta: dp.ObjectMeta,
+		Spec: appv1.DaemonSetSpec{
+			Selector: dp.Spec.Selector,
+			Template: dp.Spec.Template,
+		},
+	}
+}
+
+// getDataplaneResourceType returns the kind of the resource to deploy stunnerd with.
+func getDataplaneResourceType(dataplane *stnrv1a1.Dataplane) stnrv1a1.DataplaneResourceType {
+	if dataplane == nil || dataplane.Spec.DataplaneResource == nil {
+		return stnrv1a1.DataplaneResourceDeployment
+	}
+	return *dataplane.Spec.DataplaneResource
+}
+
+// defaultHPASkeleton returns a HorizontalPodAutoscaler that can be used to identify the HPA of a
+// managed Gateway.
+func defaultHPASkeleton(gateway *gwapiv1b1.Gateway) autoscalingv2.HorizontalPodAutoscaler {
+	dp := defaultDeploymentSkeleton(gateway)
+	return autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: dp.ObjectMeta}
+}
+
+// defaultPDBSkeleton returns a PodDisruptionBudget that can be used to identify the PDB of a
+// managed Gateway.
+func defaultPDBSkeleton(gateway *gwapiv1b1.Gateway) policyv1.PodDisruptionBudget {
+	dp := defaultDeploymentSkeleton(gateway)
+	return policyv1.PodDisruptionBudget{ObjectMeta: dp.ObjectMeta}
+}
+
 // defaultDataplaneTemplate post-processes a deployment skeleton into a default dataplane
 func defaultDataplaneTemplate(c *RenderContext, gateway *gwapiv1b1.Gateway) *appv1.Deployment {
 	podAddrFieldSelector := corev1.ObjectFieldSelector{FieldPath: "status.podIP"}
@@ -290,6 +529,7 @@ func defaultDataplaneTemplate(c *RenderContext, gateway *gwapiv1b1.Gateway) *app
 	if addr, err := net.ResolveTCPAddr("tcp", config.ConfigDiscoveryAddress); err == nil {
 		port = fmt.Sprintf("%d", addr.Port)
 	}
+	// stunnerd v0.16 supports neither TLS nor client authentication for config discovery
 	cdsAddr := url.URL{
 		Scheme: "http",
 		Host:   config.ConfigDiscoveryAddress,
@@ -338,71 +578,207 @@ func defaultDataplaneTemplate(c *RenderContext, gateway *gwapiv1b1.Gateway) *app
 }
 
 // // configWatcherDataplaneTemplate post-processes a deployment skeleton into a dataplane with a config-watcher sidecar.
-// func configWatcherDataplaneTemplate(gateway *gwapiv1b1.Gateway) *appv1.Deployment {
-// 	podIPFieldSelector := corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "status.podIP"}
-// 	podIPEnvVarSource := corev1.EnvVarSource{FieldRef: &podIPFieldSelector}
-
-// 	emptyDir := corev1.EmptyDirVolumeSource{}
-// 	volumeMounts := []corev1.VolumeMount{{
-// 		Name:      config.ConfigVolumeName,
-// 		MountPath: "/etc/stunnerd",
-// 		ReadOnly:  true,
-// 	}}
-
-// 	dp := defaultDeploymentSkeleton(gateway)
-// 	dp.Spec.Template.Spec = corev1.PodSpec{
-// 		Containers: []corev1.Container{{
-// 			Name:    opdefault.DefaultStunnerdInstanceName,
-// 			Image:   config.StunnerdImage,
-// 			Command: []string{"stunnerd"},
-// 			Args:    []string{"-w", "-c", "/etc/stunnerd/stunnerd.conf", "--udp-thread-num=16"},
-// 			Env: []corev1.EnvVar{{
-// 				Name:      "STUNNER_ADDR",
-// 				ValueFrom: &podIPEnvVarSource,
-// 			}},
-// 			Resources: corev1.ResourceRequirements{
-// 				Limits:   config.ResourceLimit,
-// 				Requests: config.ResourceRequest,
-// 			},
-// 			VolumeMounts:    volumeMounts,
-// 			LivenessProbe:   &config.LivenessProbe,
-// 			ReadinessProbe:  &config.ReadinessProbe,
-// 			ImagePullPolicy: corev1.PullAlways,
-// 		}, {
-// 			Name:  "config-watcher",
-// 			Image: config.ConfigWatcherImage,
-// 			Env: []corev1.EnvVar{{
-// 				Name:  "LABEL",
-// 				Value: "stunner.l7mp.io/owned-by",
-// 			}, {
-// 				Name:  "LABEL_VALUE",
-// 				Value: "stunner",
-// 			}, {
-// 				Name:  "FOLDER",
-// 				Value: "/etc/stunnerd",
-// 			}, {
-// 				Name:  "RESOURCE",
-// 				Value: "configmap",
-// 			}, {
-// 				Name:  "NAMESPACE",
-// 				Value: "stunner",
-// 			}},
-// 			Resources: corev1.ResourceRequirements{
-// 				Limits:   config.ResourceLimit,
-// 				Requests: config.ResourceRequest,
-// 			},
-// 			VolumeMounts:    volumeMounts,
-// 			LivenessProbe:   &config.LivenessProbe,
-// 			ReadinessProbe:  &config.ReadinessProbe,
-// 			ImagePullPolicy: corev1.PullIfNotPresent,
-// 		}},
-// 		Volumes: []corev1.Volume{{
-// 			Name:         config.ConfigVolumeName,
-// 			VolumeSource: corev1.VolumeSource{EmptyDir: &emptyDir},
-// 		}},
-// 		TerminationGracePeriodSeconds: &config.TerminationGrace,
-// 		HostNetwork:                   false,
-// 	}
-
-// 	return &dp
-// }
+// configWatcherDataplaneTemplate post-processes a deployment skeleton into a dataplane that
+// receives the running config from the ConfigMap via a config-watcher sidecar container.
+func configWatcherDataplaneTemplate(c *RenderContext, gateway *gwapiv1b1.Gateway) *appv1.Deployment {
+	podAddrFieldSelector := corev1.ObjectFieldSelector{FieldPath: "status.podIP"}
+	podAddrEnvVarSource := corev1.EnvVarSource{FieldRef: &podAddrFieldSelector}
+
+	livenessProbe, readinessProbe := getHealthCheckParameters(c)
+
+	// the ConfigMap to watch
+	targetName, targetNamespace := gateway.GetName(), gateway.GetNamespace()
+	if c.gwConf != nil {
+		if name, namespace := getTarget(c); name != "" {
+			targetName, targetNamespace = name, namespace
+		}
+	}
+
+	emptyDir := corev1.EmptyDirVolumeSource{}
+	volumeMount := corev1.VolumeMount{
+		Name:      config.ConfigVolumeName,
+		MountPath: config.ConfigVolumeMountPath,
+	}
+	readOnlyMount := volumeMount
+	readOnlyMount.ReadOnly = true
+
+	dp := defaultDeploymentSkeleton(gateway)
+	dp.Spec.Template.Spec = corev1.PodSpec{
+		Containers: []corev1.Container{{
+			Name:    opdefault.DefaultStunnerdInstanceName,
+			Image:   config.StunnerdImage,
+			Command: []string{"stunnerd"},
+			Args:    append(configWatcherArgs(), "--udp-thread-num=16"),
+			Env: []corev1.EnvVar{{
+				Name:      "STUNNER_ADDR", // default transport relay address
+				ValueFrom: &podAddrEnvVarSource,
+			}, {
+				Name:  "STUNNER_NAME", // gateway name for creating the stunnerd id
+				Value: gateway.GetName(),
+			}, {
+				Name:  "STUNNER_NAMESPACE", // gateway namespace for creating the stunnerd id
+				Value: gateway.GetNamespace(),
+			}},
+			Resources: corev1.ResourceRequirements{
+				Limits:   config.ResourceLimit,
+				Requests: config.ResourceRequest,
+			},
+			VolumeMounts:    []corev1.VolumeMount{readOnlyMount},
+			LivenessProbe:   livenessProbe,
+			ReadinessProbe:  readinessProbe,
+			ImagePullPolicy: corev1.PullAlways,
+		}, {
+			Name:  config.ConfigWatcherName,
+			Image: config.ConfigWatcherImage,
+			Env: []corev1.EnvVar{{
+				Name:  "LABEL",
+				Value: opdefault.OwnedByLabelKey,
+			}, {
+				Name:  "LABEL_VALUE",
+				Value: opdefault.OwnedByLabelValue,
+			}, {
+				Name:  "RESOURCE_NAME",
+				Value: targetName,
+			}, {
+				Name:  "NAMESPACE",
+				Value: targetNamespace,
+			}, {
+				Name:  "FOLDER",
+				Value: config.ConfigVolumeMountPath,
+			}, {
+				Name:  "RESOURCE",
+				Value: "configmap",
+			}},
+			VolumeMounts:    []corev1.VolumeMount{volumeMount},
+			ImagePullPolicy: corev1.PullIfNotPresent,
+		}},
+		Volumes: []corev1.Volume{{
+			Name:         config.ConfigVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &emptyDir},
+		}},
+		TerminationGracePeriodSeconds: &config.TerminationGrace,
+		HostNetwork:                   false,
+	}
+
+	return &dp
+}
+
+// configWatcherArgs returns the stunnerd command line arguments needed to load and watch the
+// config file maintained by the config-watcher sidecar.
+func configWatcherArgs() []string {
+	return []string{"-w", "-c", path.Join(config.ConfigVolumeMountPath,
+		opdefault.DefaultStunnerdConfigfileName)}
+}
+
+// createConfigWatcherRBAC creates the ServiceAccount, Role and RoleBinding that allow the
+// config-watcher sidecar of a managed Gateway to watch the ConfigMap of the Gateway. No
+// ServiceAccount is created if the Dataplane specifies one.
+func (r *Renderer) createConfigWatcherRBAC(c *RenderContext, dataplane *stnrv1a1.Dataplane) (*corev1.ServiceAccount, *rbacv1.Role, *rbacv1.RoleBinding, error) {
+	gw := c.gws.GetFirst()
+	if gw == nil {
+		return nil, nil, nil, NewCriticalError(RenderingError)
+	}
+
+	meta := defaultDeploymentSkeleton(gw).ObjectMeta
+	meta.SetName(configWatcherName(gw))
+
+	var sa *corev1.ServiceAccount
+	saName := dataplane.Spec.ServiceAccountName
+	if saName == "" {
+		saName = configWatcherName(gw)
+		sa = &corev1.ServiceAccount{ObjectMeta: *meta.DeepCopy()}
+	}
+
+	role := &rbacv1.Role{
+		ObjectMeta: *meta.DeepCopy(),
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{""},
+			Resources: []string{"configmaps"},
+			Verbs:     []string{"get", "list", "watch"},
+		}},
+	}
+
+	binding := &rbacv1.RoleBinding{
+		ObjectMeta: *meta.DeepCopy(),
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      saName,
+			Namespace: gw.GetNamespace(),
+		}},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     role.GetName(),
+		},
+	}
+
+	objs := []client.Object{role, binding}
+	if sa != nil {
+		objs = append(objs, sa)
+	}
+	for _, o := range objs {
+		if err := controllerutil.SetOwnerReference(gw, o, r.scheme); err != nil {
+			r.log.Error(err, "cannot set owner reference", "owner", store.GetObjectKey(gw),
+				"reference", store.GetObjectKey(o))
+			return nil, nil, nil, NewCriticalError(RenderingError)
+		}
+	}
+
+	return sa, role, binding, nil
+}
+
+// removeConfigWatcherRBAC schedules the ServiceAccount, Role and RoleBinding of the
+// config-watcher sidecar of a managed Gateway for deletion if the live dataplane of the Gateway
+// uses the config-watcher template. If keepRole is set then only the ServiceAccount is removed,
+// which is useful when the Dataplane switches to a user-provided ServiceAccount.
+func (r *Renderer) removeConfigWatcherRBAC(c *RenderContext, keepRole bool) {
+	gw := c.gws.GetFirst()
+	if gw == nil {
+		return
+	}
+
+	podSpec := getLiveDataplanePodSpec(gw)
+	if podSpec == nil {
+		return
+	}
+
+	meta := defaultDeploymentSkeleton(gw).ObjectMeta
+	meta.SetName(configWatcherName(gw))
+
+	if podSpec.ServiceAccountName == configWatcherName(gw) {
+		c.update.DeleteQueue.ServiceAccounts.Upsert(&corev1.ServiceAccount{
+			ObjectMeta: *meta.DeepCopy()})
+	}
+
+	if keepRole {
+		return
+	}
+
+	for _, container := range podSpec.Containers {
+		if container.Name == config.ConfigWatcherName {
+			c.update.DeleteQueue.Roles.Upsert(&rbacv1.Role{ObjectMeta: *meta.DeepCopy()})
+			c.update.DeleteQueue.RoleBindings.Upsert(&rbacv1.RoleBinding{
+				ObjectMeta: *meta.DeepCopy()})
+			return
+		}
+	}
+}
+
+// getLiveDataplanePodSpec returns the pod spec of the live Deployment or DaemonSet of a managed
+// Gateway, or nil if there is none.
+func getLiveDataplanePodSpec(gw *gwapiv1b1.Gateway) *corev1.PodSpec {
+	key := store.GetNamespacedName(gw)
+	if dp := store.Deployments.GetObject(key); dp != nil {
+		return &dp.Spec.Template.Spec
+	}
+	if ds := store.DaemonSets.GetObject(key); ds != nil {
+		return &ds.Spec.Template.Spec
+	}
+	return nil
+}
+
+// configWatcherName returns the name of the RBAC resources created for the config-watcher
+// sidecar of a Gateway.
+func configWatcherName(gateway *gwapiv1b1.Gateway) string {
+	return fmt.Sprintf("%s-%s", gateway.GetName(), config.ConfigWatcherName)
+}
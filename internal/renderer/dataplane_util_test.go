@@ -3,6 +3,7 @@ package renderer
 import (
 	// "context"
 	//"fmt"
+	"path"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,6 +19,7 @@ import (
 
 	stnrv1a1 "github.com/l7mp/stunner-gateway-operator/api/v1alpha1"
 
+	"github.com/l7mp/stunner-gateway-operator/internal/config"
 	"github.com/l7mp/stunner-gateway-operator/internal/event"
 	"github.com/l7mp/stunner-gateway-operator/internal/store"
 	"github.com/l7mp/stunner-gateway-operator/internal/testutils"
@@ -136,123 +138,250 @@ func TestRenderDataplaneUtil(t *testing.T) {
 				assert.Nil(t, podSpec.Affinity, "affinity")
 			},
 		},
-		// {
-		// 	name: "config-watcher deployment render",
-		// 	cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
-		// 	cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
-		// 	gws:  []gwapiv1b1.Gateway{testutils.TestGw},
-		// 	dps:  []stnrv1a1.Dataplane{testutils.TestDataplane},
-		// 	prep: func(c *renderTestConfig) {
-		// 		d := testutils.TestDataplane.DeepCopy()
-		// 		d.Spec.Template = config.ConfigWatcherName
-		// 		c.dps = []stnrv1a1.Dataplane{*d}
-		// 	},
-		// 	tester: func(t *testing.T, r *Renderer) {
-		// 		gc, err := r.getGatewayClass()
-		// 		assert.NoError(t, err, "gw-class found")
-		// 		c := &RenderContext{gc: gc, gws: store.NewGatewayStore(), log: logr.Discard()}
-		// 		c.gwConf, err = r.getGatewayConfig4Class(c)
-		// 		assert.NoError(t, err, "gw-conf found")
-		// 		assert.Equal(t, "gatewayconfig-ok", c.gwConf.GetName(),
-		// 			"gatewayconfig name")
-
-		// 		c.update = event.NewEventUpdate(0)
-		// 		assert.NotNil(t, c.update, "update event create")
-
-		// 		gws := r.getGateways4Class(c)
-		// 		assert.Len(t, gws, 1, "gateways for class")
-		// 		gw := gws[0]
-		// 		c.gws.ResetGateways([]*gwapiv1b1.Gateway{gw})
-
-		// 		deploy, err := r.createDeployment(c)
-		// 		assert.NoError(t, err, "create deployment")
-
-		// 		assert.Equal(t, gw.GetName(), deploy.GetName(), "deployment name")
-		// 		assert.Equal(t, gw.GetNamespace(), deploy.GetNamespace(), "deployment namespace")
-
-		// 		labs := deploy.GetLabels()
-		// 		assert.Len(t, labs, 4, "labels len")
-		// 		v, ok := labs[opdefault.OwnedByLabelKey]
-		// 		assert.True(t, ok, "labels: owned-by")
-		// 		assert.Equal(t, opdefault.OwnedByLabelValue, v, "owned-by label value")
-		// 		v, ok = labs[opdefault.RelatedGatewayKey]
-		// 		assert.True(t, ok, "labels: related")
-		// 		assert.Equal(t, gw.GetName(), v, "related-gw label value")
-		// 		v, ok = labs[opdefault.RelatedGatewayNamespace]
-		// 		assert.True(t, ok, "labels: related")
-		// 		assert.Equal(t, gw.GetNamespace(), v, "related-gw label value")
-		// 		// label from the dataplane object
-		// 		v, ok = labs["dummy-label"]
-		// 		assert.True(t, ok, "labels: dataplane label copied")
-		// 		assert.Equal(t, "dummy-value", v, "copied dataplane label value")
-
-		// 		as := deploy.GetAnnotations()
-		// 		assert.Len(t, as, 1, "annotations len")
-		// 		gwName, ok := as[opdefault.RelatedGatewayKey]
-		// 		assert.True(t, ok, "annotations: related gw")
-		// 		// annotation is gw-namespace/gw-name
-		// 		assert.Equal(t, store.GetObjectKey(gw), gwName, "related-gateway annotation")
-
-		// 		// check the label selector
-		// 		labelSelector := deploy.Spec.Selector
-		// 		assert.NotNil(t, labelSelector, "label selector")
-
-		// 		selector, err := metav1.LabelSelectorAsSelector(labelSelector)
-		// 		assert.NoError(t, err, "label selector convert")
-
-		// 		labelToMatch := labels.Merge(
-		// 			labels.Merge(
-		// 				labels.Set{opdefault.AppLabelKey: opdefault.AppLabelValue},
-		// 				labels.Set{opdefault.RelatedGatewayKey: gw.GetName()},
-		// 			),
-		// 			labels.Set{opdefault.RelatedGatewayNamespace: gw.GetNamespace()},
-		// 		)
-		// 		assert.True(t, selector.Matches(labelToMatch), "selector matched")
-
-		// 		// spec
-		// 		assert.NotNil(t, deploy.Spec.Replicas, "replicas notnil")
-		// 		assert.Equal(t, int32(3), *deploy.Spec.Replicas, "replicas")
-
-		// 		// pod template spec
-		// 		podTemplate := &deploy.Spec.Template
-		// 		labs = podTemplate.GetLabels()
-		// 		assert.Len(t, labs, 3, "labels len")
-		// 		v, ok = labs[opdefault.AppLabelKey]
-		// 		assert.True(t, ok, "pod labels: owned-by")
-		// 		assert.Equal(t, opdefault.AppLabelValue, v, "pod owned-by label value")
-		// 		v, ok = labs[opdefault.RelatedGatewayKey]
-		// 		assert.True(t, ok, "pod related-gw label")
-		// 		assert.Equal(t, gw.GetName(), v, "related-gw label value")
-		// 		v, ok = labs[opdefault.RelatedGatewayNamespace]
-		// 		assert.True(t, ok, "pod related-gw-namespace label")
-		// 		assert.Equal(t, gw.GetNamespace(), v, "related-gw-namespace label value")
-
-		// 		// deployment selector matches pod template
-		// 		assert.True(t, selector.Matches(labels.Set(labs)), "selector matched")
-
-		// 		podSpec := &podTemplate.Spec
-		// 		assert.Len(t, podSpec.Containers, 2, "containers len")
-
-		// 		// template must be such that the first pod is the stunnerd container
-		// 		container := podSpec.Containers[0]
-		// 		assert.Equal(t, opdefault.DefaultStunnerdInstanceName, container.Name, "container 1 name")
-		// 		assert.Equal(t, "testimage-1", container.Image, "container 1 image")
-		// 		assert.Equal(t, []string{"testcommand-1"}, container.Command, "container 1 command")
-		// 		assert.Equal(t, []string{"arg-1", "arg-2"}, container.Args, "container 1 args")
-		// 		assert.Equal(t, testutils.TestResourceLimit, container.Resources.Limits, "container 1 - resource limits")
-		// 		assert.Equal(t, testutils.TestResourceRequest, container.Resources.Requests, "container 1 - resource req")
-		// 		assert.Equal(t, corev1.PullAlways, container.ImagePullPolicy, "container 1 - readiness probe")
-
-		// 		// the config watcher
-		// 		container = podSpec.Containers[1]
-		// 		assert.Equal(t, config.ConfigWatcherName, container.Name, "container 1 name")
-
-		// 		// remainder
-		// 		assert.NotNil(t, podSpec.TerminationGracePeriodSeconds, "termination grace ptr")
-		// 		assert.Equal(t, testutils.TestTerminationGrace, *podSpec.TerminationGracePeriodSeconds, "termination grace")
-		// 		assert.True(t, podSpec.HostNetwork, "hostnetwork")
-		// 		assert.Nil(t, podSpec.Affinity, "affinity")
-		// 	},
-		// },
+		{
+			name: "deployment render - pod template",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			dps:  []stnrv1a1.Dataplane{testutils.TestDataplane},
+			prep: func(c *renderTestConfig) {
+				dp := testutils.TestDataplane.DeepCopy()
+				dp.Spec.NodeSelector = map[string]string{"node-type": "turn"}
+				dp.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{{
+					MaxSkew:           1,
+					TopologyKey:       "topology.kubernetes.io/zone",
+					WhenUnsatisfiable: corev1.ScheduleAnyway,
+				}}
+				dp.Spec.PriorityClassName = "high-priority"
+				dp.Spec.ServiceAccountName = "stunnerd"
+				dp.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "regcred"}}
+				dp.Spec.Volumes = []corev1.Volume{{
+					Name:         "extra",
+					VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+				}}
+				dp.Spec.VolumeMounts = []corev1.VolumeMount{{Name: "extra", MountPath: "/extra"}}
+				dp.Spec.PodAnnotations = map[string]string{
+					"dummy-annotation":          "dummy-value",
+					opdefault.RelatedGatewayKey: "dummy",
+				}
+				readOnly := true
+				dp.Spec.ContainerSecurityContext = &corev1.SecurityContext{
+					ReadOnlyRootFilesystem: &readOnly,
+				}
+				dp.Spec.Sidecars = []corev1.Container{{
+					Name:  "sidecar",
+					Image: "sidecar-image",
+				}, {
+					Name:  opdefault.DefaultStunnerdInstanceName,
+					Image: "dummy",
+				}}
+				port := 8080
+				dp.Spec.MetricsEndpointPort = &port
+				c.dps = []stnrv1a1.Dataplane{*dp}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, gws: store.NewGatewayStore(), log: logr.Discard()}
+				c.gwConf, err = r.getGatewayConfig4Class(c)
+				assert.NoError(t, err, "gw-conf found")
+				c.update = event.NewEventUpdate(0)
+
+				gws := r.getGateways4Class(c)
+				assert.Len(t, gws, 1, "gateways for class")
+				gw := gws[0]
+				c.gws.ResetGateways([]*gwapiv1b1.Gateway{gw})
+
+				deploy, err := r.createDeployment(c)
+				assert.NoError(t, err, "create deployment")
+
+				// pod annotations: operator annotations take precedence
+				as := deploy.Spec.Template.GetAnnotations()
+				assert.Len(t, as, 2, "pod annotations len")
+				assert.Equal(t, "dummy-value", as["dummy-annotation"], "pod annotation")
+				assert.Equal(t, store.GetObjectKey(gw), as[opdefault.RelatedGatewayKey],
+					"related-gateway pod annotation")
+
+				podSpec := &deploy.Spec.Template.Spec
+				assert.Equal(t, map[string]string{"node-type": "turn"}, podSpec.NodeSelector,
+					"node selector")
+				assert.Len(t, podSpec.TopologySpreadConstraints, 1, "topology spread len")
+				assert.Equal(t, "topology.kubernetes.io/zone",
+					podSpec.TopologySpreadConstraints[0].TopologyKey, "topology key")
+				assert.Equal(t, "high-priority", podSpec.PriorityClassName, "priority class")
+				assert.Equal(t, "stunnerd", podSpec.ServiceAccountName, "service account")
+				assert.Equal(t, []corev1.LocalObjectReference{{Name: "regcred"}},
+					podSpec.ImagePullSecrets, "image pull secrets")
+				assert.Len(t, podSpec.Volumes, 1, "volumes len")
+				assert.Equal(t, "extra", podSpec.Volumes[0].Name, "volume name")
+
+				// the sidecar with the reserved name is ignored
+				assert.Len(t, podSpec.Containers, 2, "containers len")
+				container := podSpec.Containers[0]
+				assert.Equal(t, opdefault.DefaultStunnerdInstanceName, container.Name,
+					"stunnerd container name")
+				assert.Equal(t, "testimage-1", container.Image, "stunnerd container image")
+				assert.Equal(t, "sidecar", podSpec.Containers[1].Name, "sidecar name")
+				assert.Equal(t, "sidecar-image", podSpec.Containers[1].Image, "sidecar image")
+
+				assert.Len(t, container.VolumeMounts, 1, "volume mounts len")
+				assert.Equal(t, "/extra", container.VolumeMounts[0].MountPath, "mount path")
+				assert.NotNil(t, container.SecurityContext, "container security context")
+				assert.True(t, *container.SecurityContext.ReadOnlyRootFilesystem,
+					"read-only root fs")
+				assert.Len(t, container.Ports, 1, "container ports len")
+				assert.Equal(t, "metrics", container.Ports[0].Name, "metrics port name")
+				assert.Equal(t, int32(8080), container.Ports[0].ContainerPort, "metrics port")
+			},
+		},
+		{
+			name: "config-watcher deployment render",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			dps:  []stnrv1a1.Dataplane{testutils.TestDataplane},
+			prep: func(c *renderTestConfig) {
+				d := testutils.TestDataplane.DeepCopy()
+				d.Spec.Template = config.ConfigWatcherName
+				c.dps = []stnrv1a1.Dataplane{*d}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				config.DataplaneMode = config.DataplaneModeManaged
+				defer func() {
+					config.DataplaneMode = config.NewDataplaneMode(opdefault.DefaultDataplaneMode)
+				}()
+
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, gws: store.NewGatewayStore(), log: logr.Discard()}
+				c.gwConf, err = r.getGatewayConfig4Class(c)
+				assert.NoError(t, err, "gw-conf found")
+				assert.Equal(t, "gatewayconfig-ok", c.gwConf.GetName(),
+					"gatewayconfig name")
+
+				c.update = event.NewEventUpdate(0)
+				assert.NotNil(t, c.update, "update event create")
+
+				gws := r.getGateways4Class(c)
+				assert.Len(t, gws, 1, "gateways for class")
+				gw := gws[0]
+				c.gws.ResetGateways([]*gwapiv1b1.Gateway{gw})
+
+				deploy, err := r.createDeployment(c)
+				assert.NoError(t, err, "create deployment")
+
+				assert.Equal(t, gw.GetName(), deploy.GetName(), "deployment name")
+				assert.Equal(t, gw.GetNamespace(), deploy.GetNamespace(), "deployment namespace")
+
+				// check the label selector
+				labelSelector := deploy.Spec.Selector
+				assert.NotNil(t, labelSelector, "label selector")
+				selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+				assert.NoError(t, err, "label selector convert")
+				assert.True(t, selector.Matches(labels.Set(deploy.Spec.Template.GetLabels())),
+					"selector matched")
+
+				// spec
+				assert.NotNil(t, deploy.Spec.Replicas, "replicas notnil")
+				assert.Equal(t, int32(3), *deploy.Spec.Replicas, "replicas")
+
+				podSpec := &deploy.Spec.Template.Spec
+				assert.Len(t, podSpec.Containers, 2, "containers len")
+				assert.Equal(t, configWatcherName(gw), podSpec.ServiceAccountName,
+					"service account")
+
+				assert.Len(t, podSpec.Volumes, 1, "volumes len")
+				assert.Equal(t, config.ConfigVolumeName, podSpec.Volumes[0].Name, "volume name")
+				assert.NotNil(t, podSpec.Volumes[0].EmptyDir, "emptydir volume")
+
+				// template must be such that the first pod is the stunnerd container
+				container := podSpec.Containers[0]
+				assert.Equal(t, opdefault.DefaultStunnerdInstanceName, container.Name, "container 1 name")
+				assert.Equal(t, "testimage-1", container.Image, "container 1 image")
+				assert.Equal(t, []string{"testcommand-1"}, container.Command, "container 1 command")
+				// the config file args are always kept
+				assert.Equal(t, []string{"-w", "-c", path.Join(config.ConfigVolumeMountPath,
+					opdefault.DefaultStunnerdConfigfileName), "arg-1", "arg-2"}, container.Args,
+					"container 1 args")
+				for _, e := range container.Env {
+					assert.NotEqual(t, "STUNNER_CONFIG_ORIGIN", e.Name, "no config discovery")
+				}
+				assert.Len(t, container.VolumeMounts, 1, "container 1 volume mounts")
+				assert.Equal(t, config.ConfigVolumeName, container.VolumeMounts[0].Name,
+					"container 1 volume mount name")
+				assert.True(t, container.VolumeMounts[0].ReadOnly, "container 1 read-only mount")
+
+				// the config watcher
+				container = podSpec.Containers[1]
+				assert.Equal(t, config.ConfigWatcherName, container.Name, "container 2 name")
+				assert.Equal(t, config.ConfigWatcherImage, container.Image, "container 2 image")
+				env := map[string]string{}
+				for _, e := range container.Env {
+					env[e.Name] = e.Value
+				}
+				// the ConfigMap is named after the gateway in managed mode
+				assert.Equal(t, gw.GetName(), env["RESOURCE_NAME"], "watched configmap name")
+				assert.Equal(t, gw.GetNamespace(), env["NAMESPACE"], "watched configmap namespace")
+				assert.Equal(t, config.ConfigVolumeMountPath, env["FOLDER"], "config folder")
+				assert.Len(t, container.VolumeMounts, 1, "container 2 volume mounts")
+				assert.False(t, container.VolumeMounts[0].ReadOnly, "container 2 writable mount")
+
+				// remainder
+				assert.NotNil(t, podSpec.TerminationGracePeriodSeconds, "termination grace ptr")
+				assert.Equal(t, testutils.TestTerminationGrace, *podSpec.TerminationGracePeriodSeconds, "termination grace")
+				assert.True(t, podSpec.HostNetwork, "hostnetwork")
+
+				// rbac
+				dataplane, err := getDataplane(c)
+				assert.NoError(t, err, "dataplane found")
+				sa, role, binding, err := r.createConfigWatcherRBAC(c, dataplane)
+				assert.NoError(t, err, "create rbac")
+				assert.NotNil(t, sa, "service account")
+				assert.Equal(t, configWatcherName(gw), sa.GetName(), "service account name")
+				assert.Equal(t, gw.GetNamespace(), sa.GetNamespace(), "service account namespace")
+				assert.Len(t, sa.GetOwnerReferences(), 1, "service account owner ref")
+
+				assert.Equal(t, configWatcherName(gw), role.GetName(), "role name")
+				assert.Len(t, role.Rules, 1, "role rules")
+				assert.Equal(t, []string{"configmaps"}, role.Rules[0].Resources, "role resources")
+				assert.Equal(t, []string{"get", "list", "watch"}, role.Rules[0].Verbs, "role verbs")
+
+				assert.Equal(t, role.GetName(), binding.RoleRef.Name, "role ref")
+				assert.Len(t, binding.Subjects, 1, "subjects")
+				assert.Equal(t, sa.GetName(), binding.Subjects[0].Name, "subject name")
+				assert.Equal(t, sa.GetNamespace(), binding.Subjects[0].Namespace, "subject namespace")
+
+				// user-provided service account
+				dataplane.Spec.ServiceAccountName = "dummy-sa"
+				sa, _, binding, err = r.createConfigWatcherRBAC(c, dataplane)
+				assert.NoError(t, err, "create rbac")
+				assert.Nil(t, sa, "no service account")
+				assert.Equal(t, "dummy-sa", binding.Subjects[0].Name, "subject name")
+
+				// no live dataplane: nothing to remove
+				r.removeConfigWatcherRBAC(c, false)
+				assert.Len(t, c.update.DeleteQueue.ServiceAccounts.Objects(), 0, "no sa removed")
+				assert.Len(t, c.update.DeleteQueue.Roles.Objects(), 0, "no role removed")
+
+				// switching to a user-provided service account removes the service account
+				// only
+				store.Deployments.Upsert(deploy)
+				defer store.Deployments.Flush()
+				r.removeConfigWatcherRBAC(c, true)
+				assert.Len(t, c.update.DeleteQueue.ServiceAccounts.Objects(), 1, "sa removed")
+				assert.Len(t, c.update.DeleteQueue.Roles.Objects(), 0, "no role removed")
+				assert.Len(t, c.update.DeleteQueue.RoleBindings.Objects(), 0, "no binding removed")
+
+				// switching to another template removes all RBAC resources
+				c.update = event.NewEventUpdate(0)
+				r.removeConfigWatcherRBAC(c, false)
+				assert.Len(t, c.update.DeleteQueue.ServiceAccounts.Objects(), 1, "sa removed")
+				roles := c.update.DeleteQueue.Roles.Objects()
+				assert.Len(t, roles, 1, "role removed")
+				assert.Equal(t, configWatcherName(gw), roles[0].GetName(), "role name")
+				assert.Equal(t, gw.GetNamespace(), roles[0].GetNamespace(), "role namespace")
+				assert.Len(t, c.update.DeleteQueue.RoleBindings.Objects(), 1, "binding removed")
+			},
+		},
 	})
 }
@@ -0,0 +1,262 @@
+package renderer
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	stnrv1a1 "github.com/l7mp/stunner-gateway-operator/api/v1alpha1"
+	"github.com/l7mp/stunner-gateway-operator/internal/event"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
+)
+
+// dependencyIndex tracks the GatewayClasses and Gateways that must be re-rendered when an object
+// changes. Objects are identified by their namespaced name only: the controllers that share a
+// reconciler (e.g., for routes, Services and EndpointSlices) do not know the kind of the changed
+// object. Objects of different kinds with the same name may alias, which results only in some
+// superfluous re-rendering.
+type dependencyIndex struct {
+	// classes maps object keys to the names of the GatewayClasses that depend on them
+	classes map[types.NamespacedName]map[string]bool
+	// gateways maps object keys to the keys of the Gateways that depend on them
+	gateways map[types.NamespacedName]map[types.NamespacedName]bool
+	// gatewayClass maps Gateway keys to the name of the GatewayClass of the Gateway
+	gatewayClass map[types.NamespacedName]string
+	// global holds the keys of the objects that may affect any Gateway
+	global map[types.NamespacedName]bool
+}
+
+func newDependencyIndex() *dependencyIndex {
+	return &dependencyIndex{
+		classes:      map[types.NamespacedName]map[string]bool{},
+		gateways:     map[types.NamespacedName]map[types.NamespacedName]bool{},
+		gatewayClass: map[types.NamespacedName]string{},
+		global:       map[types.NamespacedName]bool{},
+	}
+}
+
+func (d *dependencyIndex) addClassDep(n types.NamespacedName, class string) {
+	if _, ok := d.classes[n]; !ok {
+		d.classes[n] = map[string]bool{}
+	}
+	d.classes[n][class] = true
+}
+
+func (d *dependencyIndex) addGatewayDep(n, gw types.NamespacedName) {
+	if _, ok := d.gateways[n]; !ok {
+		d.gateways[n] = map[types.NamespacedName]bool{}
+	}
+	d.gateways[n][gw] = true
+}
+
+// getGatewayConfigDeps returns the keys of the objects a GatewayConfig refers to.
+func getGatewayConfigDeps(gwConf *stnrv1a1.GatewayConfig) []types.NamespacedName {
+	ret := []types.NamespacedName{}
+
+	if gwConf.Spec.AuthRef != nil {
+		if n, err := getSecretNameFromRef(gwConf.Spec.AuthRef, gwConf.GetNamespace()); err == nil {
+			ret = append(ret, n)
+		}
+	}
+
+	dataplaneName := opdefault.DefaultDataplaneName
+	if gwConf.Spec.Dataplane != nil {
+		dataplaneName = *gwConf.Spec.Dataplane
+	}
+	ret = append(ret, types.NamespacedName{Name: dataplaneName})
+
+	return ret
+}
+
+// buildDependencyIndex indexes the dependencies of the objects in the global stores.
+func buildDependencyIndex() *dependencyIndex {
+	d := newDependencyIndex()
+
+	for _, gc := range store.GatewayClasses.GetAll() {
+		class := gc.GetName()
+		d.addClassDep(store.GetNamespacedName(gc), class)
+
+		ref := gc.Spec.ParametersRef
+		if ref == nil || ref.Namespace == nil {
+			continue
+		}
+
+		n := types.NamespacedName{Namespace: string(*ref.Namespace), Name: ref.Name}
+		d.addClassDep(n, class)
+		if gwConf := store.GatewayConfigs.GetObject(n); gwConf != nil {
+			for _, dep := range getGatewayConfigDeps(gwConf) {
+				d.addClassDep(dep, class)
+			}
+		}
+	}
+
+	for _, gw := range store.Gateways.GetAll() {
+		key := store.GetNamespacedName(gw)
+		d.gatewayClass[key] = string(gw.Spec.GatewayClassName)
+
+		// the Gateway itself and the dataplane resources named after the Gateway
+		d.addGatewayDep(key, key)
+
+		// TLS certificates
+		for _, l := range gw.Spec.Listeners {
+			if l.TLS == nil {
+				continue
+			}
+			for i := range l.TLS.CertificateRefs {
+				if n, err := getSecretNameFromRef(&l.TLS.CertificateRefs[i], gw.GetNamespace()); err == nil {
+					d.addGatewayDep(n, key)
+				}
+			}
+		}
+
+		// Gateway-level GatewayConfig override
+		if n, ok := getGatewayConfigOverrideName(gw); ok {
+			d.addGatewayDep(n, key)
+			if gwConf := store.GatewayConfigs.GetObject(n); gwConf != nil {
+				for _, dep := range getGatewayConfigDeps(gwConf) {
+					d.addGatewayDep(dep, key)
+				}
+			}
+		}
+	}
+
+	// LoadBalancer Services
+	for _, svc := range store.Services.GetAll() {
+		related, ok := svc.GetAnnotations()[opdefault.RelatedGatewayKey]
+		if !ok || !strings.Contains(related, "/") {
+			continue
+		}
+		d.addGatewayDep(store.GetNamespacedName(svc), store.GetNameFromKey(related))
+	}
+
+	// routes and their backends
+	for _, ro := range getAllRoutes() {
+		gws := []types.NamespacedName{}
+		for _, p := range getRouteParentRefs(ro) {
+			ns := ro.GetNamespace()
+			if p.Namespace != nil {
+				ns = string(*p.Namespace)
+			}
+			gws = append(gws, types.NamespacedName{Namespace: ns, Name: string(p.Name)})
+		}
+
+		deps := []types.NamespacedName{store.GetNamespacedName(ro)}
+		for _, refs := range getRouteBackendRefs(ro) {
+			for _, ref := range refs {
+				ns := ro.GetNamespace()
+				if ref.Namespace != nil {
+					ns = string(*ref.Namespace)
+				}
+				n := types.NamespacedName{Namespace: ns, Name: string(ref.Name)}
+				deps = append(deps, n)
+
+				for _, slice := range store.EndpointSlices.GetForService(n) {
+					deps = append(deps, store.GetNamespacedName(slice))
+				}
+			}
+		}
+
+		for _, dep := range deps {
+			for _, gw := range gws {
+				d.addGatewayDep(dep, gw)
+			}
+		}
+	}
+
+	// Nodes may provide the public address for any Gateway and ReferenceGrants may permit
+	// or forbid any cross-namespace reference
+	for _, node := range store.Nodes.GetAll() {
+		d.global[store.GetNamespacedName(node)] = true
+	}
+	for _, grant := range store.ReferenceGrants.GetAll() {
+		d.global[store.GetNamespacedName(grant)] = true
+	}
+
+	return d
+}
+
+// renderScope is the set of Gateways to render in a rendering round. A nil renderScope means a
+// full render.
+type renderScope struct {
+	// gateways are the Gateways to be rendered
+	gateways map[types.NamespacedName]bool
+	// classes are the GatewayClasses of the Gateways to be rendered
+	classes map[string]bool
+	// objects are the keys of the changed objects
+	objects map[types.NamespacedName]bool
+}
+
+// getRenderScope computes the Gateways affected by the changed objects of a render event.
+// Dependencies are looked up both in the previous and in the current index, so that an object that
+// has been removed or has changed its dependencies will re-render both the former and the new
+// dependents. Changes affecting an entire GatewayClass are rare and may affect other GatewayClasses
+// too (e.g., by resolving a config map collision), so these trigger a full render. Returns nil if
+// a full render is needed.
+func getRenderScope(e *event.EventRender, prev, cur *dependencyIndex) *renderScope {
+	if e == nil || e.IsFull() || prev == nil {
+		return nil
+	}
+
+	s := &renderScope{
+		gateways: map[types.NamespacedName]bool{},
+		classes:  map[string]bool{},
+		objects:  map[types.NamespacedName]bool{},
+	}
+
+	for _, n := range e.Objects {
+		s.objects[n] = true
+		for _, d := range []*dependencyIndex{prev, cur} {
+			if d.global[n] || len(d.classes[n]) > 0 {
+				return nil
+			}
+			for gw := range d.gateways[n] {
+				s.gateways[gw] = true
+			}
+		}
+	}
+
+	for gw := range s.gateways {
+		for _, d := range []*dependencyIndex{prev, cur} {
+			if class, ok := d.gatewayClass[gw]; ok {
+				s.classes[class] = true
+			}
+		}
+	}
+
+	return s
+}
+
+// hasClass returns true if any Gateway of a GatewayClass must be rendered.
+func (s *renderScope) hasClass(gc *gwapiv1b1.GatewayClass) bool {
+	return s == nil || s.classes[gc.GetName()]
+}
+
+// hasGateway returns true if a Gateway must be rendered.
+func (s *renderScope) hasGateway(gw types.NamespacedName) bool {
+	return s == nil || s.gateways[gw]
+}
+
+// isRouteInScope returns true if a route must be rendered in a rendering context. During an
+// incremental render the status of the routes that are not attached to any of the Gateways being
+// rendered is left intact, except for the routes that have changed.
+func (r *Renderer) isRouteInScope(c *RenderContext, ro client.Object) bool {
+	if r.scope == nil || r.scope.objects[store.GetNamespacedName(ro)] {
+		return true
+	}
+
+	for _, p := range getRouteParentRefs(ro) {
+		ns := ro.GetNamespace()
+		if p.Namespace != nil {
+			ns = string(*p.Namespace)
+		}
+		if c.gws.GetObject(types.NamespacedName{Namespace: ns, Name: string(p.Name)}) != nil {
+			return true
+		}
+	}
+
+	return false
+}
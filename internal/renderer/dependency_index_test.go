@@ -0,0 +1,163 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	stnrv1a1 "github.com/l7mp/stunner-gateway-operator/api/v1alpha1"
+	"github.com/l7mp/stunner-gateway-operator/internal/config"
+	"github.com/l7mp/stunner-gateway-operator/internal/event"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+	"github.com/l7mp/stunner-gateway-operator/internal/testutils"
+	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
+)
+
+// secondGateway adds another Gateway to the gateway-class with no routes attached
+func secondGateway(c *renderTestConfig) {
+	gw := testutils.TestGw.DeepCopy()
+	gw.SetName("gateway-2")
+	c.gws = append(c.gws, *gw)
+}
+
+func readUpdate(t *testing.T, ch chan event.Event) *event.EventUpdate {
+	e := <-ch
+	u, ok := e.(*event.EventUpdate)
+	assert.True(t, ok, "update event")
+	return u
+}
+
+func TestDependencyIndex(t *testing.T) {
+	gw1 := types.NamespacedName{Namespace: "testnamespace", Name: "gateway-1"}
+	gw2 := types.NamespacedName{Namespace: "testnamespace", Name: "gateway-2"}
+	route := types.NamespacedName{Namespace: "testnamespace", Name: "udproute-ok"}
+
+	renderTester(t, []renderTestConfig{
+		{
+			name: "render scope",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
+			svcs: []corev1.Service{testutils.TestSvc},
+			prep: secondGateway,
+			tester: func(t *testing.T, r *Renderer) {
+				d := buildDependencyIndex()
+
+				assert.Nil(t, getRenderScope(event.NewEventRender(), d, d), "full render")
+				assert.Nil(t, getRenderScope(event.NewEventRender(route), nil, d),
+					"no previous index")
+
+				s := getRenderScope(event.NewEventRender(route), d, d)
+				assert.NotNil(t, s, "incremental render")
+				assert.True(t, s.hasGateway(gw1), "route parent in scope")
+				assert.False(t, s.hasGateway(gw2), "other gateway not in scope")
+				assert.True(t, s.hasClass(&testutils.TestGwClass), "gateway-class in scope")
+
+				// the backend of the route
+				s = getRenderScope(event.NewEventRender(types.NamespacedName{
+					Namespace: "testnamespace", Name: "testservice-ok",
+				}), d, d)
+				assert.NotNil(t, s, "incremental render")
+				assert.True(t, s.hasGateway(gw1), "backend parent in scope")
+
+				// unrelated object
+				s = getRenderScope(event.NewEventRender(types.NamespacedName{
+					Namespace: "dummy", Name: "dummy",
+				}), d, d)
+				assert.NotNil(t, s, "incremental render")
+				assert.Len(t, s.gateways, 0, "no gateway in scope")
+				assert.False(t, s.hasClass(&testutils.TestGwClass), "gateway-class not in scope")
+
+				// class-level dependencies
+				assert.Nil(t, getRenderScope(event.NewEventRender(types.NamespacedName{
+					Namespace: "testnamespace", Name: "gatewayconfig-ok",
+				}), d, d), "gateway-config change renders all")
+
+				// the route is moved to the other gateway: both are rendered
+				ro := testutils.TestUDPRoute.DeepCopy()
+				ro.Spec.ParentRefs[0].Name = "gateway-2"
+				store.UDPRoutes.Upsert(ro)
+				s = getRenderScope(event.NewEventRender(route), d, buildDependencyIndex())
+				assert.NotNil(t, s, "incremental render")
+				assert.True(t, s.hasGateway(gw1), "old parent in scope")
+				assert.True(t, s.hasGateway(gw2), "new parent in scope")
+			},
+		},
+		{
+			name: "incremental render - managed mode",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
+			svcs: []corev1.Service{testutils.TestSvc},
+			dps:  []stnrv1a1.Dataplane{testutils.TestDataplane},
+			prep: secondGateway,
+			tester: func(t *testing.T, r *Renderer) {
+				config.DataplaneMode = config.DataplaneModeManaged
+
+				ch := make(chan event.Event, 10)
+				r.SetOperatorChannel(ch)
+
+				// full render
+				r.Render(event.NewEventRender())
+				assert.Len(t, ch, 1, "update event sent")
+				u := readUpdate(t, ch)
+				assert.True(t, u.Incremental, "incremental update")
+				assert.Len(t, u.UpsertQueue.ConfigMaps.Objects(), 2, "configmaps rendered")
+				assert.Len(t, u.UpsertQueue.UDPRoutes.Objects(), 1, "route status")
+
+				// route change: only the parent of the route is rendered
+				r.Render(event.NewEventRender(route))
+				assert.Len(t, ch, 1, "update event sent")
+				u = readUpdate(t, ch)
+				cms := u.UpsertQueue.ConfigMaps.Objects()
+				assert.Len(t, cms, 1, "configmap rendered")
+				assert.Equal(t, "gateway-1", cms[0].GetName(), "configmap name")
+				gws := u.UpsertQueue.Gateways.Objects()
+				assert.Len(t, gws, 1, "gateway status")
+				assert.Equal(t, "gateway-1", gws[0].GetName(), "gateway name")
+				assert.Len(t, u.UpsertQueue.UDPRoutes.Objects(), 1, "route status")
+				assert.Len(t, u.DeleteQueue.ConfigMaps.Objects(), 0, "no configmap deleted")
+
+				// a change in the other gateway does not touch the route
+				r.Render(event.NewEventRender(gw2))
+				assert.Len(t, ch, 1, "update event sent")
+				u = readUpdate(t, ch)
+				cms = u.UpsertQueue.ConfigMaps.Objects()
+				assert.Len(t, cms, 1, "configmap rendered")
+				assert.Equal(t, "gateway-2", cms[0].GetName(), "configmap name")
+				assert.Len(t, u.UpsertQueue.UDPRoutes.Objects(), 0, "no route status")
+
+				// the other gateway is deleted: its configmap is removed
+				store.Gateways.Remove(gw2)
+				r.Render(event.NewEventRender(gw2))
+				assert.Len(t, ch, 2, "update events sent")
+				u = readUpdate(t, ch)
+				assert.Len(t, u.UpsertQueue.ConfigMaps.Objects(), 0, "no configmap rendered")
+				u = readUpdate(t, ch)
+				assert.True(t, u.Incremental, "incremental update")
+				cms = u.DeleteQueue.ConfigMaps.Objects()
+				assert.Len(t, cms, 1, "configmap deleted")
+				assert.Equal(t, "gateway-2", cms[0].GetName(), "configmap name")
+				assert.Equal(t, "testnamespace", cms[0].GetNamespace(), "configmap namespace")
+
+				// global dependencies trigger a full render
+				store.Nodes.Upsert(testutils.TestNode.DeepCopy())
+				r.Render(event.NewEventRender(store.GetNamespacedName(&testutils.TestNode)))
+				assert.Len(t, ch, 1, "update event sent")
+				u = readUpdate(t, ch)
+				cms = u.UpsertQueue.ConfigMaps.Objects()
+				assert.Len(t, cms, 1, "configmap rendered")
+				assert.Equal(t, "gateway-1", cms[0].GetName(), "configmap name")
+
+				config.DataplaneMode = config.NewDataplaneMode(opdefault.DefaultDataplaneMode)
+			},
+		},
+	})
+}
@@ -1,38 +1,74 @@
 package renderer
 
 import (
-	// "fmt"
-
+	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/l7mp/stunner-gateway-operator/internal/store"
 )
 
-// find the list of endpoint IP addresses associated with a service
+// find the list of endpoint IP addresses associated with a service: the endpoints of all the
+// EndpointSlices of the service are merged
 func getEndpointAddrs(n types.NamespacedName, suppressNotReady bool) ([]string, error) {
 	ret := []string{}
 
-	ep := store.Endpoints.GetObject(n)
-	if ep == nil {
+	eps := store.EndpointSlices.GetForService(n)
+	if len(eps) == 0 {
 		return ret, NewNonCriticalError(EndpointNotFound)
 	}
 
-	// allow clients to reach not-ready addresses: they have already gone through ICE
-	// negotiation so they may have a better idea on endpoint-readiness than Kubernetes
-	for _, s := range ep.Subsets {
-		for _, a := range s.Addresses {
-			if a.IP != "" {
-				ret = append(ret, a.IP)
-			}
+	// an address may temporarily appear in multiple slices
+	seen := map[string]bool{}
+	for _, es := range eps {
+		if es.AddressType == discoveryv1.AddressTypeFQDN {
+			continue
 		}
-		if !suppressNotReady {
-			for _, a := range s.NotReadyAddresses {
-				if a.IP != "" {
-					ret = append(ret, a.IP)
-				}
+
+		for _, e := range es.Endpoints {
+			if !isEndpointEnabled(e, suppressNotReady) {
+				continue
+			}
+
+			// addresses are fungible: use the first one
+			if len(e.Addresses) == 0 || e.Addresses[0] == "" {
+				continue
+			}
+
+			addr := e.Addresses[0]
+			if !seen[addr] {
+				seen[addr] = true
+				ret = append(ret, addr)
 			}
 		}
 	}
 
 	return ret, nil
 }
+
+// isEndpointEnabled decides whether the IP address of an endpoint should be reachable via STUNner,
+// based on the conditions of the endpoint. Ready endpoints are always enabled. In addition,
+// unless suppressNotReady is set, clients are allowed to reach not-ready endpoints as well: they
+// have already gone through ICE negotiation so they may have a better idea on endpoint-readiness
+// than Kubernetes. This includes terminating endpoints that are still serving, so that live
+// allocations to a terminating media server are not cut. Terminating endpoints that are no longer
+// serving are never enabled.
+func isEndpointEnabled(e discoveryv1.Endpoint, suppressNotReady bool) bool {
+	// nil means unknown state, which should be interpreted as ready
+	ready := e.Conditions.Ready == nil || *e.Conditions.Ready
+	serving := ready
+	if e.Conditions.Serving != nil {
+		serving = *e.Conditions.Serving
+	}
+	terminating := e.Conditions.Terminating != nil && *e.Conditions.Terminating
+
+	switch {
+	case ready:
+		return true
+	case suppressNotReady:
+		return false
+	case terminating && !serving:
+		return false
+	default:
+		return true
+	}
+}
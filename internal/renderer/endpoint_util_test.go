@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/l7mp/stunner-gateway-operator/internal/store"
@@ -18,7 +19,7 @@ func TestRenderEndpointUtil(t *testing.T) {
 		{
 			name: "endpoint-ips ok",
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {},
 			tester: func(t *testing.T, r *Renderer) {
 				svcs := store.Services.GetAll()
@@ -43,7 +44,7 @@ func TestRenderEndpointUtil(t *testing.T) {
 		{
 			name: "ready endpoint-ips ok",
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {},
 			tester: func(t *testing.T, r *Renderer) {
 				svcs := store.Services.GetAll()
@@ -65,13 +66,13 @@ func TestRenderEndpointUtil(t *testing.T) {
 			},
 		},
 		{
-			name: "wrong endpoint object name gives empty addr list",
+			name: "wrong service-name label gives empty addr list",
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
-				e := testutils.TestEndpoint.DeepCopy()
-				e.SetName("dummy")
-				c.eps = []corev1.Endpoints{*e}
+				e := testutils.TestEndpointSlice.DeepCopy()
+				e.SetLabels(map[string]string{discoveryv1.LabelServiceName: "dummy"})
+				c.eps = []discoveryv1.EndpointSlice{*e}
 			},
 			tester: func(t *testing.T, r *Renderer) {
 				svcs := store.Services.GetAll()
@@ -93,11 +94,11 @@ func TestRenderEndpointUtil(t *testing.T) {
 		{
 			name: "wrong endpoint object namespace gives empty addr list",
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
-				e := testutils.TestEndpoint.DeepCopy()
+				e := testutils.TestEndpointSlice.DeepCopy()
 				e.SetNamespace("dummy")
-				c.eps = []corev1.Endpoints{*e}
+				c.eps = []discoveryv1.EndpointSlice{*e}
 			},
 			tester: func(t *testing.T, r *Renderer) {
 				svcs := store.Services.GetAll()
@@ -117,13 +118,13 @@ func TestRenderEndpointUtil(t *testing.T) {
 			},
 		},
 		{
-			name: "multiple endpoint objects ok",
+			name: "duplicate addresses in multiple endpointslices ok",
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
-				e := testutils.TestEndpoint.DeepCopy()
+				e := testutils.TestEndpointSlice.DeepCopy()
 				e.SetName("dummy")
-				c.eps = []corev1.Endpoints{testutils.TestEndpoint, *e}
+				c.eps = []discoveryv1.EndpointSlice{testutils.TestEndpointSlice, *e}
 			},
 			tester: func(t *testing.T, r *Renderer) {
 				svcs := store.Services.GetAll()
@@ -145,5 +146,98 @@ func TestRenderEndpointUtil(t *testing.T) {
 				assert.Contains(t, addrs, "1.2.3.7", "addr-4 ok")
 			},
 		},
+		{
+			name: "multiple endpointslices are merged",
+			svcs: []corev1.Service{testutils.TestSvc},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
+			prep: func(c *renderTestConfig) {
+				e1 := testutils.TestEndpointSlice.DeepCopy()
+				e1.SetName("testservice-ok-slice-1")
+				e1.Endpoints = []discoveryv1.Endpoint{{
+					Addresses: []string{"1.2.3.8"},
+				}}
+				e2 := testutils.TestEndpointSlice.DeepCopy()
+				e2.SetName("testservice-ok-slice-2")
+				e2.AddressType = discoveryv1.AddressTypeFQDN
+				e2.Endpoints = []discoveryv1.Endpoint{{
+					Addresses: []string{"example.com"},
+				}}
+				c.eps = []discoveryv1.EndpointSlice{testutils.TestEndpointSlice, *e1, *e2}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				n := types.NamespacedName{
+					Namespace: testutils.TestSvc.GetNamespace(),
+					Name:      testutils.TestSvc.GetName(),
+				}
+				addrs, err := getEndpointAddrs(n, true)
+
+				assert.Nil(t, err, "no error")
+				assert.Len(t, addrs, 4, "endpoint addrs len ok")
+				assert.Contains(t, addrs, "1.2.3.4", "addr-1 ok")
+				assert.Contains(t, addrs, "1.2.3.5", "addr-2 ok")
+				assert.Contains(t, addrs, "1.2.3.7", "addr-4 ok")
+				assert.Contains(t, addrs, "1.2.3.8", "addr-5 ok")
+			},
+		},
+		{
+			name: "terminating endpoints",
+			svcs: []corev1.Service{testutils.TestSvc},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
+			prep: func(c *renderTestConfig) {
+				e := testutils.TestEndpointSlice.DeepCopy()
+				e.Endpoints = []discoveryv1.Endpoint{{
+					// no conditions: ready
+					Addresses: []string{"1.2.3.4"},
+				}, {
+					// terminating but still serving
+					Addresses: []string{"1.2.3.5"},
+					Conditions: discoveryv1.EndpointConditions{
+						Ready:       &testutils.TestFalse,
+						Serving:     &testutils.TestTrue,
+						Terminating: &testutils.TestTrue,
+					},
+				}, {
+					// terminating and no longer serving
+					Addresses: []string{"1.2.3.6"},
+					Conditions: discoveryv1.EndpointConditions{
+						Ready:       &testutils.TestFalse,
+						Serving:     &testutils.TestFalse,
+						Terminating: &testutils.TestTrue,
+					},
+				}}
+				c.eps = []discoveryv1.EndpointSlice{*e}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				n := types.NamespacedName{
+					Namespace: testutils.TestSvc.GetNamespace(),
+					Name:      testutils.TestSvc.GetName(),
+				}
+				addrs, err := getEndpointAddrs(n, false)
+				assert.Nil(t, err, "no error")
+				assert.Equal(t, []string{"1.2.3.4", "1.2.3.5"}, addrs, "endpoint addrs ok")
+
+				addrs, err = getEndpointAddrs(n, true)
+				assert.Nil(t, err, "no error")
+				assert.Equal(t, []string{"1.2.3.4"}, addrs, "ready endpoint addrs ok")
+			},
+		},
+		{
+			name: "dual-stack endpoint-ips ok",
+			svcs: []corev1.Service{testutils.TestSvcDualStack},
+			eps: []discoveryv1.EndpointSlice{testutils.TestEndpointSlice,
+				testutils.TestEndpointSliceIPv6},
+			prep: func(c *renderTestConfig) {},
+			tester: func(t *testing.T, r *Renderer) {
+				n := store.GetNamespacedName(&testutils.TestSvcDualStack)
+				addrs, err := getEndpointAddrs(n, true)
+
+				assert.Nil(t, err, "no error")
+				assert.Len(t, addrs, 4, "endpoint addrs len ok")
+				assert.Contains(t, addrs, "1.2.3.4", "addr-1 ok")
+				assert.Contains(t, addrs, "1.2.3.5", "addr-2 ok")
+				assert.Contains(t, addrs, "1.2.3.7", "addr-4 ok")
+				assert.Contains(t, addrs, "fd00::4", "IPv6 addr ok")
+			},
+		},
 	})
 }
@@ -1,5 +1,7 @@
 package renderer
 
+import "errors"
+
 // ErrorType species the type of a non-critical rendering error
 type ErrorType int
 
@@ -14,6 +16,8 @@ const (
 	NoRuleFound
 	ExternalAuthCredentialsNotFound
 	InvalidAuthConfig
+	InvalidGatewayConfigRef
+	ConfigMapCollision
 	RenderingError
 	InternalError
 
@@ -28,8 +32,43 @@ const (
 	InvalidProtocol
 	PortUnavailable
 	PublicAddressNotFound
+	RefNotPermitted
 )
 
+var errorReasons = map[ErrorType]string{
+	NoError:                         "NoError",
+	InvalidAuthType:                 "InvalidAuthType",
+	InvalidUsernamePassword:         "InvalidUsernamePassword",
+	InvalidSharedSecret:             "InvalidSharedSecret",
+	InvalidDataplane:                "InvalidDataplane",
+	NoRuleFound:                     "NoRuleFound",
+	ExternalAuthCredentialsNotFound: "ExternalAuthCredentialsNotFound",
+	InvalidAuthConfig:               "InvalidAuthConfig",
+	InvalidGatewayConfigRef:         "InvalidGatewayConfigRef",
+	ConfigMapCollision:              "ConfigMapCollision",
+	RenderingError:                  "RenderingError",
+	InternalError:                   "InternalError",
+	InvalidBackendGroup:             "InvalidBackendGroup",
+	InvalidBackendKind:              "InvalidBackendKind",
+	BackendNotFound:                 "BackendNotFound",
+	ServiceNotFound:                 "ServiceNotFound",
+	ClusterIPNotFound:               "ClusterIPNotFound",
+	EndpointNotFound:                "EndpointNotFound",
+	InconsitentClusterType:          "InconsistentClusterType",
+	InvalidProtocol:                 "InvalidProtocol",
+	PortUnavailable:                 "PortUnavailable",
+	PublicAddressNotFound:           "PublicAddressNotFound",
+	RefNotPermitted:                 "RefNotPermitted",
+}
+
+// String returns the name of an error type, used as the reason of Kubernetes Events.
+func (t ErrorType) String() string {
+	if r, ok := errorReasons[t]; ok {
+		return r
+	}
+	return "Unknown"
+}
+
 type TypedError struct {
 	reason ErrorType
 }
@@ -56,6 +95,10 @@ func (e *CriticalError) Error() string {
 		return "missing shared-secret for longterm authentication"
 	case InvalidAuthConfig:
 		return "internal error: could not validate generated auth config"
+	case InvalidGatewayConfigRef:
+		return "missing or not permitted GatewayConfig override for Gateway"
+	case ConfigMapCollision:
+		return "target ConfigMap is already rendered by another GatewayClass"
 	case InvalidDataplane:
 		return "missing Dataplane resource for Gateway"
 	case NoRuleFound:
@@ -105,6 +148,8 @@ func (e *NonCriticalError) Error() string {
 		return "invalid protocol"
 	case PublicAddressNotFound:
 		return "no public address found for gateway"
+	case RefNotPermitted:
+		return "cross-namespace reference not permitted by any ReferenceGrant"
 	}
 	return "Unknown error"
 }
@@ -132,3 +177,17 @@ func IsNonCriticalError(e error, reason ErrorType) bool {
 	err, ok := e.(*NonCriticalError)
 	return err != nil && ok && err.reason == reason
 }
+
+// GetErrorReason returns the type of a rendering error, or InternalError for errors not created
+// by the renderer. Wrapped rendering errors are unwrapped.
+func GetErrorReason(e error) ErrorType {
+	var cErr *CriticalError
+	if errors.As(e, &cErr) {
+		return cErr.reason
+	}
+	var ncErr *NonCriticalError
+	if errors.As(e, &ncErr) {
+		return ncErr.reason
+	}
+	return InternalError
+}
@@ -0,0 +1,109 @@
+package renderer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+)
+
+// EventResyncPeriod is the period after which an Event is re-emitted for an error that persists
+// across renders. Kubernetes garbage-collects Events after an hour by default, this makes sure
+// the error remains visible on the object.
+var EventResyncPeriod = 30 * time.Minute
+
+// eventRecorder emits Kubernetes Events for rendering errors. Events are deduplicated: an Event
+// is emitted when an error first appears on an object, and then only after EventResyncPeriod as
+// long as the error persists. An error that disappears in a render and then reappears is
+// reported again.
+type eventRecorder struct {
+	recorder record.EventRecorder
+	// sent keeps track of the last time an Event was emitted
+	sent map[string]sentEvent
+	// seen keeps track of the errors seen during the current render
+	seen map[string]bool
+	lock sync.Mutex
+}
+
+// sentEvent is the last time an Event was emitted on an object.
+type sentEvent struct {
+	last   time.Time
+	object types.NamespacedName
+}
+
+func newEventRecorder(recorder record.EventRecorder) *eventRecorder {
+	return &eventRecorder{
+		recorder: recorder,
+		sent:     make(map[string]sentEvent),
+		seen:     make(map[string]bool),
+	}
+}
+
+// startRender marks the beginning of a render.
+func (e *eventRecorder) startRender() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.seen = make(map[string]bool)
+}
+
+// finishRender forgets the errors that have not reappeared during the last render. An incremental
+// render revisits only the objects in the render scope, so errors on objects outside the scope
+// are kept.
+func (e *eventRecorder) finishRender(scope *renderScope) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for k, s := range e.sent {
+		if e.seen[k] {
+			continue
+		}
+		if scope != nil && !scope.gateways[s.object] && !scope.objects[s.object] {
+			continue
+		}
+		delete(e.sent, k)
+	}
+}
+
+// warn emits a Warning Event on an object, unless the same Event has been emitted recently.
+func (e *eventRecorder) warn(obj client.Object, reason, message string) {
+	if e.recorder == nil || obj == nil {
+		return
+	}
+
+	key := fmt.Sprintf("%T/%s/%s/%s", obj, store.GetObjectKey(obj), reason, message)
+
+	e.lock.Lock()
+	e.seen[key] = true
+	last, ok := e.sent[key]
+	if ok && time.Since(last.last) < EventResyncPeriod {
+		e.lock.Unlock()
+		return
+	}
+	e.sent[key] = sentEvent{last: time.Now(), object: store.GetNamespacedName(obj)}
+	e.lock.Unlock()
+
+	e.recorder.Event(obj, corev1.EventTypeWarning, reason, message)
+}
+
+// recordError emits a deduplicated Warning Event on an object that is affected by a rendering
+// error. The reason of the Event is the type of the error and the message is the error message,
+// prefixed with the optional context given in format and args.
+func (r *Renderer) recordError(obj client.Object, err error, format string, args ...any) {
+	if err == nil {
+		return
+	}
+
+	message := err.Error()
+	if format != "" {
+		message = fmt.Sprintf(format, args...) + ": " + message
+	}
+
+	r.events.warn(obj, GetErrorReason(err).String(), message)
+}
@@ -0,0 +1,182 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	stnrv1a1 "github.com/l7mp/stunner-gateway-operator/api/v1alpha1"
+	"github.com/l7mp/stunner-gateway-operator/internal/config"
+	"github.com/l7mp/stunner-gateway-operator/internal/event"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+	"github.com/l7mp/stunner-gateway-operator/internal/testutils"
+	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
+)
+
+func drainEvents(ch chan string) []string {
+	ret := []string{}
+	for {
+		select {
+		case e := <-ch:
+			ret = append(ret, e)
+		default:
+			return ret
+		}
+	}
+}
+
+func TestRenderEvents(t *testing.T) {
+	renderTester(t, []renderTestConfig{
+		{
+			name: "deduplicated render error events",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
+			svcs: []corev1.Service{testutils.TestSvc},
+			prep: func(c *renderTestConfig) {
+				// update owner ref so that we accept the public IP
+				s := testutils.TestSvc.DeepCopy()
+				s.SetOwnerReferences([]metav1.OwnerReference{{
+					APIVersion: gwapiv1b1.GroupVersion.String(),
+					Kind:       "Gateway",
+					UID:        testutils.TestGw.GetUID(),
+					Name:       testutils.TestGw.GetName(),
+				}})
+				c.svcs = []corev1.Service{*s}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				config.DataplaneMode = config.DataplaneModeLegacy
+				defer func() {
+					config.DataplaneMode = config.NewDataplaneMode(opdefault.DefaultDataplaneMode)
+				}()
+
+				recorder := record.NewFakeRecorder(100)
+				r.events = newEventRecorder(recorder)
+				ch := make(chan event.Event, 10)
+				r.SetOperatorChannel(ch)
+
+				// the "invalid" listener of the test gateway has an invalid protocol and
+				// the backend of the route has no endpoints
+				r.Render(event.NewEventRender())
+				assert.Len(t, ch, 1, "update event sent")
+				<-ch
+
+				es := drainEvents(recorder.Events)
+				assert.Len(t, es, 2, "events emitted")
+				assert.Contains(t, es, `Warning InvalidProtocol listener "invalid": invalid protocol`,
+					"listener event")
+				assert.Contains(t, es, "Warning BackendNotFound backend not found", "route event")
+
+				// the error persists: no new event
+				r.Render(event.NewEventRender())
+				<-ch
+				assert.Len(t, drainEvents(recorder.Events), 0, "event deduplicated")
+
+				// fix the listener
+				gw := store.Gateways.GetObject(store.GetNamespacedName(&testutils.TestGw))
+				assert.NotNil(t, gw, "gateway found")
+				ls := gw.Spec.Listeners
+				gw.Spec.Listeners = []gwapiv1b1.Listener{ls[0], ls[2]}
+				r.Render(event.NewEventRender())
+				<-ch
+				assert.Len(t, drainEvents(recorder.Events), 0, "no error, no event")
+
+				// the error reappears: new event
+				gw.Spec.Listeners = ls
+				r.Render(event.NewEventRender())
+				<-ch
+				es = drainEvents(recorder.Events)
+				assert.Len(t, es, 1, "event emitted again")
+				assert.True(t, strings.HasPrefix(es[0], "Warning InvalidProtocol"), "event reason")
+			},
+		},
+		{
+			name: "render error events on invalid gateway-config",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			prep: func(c *renderTestConfig) {
+				w := testutils.TestGwConfig.DeepCopy()
+				w.Spec.Username = nil
+				c.cfs = []stnrv1a1.GatewayConfig{*w}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				config.DataplaneMode = config.DataplaneModeLegacy
+				defer func() {
+					config.DataplaneMode = config.NewDataplaneMode(opdefault.DefaultDataplaneMode)
+				}()
+
+				recorder := record.NewFakeRecorder(100)
+				r.events = newEventRecorder(recorder)
+				ch := make(chan event.Event, 10)
+				r.SetOperatorChannel(ch)
+
+				r.Render(event.NewEventRender())
+				<-ch
+
+				es := drainEvents(recorder.Events)
+				// one on the gateway-config and one on the gateway
+				assert.Len(t, es, 2, "events emitted")
+				for _, e := range es {
+					assert.True(t, strings.HasPrefix(e, "Warning InvalidUsernamePassword"),
+						"event reason")
+				}
+			},
+		},
+	})
+}
+
+func TestEventRecorderRenderScope(t *testing.T) {
+	recorder := record.NewFakeRecorder(100)
+	e := newEventRecorder(recorder)
+	gw1, gw2 := testutils.TestGw.DeepCopy(), testutils.TestGw.DeepCopy()
+	gw2.SetName("gateway-2")
+
+	e.startRender()
+	e.warn(gw1, "InvalidProtocol", "error")
+	e.warn(gw2, "InvalidProtocol", "error")
+	e.finishRender(nil)
+	assert.Len(t, drainEvents(recorder.Events), 2, "events emitted")
+
+	// incremental render of gw1 only: the error on gw2 is kept
+	scope := &renderScope{
+		gateways: map[types.NamespacedName]bool{store.GetNamespacedName(gw1): true},
+		classes:  map[string]bool{},
+		objects:  map[types.NamespacedName]bool{},
+	}
+	e.startRender()
+	e.warn(gw1, "InvalidProtocol", "error")
+	e.finishRender(scope)
+	e.startRender()
+	e.warn(gw2, "InvalidProtocol", "error")
+	e.finishRender(nil)
+	assert.Len(t, drainEvents(recorder.Events), 0, "out-of-scope event deduplicated")
+
+	// the error disappears from gw1 in an incremental render and then reappears
+	e.startRender()
+	e.finishRender(scope)
+	e.startRender()
+	e.warn(gw1, "InvalidProtocol", "error")
+	e.warn(gw2, "InvalidProtocol", "error")
+	e.finishRender(nil)
+	assert.Len(t, drainEvents(recorder.Events), 1, "in-scope event emitted again")
+
+	// the error disappears from gw2 in a full render
+	e.startRender()
+	e.warn(gw1, "InvalidProtocol", "error")
+	e.finishRender(nil)
+	e.startRender()
+	e.warn(gw2, "InvalidProtocol", "error")
+	e.finishRender(nil)
+	assert.Len(t, drainEvents(recorder.Events), 1, "event emitted again after full render")
+}
@@ -88,22 +88,19 @@ func initGatewayStatus(gw *gwapiv1b1.Gateway, cname string) {
 
 	// reinit listener statuses
 	gw.Status.Listeners = gw.Status.Listeners[:0]
-	group := gwapiv1b1.Group(gwapiv1b1.GroupVersion.Group)
 
 	for _, l := range gw.Spec.Listeners {
+		l := l
 		gw.Status.Listeners = append(gw.Status.Listeners,
 			gwapiv1b1.ListenerStatus{
-				Name: l.Name,
-				SupportedKinds: []gwapiv1b1.RouteGroupKind{{
-					Group: &group,
-					Kind:  gwapiv1b1.Kind("UDPRoute"),
-				}},
-				Conditions: []metav1.Condition{},
+				Name:           l.Name,
+				SupportedKinds: getSupportedKinds(&l),
+				Conditions:     []metav1.Condition{},
 			})
 	}
 }
 
-func setGatewayStatusProgrammed(gw *gwapiv1b1.Gateway, err error, ap *gatewayAddress) {
+func setGatewayStatusProgrammed(gw *gwapiv1b1.Gateway, err error, aps []gatewayAddress) {
 	if err != nil {
 		meta.SetStatusCondition(&gw.Status.Conditions, metav1.Condition{
 			Type:               string(gwapiv1b1.GatewayConditionProgrammed),
@@ -117,15 +114,18 @@ func setGatewayStatusProgrammed(gw *gwapiv1b1.Gateway, err error, ap *gatewayAdd
 		return
 	}
 
-	if ap != nil {
-		aType := ap.aType
-		if string(aType) == "" {
-			aType = gwapiv1b1.IPAddressType
+	if len(aps) > 0 {
+		gw.Status.Addresses = []gwapiv1b1.GatewayStatusAddress{}
+		for _, ap := range aps {
+			aType := ap.aType
+			if string(aType) == "" {
+				aType = gwapiv1b1.IPAddressType
+			}
+			gw.Status.Addresses = append(gw.Status.Addresses, gwapiv1b1.GatewayStatusAddress{
+				Type:  &aType,
+				Value: ap.addr,
+			})
 		}
-		gw.Status.Addresses = []gwapiv1b1.GatewayStatusAddress{{
-			Type:  &aType,
-			Value: ap.addr,
-		}}
 		meta.SetStatusCondition(&gw.Status.Conditions, metav1.Condition{
 			Type:               string(gwapiv1b1.GatewayConditionProgrammed),
 			Status:             metav1.ConditionTrue,
@@ -168,7 +168,7 @@ func setListenerStatus(gw *gwapiv1b1.Gateway, l *gwapiv1b1.Listener, err error,
 
 	setListenerStatusAccepted(gw, s, err)
 	setListenerStatusConflicted(gw, s, conflicted)
-	setListenerStatusResolvedRefs(gw, s)
+	setListenerStatusResolvedRefs(gw, s, checkCertRefsPermitted(gw, l))
 	// listener ready status deprecated
 	// setListenerStatusReady(gw, s, ready)
 	s.AttachedRoutes = int32(routes)
@@ -237,7 +237,19 @@ func setListenerStatusConflicted(gw *gwapiv1b1.Gateway, s *gwapiv1b1.ListenerSta
 	}
 }
 
-func setListenerStatusResolvedRefs(gw *gwapiv1b1.Gateway, s *gwapiv1b1.ListenerStatus) {
+func setListenerStatusResolvedRefs(gw *gwapiv1b1.Gateway, s *gwapiv1b1.ListenerStatus, reason error) {
+	if IsNonCriticalError(reason, RefNotPermitted) {
+		meta.SetStatusCondition(&s.Conditions, metav1.Condition{
+			Type:               string(gwapiv1b1.ListenerConditionResolvedRefs),
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: gw.Generation,
+			LastTransitionTime: metav1.Now(),
+			Reason:             string(gwapiv1b1.ListenerReasonRefNotPermitted),
+			Message:            "cross-namespace certificate reference not permitted by any ReferenceGrant",
+		})
+		return
+	}
+
 	meta.SetStatusCondition(&s.Conditions, metav1.Condition{
 		Type:               string(gwapiv1b1.ListenerConditionResolvedRefs),
 		Status:             metav1.ConditionTrue,
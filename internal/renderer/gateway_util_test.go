@@ -12,6 +12,8 @@ import (
 	meta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
 	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
@@ -205,7 +207,7 @@ func TestRenderGatewayUtil(t *testing.T) {
 					}
 
 					_, err := r.renderListener(gw, c.gwConf, &l,
-						[]*gwapiv1a2.UDPRoute{}, addr)
+						[]client.Object{}, addr)
 
 					if err != nil {
 						setListenerStatus(gw, &l, err, conflicted, 0)
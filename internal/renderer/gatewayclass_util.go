@@ -2,6 +2,7 @@ package renderer
 
 import (
 	"fmt"
+	"sort"
 
 	meta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -26,6 +27,15 @@ func (r *Renderer) getGatewayClasses() []*gwapiv1b1.GatewayClass {
 		ret = append(ret, gc)
 	}
 
+	// oldest first, so that conflicts are resolved in favor of the older gateway-class
+	sort.SliceStable(ret, func(i, j int) bool {
+		ti, tj := ret[i].GetCreationTimestamp(), ret[j].GetCreationTimestamp()
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		return store.GetObjectKey(ret[i]) < store.GetObjectKey(ret[j])
+	})
+
 	r.log.V(2).Info("getGatewayClasses", "found", fmt.Sprintf("%d gateway-classes", len(ret)))
 
 	return ret
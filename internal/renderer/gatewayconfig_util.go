@@ -2,11 +2,22 @@ package renderer
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
+	"k8s.io/apimachinery/pkg/api/equality"
+	meta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	stnrconfv1a1 "github.com/l7mp/stunner/pkg/apis/v1alpha1"
+
 	stnrv1a1 "github.com/l7mp/stunner-gateway-operator/api/v1alpha1"
+	"github.com/l7mp/stunner-gateway-operator/internal/config"
 	"github.com/l7mp/stunner-gateway-operator/internal/store"
+	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
 )
 
 func (r *Renderer) getGatewayConfig4Class(c *RenderContext) (*stnrv1a1.GatewayConfig, error) {
@@ -31,3 +42,262 @@ func (r *Renderer) getGatewayConfig4Class(c *RenderContext) (*stnrv1a1.GatewayCo
 
 	return gwConf, nil
 }
+
+// getGatewayClasses4Config returns the GatewayClasses that use a GatewayConfig
+func (r *Renderer) getGatewayClasses4Config(gwConf *stnrv1a1.GatewayConfig) []*gwapiv1b1.GatewayClass {
+	ret := []*gwapiv1b1.GatewayClass{}
+
+	for _, gc := range store.GatewayClasses.GetAll() {
+		if string(gc.Spec.ControllerName) != config.ControllerName {
+			continue
+		}
+
+		ref := gc.Spec.ParametersRef
+		if ref == nil || ref.Namespace == nil || ref.Kind != gwapiv1b1.Kind("GatewayConfig") {
+			continue
+		}
+
+		if string(*ref.Namespace) == gwConf.GetNamespace() && ref.Name == gwConf.GetName() {
+			ret = append(ret, gc)
+		}
+	}
+
+	return ret
+}
+
+// getGateways4Config returns the Gateways that use a GatewayConfig, either via their GatewayClass
+// or via a Gateway-level override
+func (r *Renderer) getGateways4Config(gwConf *stnrv1a1.GatewayConfig) []*gwapiv1b1.Gateway {
+	ret := []*gwapiv1b1.Gateway{}
+
+	classes := map[string]bool{}
+	for _, gc := range r.getGatewayClasses4Config(gwConf) {
+		classes[gc.GetName()] = true
+	}
+
+	for _, gw := range store.Gateways.GetAll() {
+		if classes[string(gw.Spec.GatewayClassName)] {
+			ret = append(ret, gw)
+			continue
+		}
+
+		if name, ok := getGatewayConfigOverrideName(gw); ok &&
+			name == store.GetNamespacedName(gwConf) {
+			ret = append(ret, gw)
+		}
+	}
+
+	return ret
+}
+
+// getGatewayConfigOverrideName returns the name of the GatewayConfig specified in the Gateway
+// annotations to override the GatewayConfig of the GatewayClass
+func getGatewayConfigOverrideName(gw *gwapiv1b1.Gateway) (types.NamespacedName, bool) {
+	ref, ok := gw.GetAnnotations()[opdefault.GatewayConfigAnnotationKey]
+	if !ok || ref == "" {
+		return types.NamespacedName{}, false
+	}
+
+	if strings.Contains(ref, "/") {
+		return store.GetNameFromKey(ref), true
+	}
+
+	return types.NamespacedName{Namespace: gw.GetNamespace(), Name: ref}, true
+}
+
+// getGatewayConfig4Gateway returns the GatewayConfig that overrides the GatewayConfig of the
+// GatewayClass for a Gateway, or nil if no override is specified
+func (r *Renderer) getGatewayConfig4Gateway(gw *gwapiv1b1.Gateway) (*stnrv1a1.GatewayConfig, error) {
+	name, ok := getGatewayConfigOverrideName(gw)
+	if !ok {
+		return nil, nil
+	}
+
+	to := objectRef{
+		group:     stnrv1a1.GroupVersion.Group,
+		kind:      "GatewayConfig",
+		namespace: name.Namespace,
+		name:      name.Name,
+	}
+	if !isRefPermitted(getGatewayRef(gw), to) {
+		r.log.Info("cross-namespace GatewayConfig override not permitted by any ReferenceGrant",
+			"gateway", store.GetObjectKey(gw), "gateway-config", name.String())
+		return nil, NewCriticalError(InvalidGatewayConfigRef)
+	}
+
+	gwConf := store.GatewayConfigs.GetObject(name)
+	if gwConf == nil {
+		r.log.Info("GatewayConfig override not found", "gateway", store.GetObjectKey(gw),
+			"gateway-config", name.String())
+		return nil, NewCriticalError(InvalidGatewayConfigRef)
+	}
+
+	r.log.V(4).Info("getGatewayConfig4Gateway", "gateway", store.GetObjectKey(gw), "result",
+		store.GetObjectKey(gwConf))
+
+	return gwConf, nil
+}
+
+// mergeGatewayConfig merges a Gateway-level GatewayConfig over the GatewayConfig of the
+// GatewayClass. The result keeps the identity of the class-level GatewayConfig. Every field set in
+// the override overrides the class-level setting, even if it is set to the default. Authentication
+// settings are merged as a whole: if the override specifies credentials or an auth Secret then
+// all authentication settings are taken from the override.
+func mergeGatewayConfig(base, override *stnrv1a1.GatewayConfig) *stnrv1a1.GatewayConfig {
+	ret := base.DeepCopy()
+	spec, o := &ret.Spec, override.DeepCopy().Spec
+
+	if o.Realm != nil && *o.Realm != stnrconfv1a1.DefaultRealm {
+		spec.Realm = o.Realm
+	}
+
+	if o.Dataplane != nil && *o.Dataplane != opdefault.DefaultDataplaneName {
+		spec.Dataplane = o.Dataplane
+	}
+
+	if o.AuthRef != nil || o.Username != nil || o.Password != nil || o.SharedSecret != nil {
+		spec.AuthType = o.AuthType
+		spec.Username = o.Username
+		spec.Password = o.Password
+		spec.SharedSecret = o.SharedSecret
+		spec.AuthLifetime = o.AuthLifetime
+		spec.AuthRef = o.AuthRef
+		spec.SharedSecretRotation = o.SharedSecretRotation
+
+		// the auth Secret must be looked up in the namespace of the override
+		if spec.AuthRef != nil && spec.AuthRef.Namespace == nil {
+			ns := gwapiv1b1.Namespace(override.GetNamespace())
+			spec.AuthRef.Namespace = &ns
+		}
+	}
+
+	if o.MetricsEndpoint != nil {
+		spec.MetricsEndpoint = o.MetricsEndpoint
+	}
+
+	if o.HealthCheckEndpoint != nil {
+		spec.HealthCheckEndpoint = o.HealthCheckEndpoint
+	}
+
+	if o.LogLevel != nil {
+		spec.LogLevel = o.LogLevel
+	}
+
+	if o.MinPort != nil {
+		spec.MinPort = o.MinPort
+	}
+
+	if o.MaxPort != nil {
+		spec.MaxPort = o.MaxPort
+	}
+
+	if len(o.LoadBalancerServiceAnnotations) > 0 {
+		spec.LoadBalancerServiceAnnotations =
+			mergeMaps(spec.LoadBalancerServiceAnnotations, o.LoadBalancerServiceAnnotations)
+	}
+
+	return ret
+}
+
+// validateGatewayConfig checks whether a valid dataplane config can be rendered from the
+// GatewayConfig of a render context: this makes it possible to report errors in the GatewayConfig
+// status even if there are no Gateways to render
+func (r *Renderer) validateGatewayConfig(c *RenderContext) error {
+	if _, err := r.renderAuth(c); err != nil {
+		return err
+	}
+
+	if config.DataplaneMode == config.DataplaneModeManaged {
+		if _, err := getDataplane(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateGatewayConfigStatus validates the GatewayConfig of a render context, sets the status of
+// gwConf accordingly and schedules gwConf for a status update. For a Gateway-level override
+// gwConf is the override itself while the render context holds the merged GatewayConfig.
+func (r *Renderer) updateGatewayConfigStatus(c *RenderContext, gwConf *stnrv1a1.GatewayConfig) {
+	if gwConf == nil {
+		return
+	}
+
+	gcs, gws := []string{}, []string{}
+	for _, gc := range r.getGatewayClasses4Config(gwConf) {
+		gcs = append(gcs, gc.GetName())
+	}
+	for _, gw := range r.getGateways4Config(gwConf) {
+		gws = append(gws, store.GetObjectKey(gw))
+	}
+	sort.Strings(gcs)
+	sort.Strings(gws)
+
+	status := gwConf.Status.DeepCopy()
+	gwConf.Status.GatewayClasses = gcs
+	gwConf.Status.Gateways = gws
+
+	err := r.validateGatewayConfig(c)
+	if err != nil {
+		r.log.Info("invalid gateway-config", "gateway-config", store.GetObjectKey(gwConf),
+			"error", err.Error())
+		r.recordError(gwConf, err, "")
+	}
+
+	setGatewayConfigStatusAccepted(gwConf, err)
+	setGatewayConfigStatusResolvedRefs(gwConf, err)
+
+	// bump the render generation whenever a new generation of the GatewayConfig is observed
+	// (this changes the ObservedGeneration of the conditions) or the status otherwise changes;
+	// bumping it on every render would make the updater rewrite an unchanged status each time
+	if gwConf.Status.RenderGeneration == 0 || !equality.Semantic.DeepEqual(status, &gwConf.Status) {
+		gwConf.Status.RenderGeneration = int64(r.gen)
+	}
+
+	c.update.UpsertQueue.GatewayConfigs.Upsert(gwConf)
+}
+
+func setGatewayConfigStatusAccepted(gwConf *stnrv1a1.GatewayConfig, err error) {
+	cond := metav1.Condition{
+		Type:               string(stnrv1a1.GatewayConfigConditionAccepted),
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: gwConf.Generation,
+		LastTransitionTime: metav1.Now(),
+		Reason:             string(stnrv1a1.GatewayConfigReasonAccepted),
+		Message:            "GatewayConfig is valid",
+	}
+
+	if IsCriticalError(err, InvalidAuthType) || IsCriticalError(err, InvalidUsernamePassword) ||
+		IsCriticalError(err, InvalidSharedSecret) || IsCriticalError(err, InvalidAuthConfig) {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = string(stnrv1a1.GatewayConfigReasonInvalid)
+		cond.Message = fmt.Sprintf("invalid GatewayConfig: %s", err.Error())
+	}
+
+	meta.SetStatusCondition(&gwConf.Status.Conditions, cond)
+}
+
+func setGatewayConfigStatusResolvedRefs(gwConf *stnrv1a1.GatewayConfig, err error) {
+	cond := metav1.Condition{
+		Type:               string(stnrv1a1.GatewayConfigConditionResolvedRefs),
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: gwConf.Generation,
+		LastTransitionTime: metav1.Now(),
+		Reason:             string(stnrv1a1.GatewayConfigReasonResolvedRefs),
+		Message:            "all references resolved",
+	}
+
+	switch {
+	case IsCriticalError(err, ExternalAuthCredentialsNotFound):
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = string(stnrv1a1.GatewayConfigReasonInvalidAuthRef)
+		cond.Message = err.Error()
+	case IsCriticalError(err, InvalidDataplane):
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = string(stnrv1a1.GatewayConfigReasonInvalidDataplaneRef)
+		cond.Message = err.Error()
+	}
+
+	meta.SetStatusCondition(&gwConf.Status.Conditions, cond)
+}
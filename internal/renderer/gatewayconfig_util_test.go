@@ -7,11 +7,18 @@ import (
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
+	stnrconfv1a1 "github.com/l7mp/stunner/pkg/apis/v1alpha1"
+
+	"github.com/l7mp/stunner-gateway-operator/internal/event"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
 	"github.com/l7mp/stunner-gateway-operator/internal/testutils"
+	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
 
 	stnrv1a1 "github.com/l7mp/stunner-gateway-operator/api/v1alpha1"
 )
@@ -97,5 +104,264 @@ func TestRenderGatewayConfigUtil(t *testing.T) {
 				assert.Error(t, err, "gw-conf found")
 			},
 		},
+		{
+			name: "gatewayconfig status ok",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{},
+			svcs: []corev1.Service{},
+			prep: func(c *renderTestConfig) {},
+			tester: func(t *testing.T, r *Renderer) {
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, log: logr.Discard()}
+				c.gwConf, err = r.getGatewayConfig4Class(c)
+				assert.NoError(t, err, "gw-conf found")
+				c.update = event.NewEventUpdate(0)
+
+				r.gen = 12
+				r.updateGatewayConfigStatus(c, c.gwConf)
+
+				gwConfs := c.update.UpsertQueue.GatewayConfigs.GetAll()
+				assert.Len(t, gwConfs, 1, "gw-conf scheduled for update")
+				gwConf := gwConfs[0]
+
+				assert.Equal(t, []string{"gatewayclass-ok"}, gwConf.Status.GatewayClasses,
+					"gateway-classes")
+				assert.Equal(t, []string{"testnamespace/gateway-1"}, gwConf.Status.Gateways,
+					"gateways")
+				assert.Equal(t, int64(12), gwConf.Status.RenderGeneration, "render generation")
+
+				// status unchanged: render generation is kept
+				r.gen = 13
+				r.updateGatewayConfigStatus(c, c.gwConf)
+				assert.Equal(t, int64(12), c.gwConf.Status.RenderGeneration,
+					"render generation unchanged")
+
+				// status changes: render generation bumped
+				r.gen = 14
+				c.gwConf.Status.Gateways = nil
+				r.updateGatewayConfigStatus(c, c.gwConf)
+				assert.Equal(t, int64(14), c.gwConf.Status.RenderGeneration,
+					"render generation bumped")
+
+				// new GatewayConfig generation observed: render generation bumped
+				r.gen = 15
+				c.gwConf.Generation += 1
+				r.updateGatewayConfigStatus(c, c.gwConf)
+				assert.Equal(t, int64(15), c.gwConf.Status.RenderGeneration,
+					"render generation bumped on new observed generation")
+				d := meta.FindStatusCondition(c.gwConf.Status.Conditions,
+					string(stnrv1a1.GatewayConfigConditionAccepted))
+				assert.NotNil(t, d, "accepted cond found")
+				assert.Equal(t, c.gwConf.Generation, d.ObservedGeneration, "observed generation")
+
+				// nothing changed: render generation kept
+				r.gen = 16
+				r.updateGatewayConfigStatus(c, c.gwConf)
+				assert.Equal(t, int64(15), c.gwConf.Status.RenderGeneration,
+					"render generation unchanged")
+
+				d = meta.FindStatusCondition(gwConf.Status.Conditions,
+					string(stnrv1a1.GatewayConfigConditionAccepted))
+				assert.NotNil(t, d, "accepted cond found")
+				assert.Equal(t, metav1.ConditionTrue, d.Status, "accepted status")
+				assert.Equal(t, string(stnrv1a1.GatewayConfigReasonAccepted), d.Reason,
+					"accepted reason")
+
+				d = meta.FindStatusCondition(gwConf.Status.Conditions,
+					string(stnrv1a1.GatewayConfigConditionResolvedRefs))
+				assert.NotNil(t, d, "resolved-refs cond found")
+				assert.Equal(t, metav1.ConditionTrue, d.Status, "resolved-refs status")
+				assert.Equal(t, string(stnrv1a1.GatewayConfigReasonResolvedRefs), d.Reason,
+					"resolved-refs reason")
+			},
+		},
+		{
+			name: "gatewayconfig status - invalid shared secret",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{},
+			rs:   []gwapiv1a2.UDPRoute{},
+			svcs: []corev1.Service{},
+			prep: func(c *renderTestConfig) {
+				w := c.cfs[0].DeepCopy()
+				atype := "longterm"
+				w.Spec.AuthType = &atype
+				w.Spec.SharedSecret = nil
+				c.cfs = []stnrv1a1.GatewayConfig{*w}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, log: logr.Discard()}
+				c.gwConf, err = r.getGatewayConfig4Class(c)
+				assert.NoError(t, err, "gw-conf found")
+				c.update = event.NewEventUpdate(0)
+
+				r.updateGatewayConfigStatus(c, c.gwConf)
+
+				gwConfs := c.update.UpsertQueue.GatewayConfigs.GetAll()
+				assert.Len(t, gwConfs, 1, "gw-conf scheduled for update")
+				gwConf := gwConfs[0]
+
+				assert.Equal(t, []string{"gatewayclass-ok"}, gwConf.Status.GatewayClasses,
+					"gateway-classes")
+				assert.Len(t, gwConf.Status.Gateways, 0, "gateways")
+
+				d := meta.FindStatusCondition(gwConf.Status.Conditions,
+					string(stnrv1a1.GatewayConfigConditionAccepted))
+				assert.NotNil(t, d, "accepted cond found")
+				assert.Equal(t, metav1.ConditionFalse, d.Status, "accepted status")
+				assert.Equal(t, string(stnrv1a1.GatewayConfigReasonInvalid), d.Reason,
+					"accepted reason")
+
+				d = meta.FindStatusCondition(gwConf.Status.Conditions,
+					string(stnrv1a1.GatewayConfigConditionResolvedRefs))
+				assert.NotNil(t, d, "resolved-refs cond found")
+				assert.Equal(t, metav1.ConditionTrue, d.Status, "resolved-refs status")
+			},
+		},
+		{
+			name: "gatewayconfig status - external auth secret not found",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{},
+			rs:   []gwapiv1a2.UDPRoute{},
+			svcs: []corev1.Service{},
+			prep: func(c *renderTestConfig) {
+				w := c.cfs[0].DeepCopy()
+				w.Spec.AuthRef = &gwapiv1b1.SecretObjectReference{
+					Name: gwapiv1b1.ObjectName("dummy-secret"),
+				}
+				c.cfs = []stnrv1a1.GatewayConfig{*w}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, log: logr.Discard()}
+				c.gwConf, err = r.getGatewayConfig4Class(c)
+				assert.NoError(t, err, "gw-conf found")
+				c.update = event.NewEventUpdate(0)
+
+				r.updateGatewayConfigStatus(c, c.gwConf)
+
+				gwConfs := c.update.UpsertQueue.GatewayConfigs.GetAll()
+				assert.Len(t, gwConfs, 1, "gw-conf scheduled for update")
+				gwConf := gwConfs[0]
+
+				d := meta.FindStatusCondition(gwConf.Status.Conditions,
+					string(stnrv1a1.GatewayConfigConditionAccepted))
+				assert.NotNil(t, d, "accepted cond found")
+				assert.Equal(t, metav1.ConditionTrue, d.Status, "accepted status")
+
+				d = meta.FindStatusCondition(gwConf.Status.Conditions,
+					string(stnrv1a1.GatewayConfigConditionResolvedRefs))
+				assert.NotNil(t, d, "resolved-refs cond found")
+				assert.Equal(t, metav1.ConditionFalse, d.Status, "resolved-refs status")
+				assert.Equal(t, string(stnrv1a1.GatewayConfigReasonInvalidAuthRef), d.Reason,
+					"resolved-refs reason")
+			},
+		},
+		{
+			name: "gatewayconfig override merge",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{},
+			svcs: []corev1.Service{},
+			prep: func(c *renderTestConfig) {
+				w := testutils.TestGwConfig.DeepCopy()
+				w.SetName("gatewayconfig-override")
+				w.SetNamespace("dummy")
+				realm := "override.example.com"
+				atype := stnrconfv1a1.DefaultAuthType
+				minPort := int32(20000)
+				w.Spec = stnrv1a1.GatewayConfigSpec{
+					Realm:    &realm,
+					AuthType: &atype,
+					AuthRef: &gwapiv1b1.SecretObjectReference{
+						Name: gwapiv1b1.ObjectName("override-secret"),
+					},
+					MinPort: &minPort,
+				}
+				c.cfs = append(c.cfs, *w)
+
+				gw := testutils.TestGw.DeepCopy()
+				gw.SetAnnotations(map[string]string{
+					opdefault.GatewayConfigAnnotationKey: "dummy/gatewayconfig-override",
+				})
+				c.gws = []gwapiv1b1.Gateway{*gw}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, log: logr.Discard()}
+				gwConf, err := r.getGatewayConfig4Class(c)
+				assert.NoError(t, err, "gw-conf found")
+
+				gws := r.getGateways4Class(c)
+				assert.Len(t, gws, 1, "gw found")
+				gw := gws[0]
+
+				// cross-namespace override without a ReferenceGrant
+				_, err = r.getGatewayConfig4Gateway(gw)
+				assert.True(t, IsCriticalError(err, InvalidGatewayConfigRef), "override rejected")
+
+				store.ReferenceGrants.Upsert(&gwapiv1b1.ReferenceGrant{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "dummy", Name: "gwconf-grant"},
+					Spec: gwapiv1b1.ReferenceGrantSpec{
+						From: []gwapiv1b1.ReferenceGrantFrom{{
+							Group:     gwapiv1b1.GroupName,
+							Kind:      "Gateway",
+							Namespace: testutils.TestNsName,
+						}},
+						To: []gwapiv1b1.ReferenceGrantTo{{
+							Group: gwapiv1b1.Group(stnrv1a1.GroupVersion.Group),
+							Kind:  "GatewayConfig",
+						}},
+					},
+				})
+
+				override, err := r.getGatewayConfig4Gateway(gw)
+				assert.NoError(t, err, "override found")
+				assert.NotNil(t, override, "override found")
+
+				merged := mergeGatewayConfig(gwConf, override)
+				assert.Equal(t, store.GetObjectKey(gwConf), store.GetObjectKey(merged),
+					"identity")
+				assert.Equal(t, "override.example.com", *merged.Spec.Realm, "realm")
+				assert.Equal(t, stnrconfv1a1.DefaultAuthType, *merged.Spec.AuthType, "auth type")
+				assert.Nil(t, merged.Spec.Username, "username")
+				assert.Nil(t, merged.Spec.Password, "password")
+				assert.NotNil(t, merged.Spec.AuthRef, "auth ref")
+				assert.Equal(t, "override-secret", string(merged.Spec.AuthRef.Name),
+					"auth ref name")
+				assert.Equal(t, "dummy", string(*merged.Spec.AuthRef.Namespace),
+					"auth ref namespace")
+				assert.Equal(t, int32(20000), *merged.Spec.MinPort, "min port")
+				assert.Equal(t, testutils.TestMaxPort, *merged.Spec.MaxPort, "max port")
+				assert.Equal(t, testutils.TestLogLevel, *merged.Spec.LogLevel, "loglevel")
+
+				// class-level config untouched
+				assert.Equal(t, testutils.TestUsername, *gwConf.Spec.Username, "username")
+				assert.Nil(t, gwConf.Spec.AuthRef, "auth ref")
+
+				// unset fields do not override
+				override = override.DeepCopy()
+				override.Spec.Realm = nil
+				merged = mergeGatewayConfig(gwConf, override)
+				assert.Equal(t, testutils.TestRealm, *merged.Spec.Realm, "realm")
+
+				// fields defaulted by the CRD do not override
+				realm := stnrconfv1a1.DefaultRealm
+				dataplane := opdefault.DefaultDataplaneName
+				override.Spec.Realm, override.Spec.Dataplane = &realm, &dataplane
+				merged = mergeGatewayConfig(gwConf, override)
+				assert.Equal(t, testutils.TestRealm, *merged.Spec.Realm, "realm")
+				assert.Equal(t, gwConf.Spec.Dataplane, merged.Spec.Dataplane, "dataplane")
+			},
+		},
 	})
 }
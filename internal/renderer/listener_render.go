@@ -5,8 +5,8 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	stnrconfv1a1 "github.com/l7mp/stunner/pkg/apis/v1alpha1"
@@ -19,7 +19,7 @@ func stnrListenerName(gw *gwapiv1b1.Gateway, l *gwapiv1b1.Listener) string {
 	return fmt.Sprintf("%s/%s", store.GetObjectKey(gw), string(l.Name))
 }
 
-func (r *Renderer) renderListener(gw *gwapiv1b1.Gateway, gwConf *stnrv1a1.GatewayConfig, l *gwapiv1b1.Listener, rs []*gwapiv1a2.UDPRoute, ap *gatewayAddress) (*stnrconfv1a1.ListenerConfig, error) {
+func (r *Renderer) renderListener(gw *gwapiv1b1.Gateway, gwConf *stnrv1a1.GatewayConfig, l *gwapiv1b1.Listener, rs []client.Object, ap *gatewayAddress) (*stnrconfv1a1.ListenerConfig, error) {
 	r.log.V(4).Info("renderListener", "gateway", store.GetObjectKey(gw), "gateway-config",
 		store.GetObjectKey(gwConf), "listener", l.Name, "route number", len(rs), "public-addr", ap.String())
 
@@ -109,6 +109,13 @@ func (r *Renderer) getTLS(gw *gwapiv1b1.Gateway, l *gwapiv1b1.Listener) (string,
 			continue
 		}
 
+		if !isRefPermitted(getGatewayRef(gw), getSecretRef(n)) {
+			r.log.Info("cross-namespace secret-reference not permitted by any ReferenceGrant",
+				"gateway", store.GetObjectKey(gw), "listener", l.Name,
+				"secret", n.String())
+			continue
+		}
+
 		secret := store.Secrets.GetObject(n)
 		if secret == nil {
 			r.log.Info("secret not found", "gateway", store.GetObjectKey(gw),
@@ -8,15 +8,20 @@ import (
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
-	// metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	meta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	// "k8s.io/apimachinery/pkg/types"
 	// "sigs.k8s.io/controller-runtime/pkg/log/zap"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
 	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	stnrconfv1a1 "github.com/l7mp/stunner/pkg/apis/v1alpha1"
 
+	"github.com/l7mp/stunner-gateway-operator/internal/config"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
 	"github.com/l7mp/stunner-gateway-operator/internal/testutils"
 
 	stnrv1a1 "github.com/l7mp/stunner-gateway-operator/api/v1alpha1"
@@ -46,7 +51,7 @@ func TestRenderListenerRender(t *testing.T) {
 				ls := gw.Spec.Listeners
 				l := ls[0]
 
-				rs := r.getUDPRoutes4Listener(gw, &l)
+				rs := r.getRoutes4Listener(gw, &l)
 				assert.Len(t, rs, 1, "route found")
 
 				addr := &gatewayAddress{
@@ -90,7 +95,7 @@ func TestRenderListenerRender(t *testing.T) {
 					addr: "1.2.3.4",
 					port: 1234,
 				}
-				_, err = r.renderListener(gw, c.gwConf, &l, []*gwapiv1a2.UDPRoute{}, addr)
+				_, err = r.renderListener(gw, c.gwConf, &l, []client.Object{}, addr)
 				assert.Error(t, err, "render fails")
 			},
 		},
@@ -121,7 +126,7 @@ func TestRenderListenerRender(t *testing.T) {
 					port: 4321,
 				}
 
-				lc, err := r.renderListener(gw, c.gwConf, &l, []*gwapiv1a2.UDPRoute{}, addr)
+				lc, err := r.renderListener(gw, c.gwConf, &l, []client.Object{}, addr)
 				assert.NoError(t, err, "renderListener")
 				assert.Equal(t, "testnamespace/gateway-1/gateway-1-listener-tcp", lc.Name, "name")
 				assert.Equal(t, "TURN-TCP", lc.Protocol, "proto")
@@ -158,7 +163,7 @@ func TestRenderListenerRender(t *testing.T) {
 				ls := gw.Spec.Listeners
 				l := ls[0]
 
-				rs := r.getUDPRoutes4Listener(gw, &l)
+				rs := r.getRoutes4Listener(gw, &l)
 				assert.Len(t, rs, 1, "route found")
 
 				addr := &gatewayAddress{
@@ -204,7 +209,7 @@ func TestRenderListenerRender(t *testing.T) {
 				ls := gw.Spec.Listeners
 				l := ls[0]
 
-				rs := r.getUDPRoutes4Listener(gw, &l)
+				rs := r.getRoutes4Listener(gw, &l)
 				assert.Len(t, rs, 1, "route found")
 
 				addr := &gatewayAddress{
@@ -266,7 +271,7 @@ func TestRenderListenerRender(t *testing.T) {
 				ls := gw.Spec.Listeners
 				l := ls[0]
 
-				rs := []*gwapiv1a2.UDPRoute{}
+				rs := []client.Object{}
 				addr := &gatewayAddress{
 					addr: "1.2.3.4",
 					port: 1234,
@@ -302,6 +307,94 @@ func TestRenderListenerRender(t *testing.T) {
 				assert.Equal(t, testutils.TestKey64, lc.Key, "key")
 			},
 		},
+		{
+			name:  "TLS listener - cross-namespace secret",
+			cls:   []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:   []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:   []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:    []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
+			svcs:  []corev1.Service{testutils.TestSvc},
+			scrts: []corev1.Secret{testutils.TestSecret},
+			prep: func(c *renderTestConfig) {
+				gw := testutils.TestGw.DeepCopy()
+				mode := gwapiv1b1.TLSModeTerminate
+				ns := gwapiv1b1.Namespace("dummy")
+				tls := gwapiv1b1.GatewayTLSConfig{
+					Mode: &mode,
+					CertificateRefs: []gwapiv1b1.SecretObjectReference{{
+						Namespace: &ns,
+						Name:      gwapiv1b1.ObjectName("testsecret-ok"),
+					}},
+				}
+				gw.Spec.Listeners = []gwapiv1b1.Listener{{
+					Name:     gwapiv1b1.SectionName("gateway-1-listener-tls"),
+					Protocol: gwapiv1b1.ProtocolType("TURN-TLS"),
+					Port:     gwapiv1b1.PortNumber(2),
+					TLS:      &tls,
+				}}
+				c.gws = []gwapiv1b1.Gateway{*gw}
+
+				s := testutils.TestSecret.DeepCopy()
+				s.SetNamespace("dummy")
+				c.scrts = []corev1.Secret{*s}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, log: logr.Discard()}
+				c.gwConf, err = r.getGatewayConfig4Class(c)
+				assert.NoError(t, err, "gw-conf found")
+
+				gws := r.getGateways4Class(c)
+				assert.Len(t, gws, 1, "gw found")
+				gw := gws[0]
+				l := gw.Spec.Listeners[0]
+				addr := &gatewayAddress{addr: "1.2.3.4", port: 1234}
+
+				// no ReferenceGrant
+				lc, err := r.renderListener(gw, c.gwConf, &l, []client.Object{}, addr)
+				assert.NoError(t, err, "renderListener")
+				assert.Equal(t, "", lc.Cert, "cert")
+				assert.Equal(t, "", lc.Key, "key")
+
+				initGatewayStatus(gw, config.ControllerName)
+				setListenerStatus(gw, &l, nil, false, 0)
+				d := meta.FindStatusCondition(gw.Status.Listeners[0].Conditions,
+					string(gwapiv1b1.ListenerConditionResolvedRefs))
+				assert.NotNil(t, d, "resolved-refs cond found")
+				assert.Equal(t, metav1.ConditionFalse, d.Status, "resolved-refs status")
+				assert.Equal(t, string(gwapiv1b1.ListenerReasonRefNotPermitted), d.Reason,
+					"resolved-refs reason")
+
+				// permit Gateways in the test namespace to refer to Secrets
+				store.ReferenceGrants.Upsert(&gwapiv1b1.ReferenceGrant{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "dummy", Name: "secret-grant"},
+					Spec: gwapiv1b1.ReferenceGrantSpec{
+						From: []gwapiv1b1.ReferenceGrantFrom{{
+							Group:     gwapiv1b1.GroupName,
+							Kind:      "Gateway",
+							Namespace: testutils.TestNsName,
+						}},
+						To: []gwapiv1b1.ReferenceGrantTo{{
+							Group: corev1.GroupName,
+							Kind:  "Secret",
+						}},
+					},
+				})
+
+				lc, err = r.renderListener(gw, c.gwConf, &l, []client.Object{}, addr)
+				assert.NoError(t, err, "renderListener")
+				assert.Equal(t, testutils.TestCert64, lc.Cert, "cert")
+				assert.Equal(t, testutils.TestKey64, lc.Key, "key")
+
+				initGatewayStatus(gw, config.ControllerName)
+				setListenerStatus(gw, &l, nil, false, 0)
+				d = meta.FindStatusCondition(gw.Status.Listeners[0].Conditions,
+					string(gwapiv1b1.ListenerConditionResolvedRefs))
+				assert.NotNil(t, d, "resolved-refs cond found")
+				assert.Equal(t, metav1.ConditionTrue, d.Status, "resolved-refs status")
+			},
+		},
 		{
 			name:  "TLS/DTLS listener - wrong secret type ok",
 			cls:   []gwapiv1b1.GatewayClass{testutils.TestGwClass},
@@ -347,7 +440,7 @@ func TestRenderListenerRender(t *testing.T) {
 				ls := gw.Spec.Listeners
 				l := ls[0]
 
-				rs := []*gwapiv1a2.UDPRoute{}
+				rs := []client.Object{}
 				addr := &gatewayAddress{
 					addr: "1.2.3.4",
 					port: 1234,
@@ -400,7 +493,7 @@ func TestRenderListenerRender(t *testing.T) {
 				ls := gw.Spec.Listeners
 				l := ls[0]
 
-				rs := []*gwapiv1a2.UDPRoute{}
+				rs := []client.Object{}
 				addr := &gatewayAddress{
 					addr: "1.2.3.4",
 					port: 1234,
@@ -455,7 +548,7 @@ func TestRenderListenerRender(t *testing.T) {
 				ls := gw.Spec.Listeners
 				l := ls[0]
 
-				rs := []*gwapiv1a2.UDPRoute{}
+				rs := []client.Object{}
 				addr := &gatewayAddress{
 					addr: "1.2.3.4",
 					port: 1234,
@@ -510,7 +603,7 @@ func TestRenderListenerRender(t *testing.T) {
 				ls := gw.Spec.Listeners
 				l := ls[0]
 
-				rs := []*gwapiv1a2.UDPRoute{}
+				rs := []client.Object{}
 				addr := &gatewayAddress{
 					addr: "1.2.3.4",
 					port: 1234,
@@ -569,7 +662,7 @@ func TestRenderListenerRender(t *testing.T) {
 				ls := gw.Spec.Listeners
 				l := ls[0]
 
-				rs := []*gwapiv1a2.UDPRoute{}
+				rs := []client.Object{}
 				addr := &gatewayAddress{
 					addr: "1.2.3.4",
 					port: 1234,
@@ -628,7 +721,7 @@ func TestRenderListenerRender(t *testing.T) {
 				ls := gw.Spec.Listeners
 				l := ls[0]
 
-				rs := []*gwapiv1a2.UDPRoute{}
+				rs := []client.Object{}
 				addr := &gatewayAddress{
 					addr: "1.2.3.4",
 					port: 1234,
@@ -687,7 +780,7 @@ func TestRenderListenerRender(t *testing.T) {
 				ls := gw.Spec.Listeners
 				l := ls[0]
 
-				rs := []*gwapiv1a2.UDPRoute{}
+				rs := []client.Object{}
 				addr := &gatewayAddress{
 					addr: "1.2.3.4",
 					port: 1234,
@@ -746,7 +839,7 @@ func TestRenderListenerRender(t *testing.T) {
 				ls := gw.Spec.Listeners
 				l := ls[0]
 
-				rs := []*gwapiv1a2.UDPRoute{}
+				rs := []client.Object{}
 				addr := &gatewayAddress{
 					addr: "1.2.3.4",
 					port: 1234,
@@ -808,7 +901,7 @@ func TestRenderListenerRender(t *testing.T) {
 				ls := gw.Spec.Listeners
 				l := ls[0]
 
-				rs := []*gwapiv1a2.UDPRoute{}
+				rs := []client.Object{}
 				addr := &gatewayAddress{
 					addr: "1.2.3.4",
 					port: 1234,
@@ -872,7 +965,7 @@ func TestRenderListenerRender(t *testing.T) {
 				ls := gw.Spec.Listeners
 				l := ls[0]
 
-				rs := []*gwapiv1a2.UDPRoute{}
+				rs := []client.Object{}
 				addr := &gatewayAddress{
 					addr: "1.2.3.4",
 					port: 1234,
@@ -29,9 +29,8 @@ func TestRenderNodeUtil(t *testing.T) {
 			nodes: []corev1.Node{testutils.TestNode},
 			prep:  func(c *renderTestConfig) {},
 			tester: func(t *testing.T, r *Renderer) {
-				addr := getFirstNodeAddr()
-				assert.NotEmpty(t, addr, "public node-addr found")
-				assert.Equal(t, "1.2.3.4", addr, "public addr ok")
+				addrs := getFirstNodeAddrs()
+				assert.Equal(t, []string{"1.2.3.4"}, addrs, "public addr ok")
 			},
 		},
 		{
@@ -50,9 +49,8 @@ func TestRenderNodeUtil(t *testing.T) {
 				c.nodes = []corev1.Node{*n1, *n2}
 			},
 			tester: func(t *testing.T, r *Renderer) {
-				addr := getFirstNodeAddr()
-				assert.NotEmpty(t, addr, "public node-addr found")
-				assert.Equal(t, "1.2.3.4", addr, "public addr ok")
+				addrs := getFirstNodeAddrs()
+				assert.Equal(t, []string{"1.2.3.4"}, addrs, "public addr ok")
 			},
 		},
 		{
@@ -65,8 +63,8 @@ func TestRenderNodeUtil(t *testing.T) {
 				c.nodes = []corev1.Node{*n1}
 			},
 			tester: func(t *testing.T, r *Renderer) {
-				addr := getFirstNodeAddr()
-				assert.Empty(t, addr, "public node-addr empty")
+				addrs := getFirstNodeAddrs()
+				assert.Empty(t, addrs, "public node-addr empty")
 			},
 		},
 	})
@@ -0,0 +1,53 @@
+package renderer
+
+import (
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+)
+
+// objectRef identifies the source or the target of a reference
+type objectRef struct {
+	group, kind, namespace, name string
+}
+
+// isRefPermitted checks whether a reference is permitted: references within the same namespace are
+// always allowed, cross-namespace references must be explicitly permitted by a ReferenceGrant in
+// the namespace of the target object
+func isRefPermitted(from, to objectRef) bool {
+	if from.namespace == to.namespace {
+		return true
+	}
+
+	for _, grant := range store.ReferenceGrants.GetAll() {
+		if grant.GetNamespace() != to.namespace {
+			continue
+		}
+
+		if grantAllowsFrom(grant, from) && grantAllowsTo(grant, to) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func grantAllowsFrom(grant *gwapiv1b1.ReferenceGrant, from objectRef) bool {
+	for _, f := range grant.Spec.From {
+		if string(f.Group) == from.group && string(f.Kind) == from.kind &&
+			string(f.Namespace) == from.namespace {
+			return true
+		}
+	}
+	return false
+}
+
+func grantAllowsTo(grant *gwapiv1b1.ReferenceGrant, to objectRef) bool {
+	for _, t := range grant.Spec.To {
+		if string(t.Group) == to.group && string(t.Kind) == to.kind &&
+			(t.Name == nil || string(*t.Name) == to.name) {
+			return true
+		}
+	}
+	return false
+}
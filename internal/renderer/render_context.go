@@ -23,9 +23,13 @@ type RenderContext struct {
 }
 
 func NewRenderContext(e *event.EventRender, r *Renderer, gc *gwapiv1b1.GatewayClass) *RenderContext {
+	// each update holds only the objects rendered in the context
+	update := event.NewEventUpdate(r.gen)
+	update.Incremental = true
+
 	return &RenderContext{
 		origin: e,
-		update: event.NewEventUpdate(r.gen),
+		update: update,
 		gc:     gc,
 		gws:    store.NewGatewayStore(),
 		log:    r.log.WithValues("gateway-class", gc.GetName()),
@@ -45,19 +49,37 @@ func (r *RenderContext) Merge(mergeable *RenderContext) {
 	upsertQueue1 := &r.update.UpsertQueue
 	upsertQueue2 := mergeable.update.UpsertQueue
 	store.Merge(upsertQueue1.GatewayClasses, upsertQueue2.GatewayClasses)
+	store.Merge(upsertQueue1.GatewayConfigs, upsertQueue2.GatewayConfigs)
 	store.Merge(upsertQueue1.Gateways, upsertQueue2.Gateways)
 	store.Merge(upsertQueue1.UDPRoutes, upsertQueue2.UDPRoutes)
+	store.Merge(upsertQueue1.TCPRoutes, upsertQueue2.TCPRoutes)
 	store.Merge(upsertQueue1.Services, upsertQueue2.Services)
 	store.Merge(upsertQueue1.ConfigMaps, upsertQueue2.ConfigMaps)
+	store.Merge(upsertQueue1.Secrets, upsertQueue2.Secrets)
 	store.Merge(upsertQueue1.Deployments, upsertQueue2.Deployments)
+	store.Merge(upsertQueue1.DaemonSets, upsertQueue2.DaemonSets)
+	store.Merge(upsertQueue1.HPAs, upsertQueue2.HPAs)
+	store.Merge(upsertQueue1.PDBs, upsertQueue2.PDBs)
+	store.Merge(upsertQueue1.ServiceAccounts, upsertQueue2.ServiceAccounts)
+	store.Merge(upsertQueue1.Roles, upsertQueue2.Roles)
+	store.Merge(upsertQueue1.RoleBindings, upsertQueue2.RoleBindings)
 
 	// merge delete queues
 	deleteQueue1 := &r.update.DeleteQueue
 	deleteQueue2 := mergeable.update.DeleteQueue
 	store.Merge(deleteQueue1.GatewayClasses, deleteQueue2.GatewayClasses)
+	store.Merge(deleteQueue1.GatewayConfigs, deleteQueue2.GatewayConfigs)
 	store.Merge(deleteQueue1.Gateways, deleteQueue2.Gateways)
 	store.Merge(deleteQueue1.UDPRoutes, deleteQueue2.UDPRoutes)
+	store.Merge(deleteQueue1.TCPRoutes, deleteQueue2.TCPRoutes)
 	store.Merge(deleteQueue1.Services, deleteQueue2.Services)
 	store.Merge(deleteQueue1.ConfigMaps, deleteQueue2.ConfigMaps)
+	store.Merge(deleteQueue1.Secrets, deleteQueue2.Secrets)
 	store.Merge(deleteQueue1.Deployments, deleteQueue2.Deployments)
+	store.Merge(deleteQueue1.DaemonSets, deleteQueue2.DaemonSets)
+	store.Merge(deleteQueue1.HPAs, deleteQueue2.HPAs)
+	store.Merge(deleteQueue1.PDBs, deleteQueue2.PDBs)
+	store.Merge(deleteQueue1.ServiceAccounts, deleteQueue2.ServiceAccounts)
+	store.Merge(deleteQueue1.Roles, deleteQueue2.Roles)
+	store.Merge(deleteQueue1.RoleBindings, deleteQueue2.RoleBindings)
 }
@@ -4,13 +4,20 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	stnrconfv1a1 "github.com/l7mp/stunner/pkg/apis/v1alpha1"
 
+	stnrv1a1 "github.com/l7mp/stunner-gateway-operator/api/v1alpha1"
+
 	"github.com/l7mp/stunner-gateway-operator/internal/config"
 	"github.com/l7mp/stunner-gateway-operator/internal/event"
+	"github.com/l7mp/stunner-gateway-operator/internal/metrics"
 	"github.com/l7mp/stunner-gateway-operator/internal/store"
 	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
 )
@@ -20,6 +27,28 @@ func (r *Renderer) Render(e *event.EventRender) {
 	r.gen += 1
 	r.log.Info("rendering configuration", "generation", r.gen, "event", e.String())
 
+	// find the Gateways affected by the changes
+	deps := buildDependencyIndex()
+	r.scope = getRenderScope(e, r.deps, deps)
+	r.deps = deps
+	if r.scope == nil {
+		// scheduled renders are requested again during a full render
+		r.nextRender = time.Time{}
+	} else {
+		r.log.V(1).Info("incremental render", "gateways", len(r.scope.gateways),
+			"gateway-classes", len(r.scope.classes))
+	}
+
+	r.events.startRender()
+	defer r.events.finishRender(r.scope)
+
+	mode := config.DataplaneMode.String()
+	start := time.Now()
+	defer func() {
+		metrics.RenderTotal.WithLabelValues(mode).Inc()
+		metrics.RenderDuration.WithLabelValues(mode).Observe(time.Since(start).Seconds())
+	}()
+
 	switch config.DataplaneMode {
 	case config.DataplaneModeLegacy:
 		r.renderGatewayClass(e)
@@ -40,7 +69,6 @@ func (r *Renderer) renderGatewayClass(e *event.EventRender) {
 
 	if len(gcs) == 0 {
 		r.log.Info("no gateway-class objects found", "event", e.String())
-		return
 	}
 
 	if len(gcs) > 1 {
@@ -49,34 +77,72 @@ func (r *Renderer) renderGatewayClass(e *event.EventRender) {
 			names = append(names, fmt.Sprintf("%q", store.GetObjectKey(gc)))
 		}
 
-		r.log.Info("multiple gateway-class objects found: this is most probably UNINTENED - "+
-			"the operator will attempt to render a configuration for each gateway-class but there "+
-			"is no guarantee that this will work - this mode is UNSUPPORTED, "+
-			"if unsure, remove one of the gateway-class objects", "names", strings.Join(names, ", "))
+		r.log.Info("multiple gateway-class objects found: each gateway-class must render "+
+			"into a separate stunnerd config, gateway-classes whose target config map "+
+			"collides with that of an older gateway-class will be rejected",
+			"names", strings.Join(names, ", "))
 	}
 
-	// render each GatewayClass: hopefully they won's step on each other's throat: we cannot
-	// help if multiple GatewayClasses (or the GatewayConfigs thereof) set the rendering
-	// pipeline to render into the same configmap, but at least we can prevent race conditions
-	// by serializing update requests on the updaterChannel
+	// render each GatewayClass: gateway-classes are ordered by creation time so the oldest
+	// gateway-class wins if multiple GatewayClasses (or the GatewayConfigs thereof) set the
+	// rendering pipeline to render into the same config map; later ones are rejected
+	targets := map[types.NamespacedName]string{}
 	for _, gc := range gcs {
-		r.log.Info("rendering configuration", "gateway-class", store.GetObjectKey(gc))
 		c := NewRenderContext(e, r, gc)
 
 		r.log.V(1).Info("obtaining gateway-config", "gateway-class", gc.GetName())
 		var err error
 		c.gwConf, err = r.getGatewayConfig4Class(c)
 		if err != nil {
-			r.log.Error(err, "error obtaining gateway-config",
-				"gateway-class", gc.GetName())
+			if r.scope.hasClass(gc) {
+				r.log.Error(err, "error obtaining gateway-config",
+					"gateway-class", gc.GetName())
+				r.invalidateGatewayClass(c, err)
+				r.operatorCh <- c.update
+			}
+			continue
+		}
+
+		// claim the target even if the gateway-class is not rendered in this round
+		targetName, targetNamespace := getTarget(c)
+		target := types.NamespacedName{Namespace: targetNamespace, Name: targetName}
+		owner, collides := targets[target]
+		if !collides {
+			targets[target] = gc.GetName()
+		}
+
+		if !r.scope.hasClass(gc) {
+			r.log.V(1).Info("skipping unaffected gateway-class", "gateway-class",
+				store.GetObjectKey(gc))
+			continue
+		}
+
+		r.log.Info("rendering configuration", "gateway-class", store.GetObjectKey(gc))
+		if collides {
+			err := fmt.Errorf("%w: config map %q is already rendered by gateway-class %q",
+				NewCriticalError(ConfigMapCollision), target.String(), owner)
+			r.log.Error(err, "rejecting gateway-class", "gateway-class", gc.GetName())
+			r.recordError(gc, err, "")
 			r.invalidateGatewayClass(c, err)
+			// never touch the config map of the gateway-class that owns the target
+			c.update.UpsertQueue.ConfigMaps.Remove(target)
+			r.operatorCh <- c.update
 			continue
 		}
 
+		r.updateGatewayConfigStatus(c, c.gwConf)
+
 		r.log.V(1).Info("finding gateways", "gateway-class", store.GetObjectKey(gc))
 		gws := r.getGateways4Class(c)
 		c.gws.ResetGateways(gws)
 
+		for _, gw := range gws {
+			if _, ok := getGatewayConfigOverrideName(gw); ok {
+				r.log.Info("gateway-config overrides are supported only in managed "+
+					"dataplane mode, ignoring", "gateway", store.GetObjectKey(gw))
+			}
+		}
+
 		// render for ALL gateways that correspond to this gateway-class
 		if err := r.renderForGateways(c); err != nil {
 			// an irreparable error happened, invalidate the config and set all related
@@ -90,6 +156,27 @@ func (r *Renderer) renderGatewayClass(e *event.EventRender) {
 		// send the update back to the operator
 		r.operatorCh <- c.update
 	}
+
+	// remove the configs of the gateway-classes that are gone or render into another target
+	update := event.NewEventUpdate(r.gen)
+	update.Incremental = true
+	for n := range r.managedConfigs {
+		if _, ok := targets[n]; ok {
+			continue
+		}
+
+		r.log.Info("removing config for deleted gateway-class", "config-map", n.String())
+		cm := corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: n.Name, Namespace: n.Namespace}}
+		update.DeleteQueue.ConfigMaps.Upsert(&cm)
+		delete(r.managedConfigs, n)
+	}
+	for n := range targets {
+		r.managedConfigs[n] = true
+	}
+
+	if update.DeleteQueue.ConfigMaps.Len() > 0 {
+		r.operatorCh <- update
+	}
 }
 
 // renderManagedGateways generates and sets a STUNner daemon configuration for the "managed" dataplane mode.
@@ -101,10 +188,18 @@ func (r *Renderer) renderManagedGateways(e *event.EventRender) {
 
 	if len(gcs) == 0 {
 		r.log.Info("no gateway-class objects found", "event", e.String())
-		return
 	}
 
+	// the configs rendered in this round
+	rendered := map[types.NamespacedName]bool{}
+
 	for _, gc := range gcs {
+		if !r.scope.hasClass(gc) {
+			r.log.V(1).Info("skipping unaffected gateway-class", "gateway-class",
+				store.GetObjectKey(gc))
+			continue
+		}
+
 		r.log.Info("rendering configuration", "gateway-class", store.GetObjectKey(gc))
 
 		r.log.V(1).Info("obtaining gateway-config", "gateway-class", gc.GetName())
@@ -121,6 +216,8 @@ func (r *Renderer) renderManagedGateways(e *event.EventRender) {
 		}
 		gcCtx.gwConf = gwConf
 
+		r.updateGatewayConfigStatus(gcCtx, gcCtx.gwConf)
+
 		// don't even start rendering if Dataplane is not available
 		if _, err := getDataplane(gcCtx); err != nil {
 			r.log.Error(err, "error obtaining Dataplane",
@@ -136,6 +233,10 @@ func (r *Renderer) renderManagedGateways(e *event.EventRender) {
 		for _, gw := range r.getGateways4Class(gcCtx) {
 			gw := gw
 
+			if !r.scope.hasGateway(store.GetNamespacedName(gw)) {
+				continue
+			}
+
 			r.log.V(1).Info("rendering for gateway",
 				"gateway-class", store.GetObjectKey(gc),
 				"gateway", store.GetObjectKey(gw),
@@ -145,6 +246,33 @@ func (r *Renderer) renderManagedGateways(e *event.EventRender) {
 			gwCtx.gwConf = gcCtx.gwConf
 			gwCtx.gws.ResetGateways([]*gwapiv1b1.Gateway{gw})
 
+			// merge the Gateway-level GatewayConfig override, if any
+			override, err := r.getGatewayConfig4Gateway(gw)
+			if err != nil {
+				r.log.Error(err, "error obtaining gateway-config override",
+					"gateway-class", store.GetObjectKey(gc),
+					"gateway", store.GetObjectKey(gw),
+				)
+				r.invalidateGateways(gwCtx, err)
+				gcCtx.Merge(gwCtx)
+				continue
+			}
+
+			if override != nil {
+				r.log.V(1).Info("using gateway-config override",
+					"gateway-class", store.GetObjectKey(gc),
+					"gateway", store.GetObjectKey(gw),
+					"gateway-config", store.GetObjectKey(override),
+				)
+				gwCtx.gwConf = mergeGatewayConfig(gcCtx.gwConf, override)
+
+				// validate the merged config but report the status on the override,
+				// make sure the status update is not lost even if rendering fails
+				r.updateGatewayConfigStatus(gwCtx, override)
+				store.Merge(gcCtx.update.UpsertQueue.GatewayConfigs,
+					gwCtx.update.UpsertQueue.GatewayConfigs)
+			}
+
 			// render for this gateway
 			if err := r.renderForGateways(gwCtx); err != nil {
 				r.log.Error(err, "rendering",
@@ -159,8 +287,40 @@ func (r *Renderer) renderManagedGateways(e *event.EventRender) {
 
 		setGatewayClassStatusAccepted(gc, nil)
 
+		for _, cm := range gcCtx.update.UpsertQueue.ConfigMaps.GetAll() {
+			rendered[store.GetNamespacedName(cm)] = true
+		}
+
 		r.operatorCh <- gcCtx.update
 	}
+
+	// remove the configs of the Gateways that are gone or are no longer ours
+	classes := map[string]bool{}
+	for _, gc := range gcs {
+		classes[gc.GetName()] = true
+	}
+	update := event.NewEventUpdate(r.gen)
+	update.Incremental = true
+	for n := range r.managedConfigs {
+		if rendered[n] || !r.scope.hasGateway(n) {
+			continue
+		}
+		if gw := store.Gateways.GetObject(n); gw != nil && classes[string(gw.Spec.GatewayClassName)] {
+			continue
+		}
+
+		r.log.Info("removing config for deleted gateway", "config-map", n.String())
+		cm := corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: n.Name, Namespace: n.Namespace}}
+		update.DeleteQueue.ConfigMaps.Upsert(&cm)
+		delete(r.managedConfigs, n)
+	}
+	for n := range rendered {
+		r.managedConfigs[n] = true
+	}
+
+	if update.DeleteQueue.ConfigMaps.Len() > 0 {
+		r.operatorCh <- update
+	}
 }
 
 // renderForGateways renders a configuration for a set of Gateways (c.gws)
@@ -195,11 +355,16 @@ func (r *Renderer) renderForGateways(c *RenderContext) error {
 		initGatewayStatus(gw, config.ControllerName)
 
 		log.V(3).Info("obtaining public address", "gateway", gw.GetName())
-		ap, err := r.getPublicAddrPort4Gateway(gw)
+		aps, err := r.getPublicAddrPorts4Gateway(gw)
+		// all public addresses are published in the gateway status, but stunnerd
+		// listeners can use only a single one: choose the first address of the primary IP
+		// family
+		ap := getListenerAddrPort(aps, r.getPrimaryIPFamily4Gateway(gw))
 		if err != nil {
 			log.V(1).Info("cannot find public address", "gateway", gw.GetName(),
 				"error", err.Error())
-			ap = nil
+			r.recordError(gw, err, "")
+			ap, aps = nil, nil
 		} else if ap == nil {
 			// this should never happen: blow up
 			err = NewCriticalError(InternalError)
@@ -211,7 +376,7 @@ func (r *Renderer) renderForGateways(c *RenderContext) error {
 				"this is most probably caused by a fallback to a NodePort access service " +
 				"but no nodes seem to be having a valid external IP address. Hint: " +
 				"enable LoadBalancer services in Kubernetes")
-			ap = nil
+			ap, aps = nil, nil
 		}
 
 		// recreate the LoadBalancer service, otherwise a changed
@@ -231,12 +396,14 @@ func (r *Renderer) renderForGateways(c *RenderContext) error {
 			l := gw.Spec.Listeners[j]
 			log.V(3).Info("obtaining routes", "gateway", gw.GetName(), "listener",
 				l.Name)
-			rs := r.getUDPRoutes4Listener(gw, &l)
+			rs := r.getRoutes4Listener(gw, &l)
 
 			if isListenerConflicted(&l, udpPorts, tcpPorts) {
 				log.Info("listener protocol/port conflict", "gateway", gw.GetName(),
 					"listener", l.Name)
-				setListenerStatus(gw, &l, NewNonCriticalError(PortUnavailable), true, len(rs))
+				err := NewNonCriticalError(PortUnavailable)
+				r.recordError(gw, err, "listener %q", l.Name)
+				setListenerStatus(gw, &l, err, true, len(rs))
 				continue
 			}
 
@@ -246,6 +413,7 @@ func (r *Renderer) renderForGateways(c *RenderContext) error {
 				// rendering of the listener config
 				log.Info("error rendering configuration for listener", "gateway",
 					gw.GetName(), "listener", l.Name, "error", err.Error())
+				r.recordError(gw, err, "listener %q", l.Name)
 
 				setListenerStatus(gw, &l, err, false, 0)
 				continue
@@ -255,28 +423,29 @@ func (r *Renderer) renderForGateways(c *RenderContext) error {
 			setListenerStatus(gw, &l, nil, false, len(rs))
 		}
 
-		setGatewayStatusProgrammed(gw, nil, ap)
+		setGatewayStatusProgrammed(gw, nil, aps)
 		gw = pruneGatewayStatusConds(gw)
 
 		// schedule for update
 		c.update.UpsertQueue.Gateways.Upsert(gw)
 	}
 
-	log.V(1).Info("processing UDPRoutes")
+	log.V(1).Info("processing routes")
 	conf.Clusters = []stnrconfv1a1.ClusterConfig{}
-	rs := store.UDPRoutes.GetAll()
+	rs := getAllRoutes()
 	for _, ro := range rs {
-		log.V(2).Info("considering", "route", ro.GetName())
+		log.V(2).Info("considering", "route", ro.GetName(), "kind", getRouteKind(ro))
 
-		if !r.isRouteControlled(ro) {
+		if !r.isRouteControlled(ro) || !r.isRouteInScope(c, ro) {
 			continue
 		}
 
 		initRouteStatus(ro)
 
+		ps := getRouteParentRefs(ro)
 		renderRoute := false
-		for i := range ro.Spec.ParentRefs {
-			p := ro.Spec.ParentRefs[i]
+		for i := range ps {
+			p := ps[i]
 
 			parentOutContext := r.isParentOutContext(c.gws, ro, &p)
 			parentAccept := r.isParentAcceptingRoute(ro, &p, gc.GetName())
@@ -285,8 +454,12 @@ func (r *Renderer) renderForGateways(c *RenderContext) error {
 			renderRoute = renderRoute || (!parentOutContext && parentAccept)
 		}
 
-		rc, err := r.renderCluster(ro)
+		rc, rules, err := r.renderClusterWithStatus(ro)
+		// keep track of the original error for the route status
+		backendErr := err
 		if err != nil {
+			r.recordError(ro, err, "")
+
 			if IsNonCritical(err) {
 				log.Info("non-critical error rendering cluster", "route",
 					ro.GetName(), "error", err.Error())
@@ -304,19 +477,20 @@ func (r *Renderer) renderForGateways(c *RenderContext) error {
 
 		// set status: we can do this only once we know whether (1) the parent accepted the
 		// route and (2) the backend refs were successfully resolved
-		for i := range ro.Spec.ParentRefs {
-			p := ro.Spec.ParentRefs[i]
+		for i := range ps {
+			p := ps[i]
 
 			// set className="" -> do not consider class of the gw for setting the status
 			parentAccept := r.isParentAcceptingRoute(ro, &p, "")
 
-			setRouteConditionStatus(ro, &p, config.ControllerName, parentAccept, err)
+			setRouteConditionStatus(ro, &p, config.ControllerName, parentAccept, backendErr)
 		}
+		setRouteRuleStatus(ro, rules)
 
-		// schedule for update: note that we may process the same UDPRoute several times,
+		// schedule for update: note that we may process the same route several times,
 		// in the context of different Gateways: Upsert makes sure the last render will be
 		// updated
-		c.update.UpsertQueue.UDPRoutes.Upsert(ro)
+		upsertRoute(c, ro)
 	}
 
 	// schedule for update
@@ -344,15 +518,83 @@ func (r *Renderer) renderForGateways(c *RenderContext) error {
 	c.update.UpsertQueue.ConfigMaps.Upsert(cm)
 
 	if config.DataplaneMode == config.DataplaneModeManaged {
-		dp, err := r.createDeployment(c)
-		if err != nil {
-			return err
+		// errors are reported by createDeployment
+		dataplane, _ := getDataplane(c)
+		gw := c.gws.GetFirst()
+		switch getDataplaneResourceType(dataplane) {
+		case stnrv1a1.DataplaneResourceDaemonSet:
+			ds, err := r.createDaemonSet(c)
+			if err != nil {
+				return err
+			}
+			c.update.UpsertQueue.DaemonSets.Upsert(ds)
+
+			// remove the Deployment left behind by a previous dataplane resource type
+			if gw != nil && store.Deployments.GetObject(store.GetNamespacedName(gw)) != nil {
+				dp := defaultDeploymentSkeleton(gw)
+				c.update.DeleteQueue.Deployments.Upsert(&dp)
+			}
+
+			// DaemonSets cannot be autoscaled
+			r.removeHPA(c)
+
+			log.Info("STUNner dataplane DaemonSet ready", "generation", r.gen,
+				"daemonset", store.DumpObject(ds))
+
+		default:
+			dp, err := r.createDeployment(c)
+			if err != nil {
+				return err
+			}
+			c.update.UpsertQueue.Deployments.Upsert(dp)
+
+			// remove the DaemonSet left behind by a previous dataplane resource type
+			if gw != nil && store.DaemonSets.GetObject(store.GetNamespacedName(gw)) != nil {
+				ds := defaultDaemonSetSkeleton(gw)
+				c.update.DeleteQueue.DaemonSets.Upsert(&ds)
+			}
+
+			if dataplane.Spec.Autoscaling != nil {
+				hpa, err := r.createHPA(c, dataplane)
+				if err != nil {
+					return err
+				}
+				c.update.UpsertQueue.HPAs.Upsert(hpa)
+			} else {
+				r.removeHPA(c)
+			}
+
+			log.Info("STUNner dataplane Deployment ready", "generation", r.gen,
+				"deployment", store.DumpObject(dp))
 		}
-		c.update.UpsertQueue.Deployments.Upsert(dp)
 
-		log.Info("STUNner dataplane Deployment ready", "generation", r.gen,
-			"deployment", store.DumpObject(dp))
+		if dataplane.Spec.Template == config.ConfigWatcherName {
+			sa, role, binding, err := r.createConfigWatcherRBAC(c, dataplane)
+			if err != nil {
+				return err
+			}
+			if sa != nil {
+				c.update.UpsertQueue.ServiceAccounts.Upsert(sa)
+			} else {
+				// remove the ServiceAccount created before the Dataplane specified one
+				r.removeConfigWatcherRBAC(c, true)
+			}
+			c.update.UpsertQueue.Roles.Upsert(role)
+			c.update.UpsertQueue.RoleBindings.Upsert(binding)
+		} else {
+			// remove the RBAC resources left behind by a previous config-watcher template
+			r.removeConfigWatcherRBAC(c, false)
+		}
 
+		if dataplane.Spec.DisruptionBudget != nil {
+			pdb, err := r.createPDB(c, dataplane)
+			if err != nil {
+				return err
+			}
+			c.update.UpsertQueue.PDBs.Upsert(pdb)
+		} else {
+			r.removePDB(c)
+		}
 	}
 
 	return nil
@@ -393,6 +635,8 @@ func (r *Renderer) invalidateGateways(c *RenderContext, reason error) {
 	for _, gw := range c.gws.GetAll() {
 		log.V(2).Info("considering", "gateway", gw.GetName(), "listener-num", len(gw.Spec.Listeners))
 
+		r.recordError(gw, reason, "invalid gateway configuration")
+
 		// this also re-inits listener statuses
 		initGatewayStatus(gw, config.ControllerName)
 
@@ -408,15 +652,20 @@ func (r *Renderer) invalidateGateways(c *RenderContext, reason error) {
 		c.update.UpsertQueue.Gateways.Upsert(gw)
 	}
 
-	log.V(1).Info("processing UDPRoutes")
-	rs := store.UDPRoutes.GetAll()
+	log.V(1).Info("processing routes")
+	rs := getAllRoutes()
 	for _, ro := range rs {
-		log.V(2).Info("considering", "route", ro.GetName())
+		log.V(2).Info("considering", "route", ro.GetName(), "kind", getRouteKind(ro))
+
+		if !r.isRouteInScope(c, ro) {
+			continue
+		}
 
 		initRouteStatus(ro)
 
-		for i := range ro.Spec.ParentRefs {
-			p := ro.Spec.ParentRefs[i]
+		ps := getRouteParentRefs(ro)
+		for i := range ps {
+			p := ps[i]
 
 			// skip if we are not responsible
 			if r.isParentOutContext(c.gws, ro, &p) {
@@ -431,7 +680,7 @@ func (r *Renderer) invalidateGateways(c *RenderContext, reason error) {
 			setRouteConditionStatus(ro, &p, config.ControllerName, accepted, err)
 		}
 
-		c.update.UpsertQueue.UDPRoutes.Upsert(ro)
+		upsertRoute(c, ro)
 	}
 
 	// schedule for update
@@ -456,6 +705,12 @@ func (r *Renderer) invalidateGateways(c *RenderContext, reason error) {
 				}
 				dp := defaultDeploymentSkeleton(gw)
 				c.update.DeleteQueue.Deployments.Upsert(&dp)
+				if store.DaemonSets.GetObject(store.GetNamespacedName(gw)) != nil {
+					ds := defaultDaemonSetSkeleton(gw)
+					c.update.DeleteQueue.DaemonSets.Upsert(&ds)
+				}
+				r.removeHPA(c)
+				r.removePDB(c)
 			}
 			return
 
@@ -4,15 +4,18 @@ import (
 	// "context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	meta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	// "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/types"
 	// "sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
@@ -26,6 +29,7 @@ import (
 	"github.com/l7mp/stunner-gateway-operator/internal/store"
 	"github.com/l7mp/stunner-gateway-operator/internal/testutils"
 	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
+	cds "github.com/l7mp/stunner-gateway-operator/pkg/config/server"
 )
 
 func TestRenderPipelineLegacyMode(t *testing.T) {
@@ -183,7 +187,7 @@ func TestRenderPipelineLegacyMode(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				s := testutils.TestSvc.DeepCopy()
 				s.Spec.ClusterIP = "4.3.2.1"
@@ -288,6 +292,87 @@ func TestRenderPipelineLegacyMode(t *testing.T) {
 				config.DataplaneMode = config.NewDataplaneMode(opdefault.DefaultDataplaneMode)
 			},
 		},
+		{
+			name: "dual-stack EDS with relay-to-cluster-IP - E2E test",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
+			svcs: []corev1.Service{testutils.TestSvcDualStack},
+			eps: []discoveryv1.EndpointSlice{testutils.TestEndpointSlice,
+				testutils.TestEndpointSliceIPv6},
+			prep: func(c *renderTestConfig) {
+				s := testutils.TestSvcDualStack.DeepCopy()
+				// update owner ref so that we accept the public IP
+				s.SetOwnerReferences([]metav1.OwnerReference{{
+					APIVersion: gwapiv1b1.GroupVersion.String(),
+					Kind:       "Gateway",
+					UID:        testutils.TestGw.GetUID(),
+					Name:       testutils.TestGw.GetName(),
+				}})
+				c.svcs = []corev1.Service{*s}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				config.DataplaneMode = config.DataplaneModeLegacy
+
+				config.EnableEndpointDiscovery = true
+				config.EnableRelayToClusterIP = true
+
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, gws: store.NewGatewayStore(), log: logr.Discard()}
+				c.gwConf, err = r.getGatewayConfig4Class(c)
+				assert.NoError(t, err, "gw-conf found")
+
+				c.update = event.NewEventUpdate(0)
+				assert.NotNil(t, c.update, "update event create")
+
+				c.gws.ResetGateways(r.getGateways4Class(c))
+				err = r.renderForGateways(c)
+				assert.NoError(t, err, "render success")
+
+				cms := c.update.UpsertQueue.ConfigMaps.Objects()
+				assert.Len(t, cms, 1, "configmap ready")
+				cm, ok := cms[0].(*corev1.ConfigMap)
+				assert.True(t, ok, "configmap cast")
+
+				conf, err := store.UnpackConfigMap(cm)
+				assert.NoError(t, err, "configmap stunner-config unmarschal")
+
+				// listeners use the address of the primary IP family
+				assert.Len(t, conf.Listeners, 2, "listener num")
+				assert.Equal(t, "$STUNNER_ADDR", conf.Listeners[0].Addr, "addr")
+				assert.Equal(t, "1.2.3.4", conf.Listeners[0].PublicAddr, "public-ip")
+				assert.Equal(t, "1.2.3.4", conf.Listeners[1].PublicAddr, "public-ip")
+
+				// clusters contain the endpoints and ClusterIPs of both IP families
+				assert.Len(t, conf.Clusters, 1, "cluster num")
+				rc := conf.Clusters[0]
+				assert.Equal(t, "STATIC", rc.Type, "cluster type")
+				assert.Len(t, rc.Endpoints, 8, "endpoints len")
+				assert.Contains(t, rc.Endpoints, "10.0.0.1", "cluster-ip-4")
+				assert.Contains(t, rc.Endpoints, "fd00::1", "cluster-ip-6")
+				assert.Contains(t, rc.Endpoints, "1.2.3.4", "endpoint ip-1")
+				assert.Contains(t, rc.Endpoints, "1.2.3.5", "endpoint ip-2")
+				assert.Contains(t, rc.Endpoints, "1.2.3.6", "endpoint ip-3")
+				assert.Contains(t, rc.Endpoints, "1.2.3.7", "endpoint ip-4")
+				assert.Contains(t, rc.Endpoints, "fd00::4", "endpoint ipv6-1")
+				assert.Contains(t, rc.Endpoints, "fd00::5", "endpoint ipv6-2")
+
+				// the gateway status contains the public addresses of both IP families
+				gws := c.update.UpsertQueue.Gateways.GetAll()
+				assert.Len(t, gws, 1, "gateway num")
+				gw := gws[0]
+				assert.Len(t, gw.Status.Addresses, 2, "gateway status addresses")
+				assert.Equal(t, "1.2.3.4", gw.Status.Addresses[0].Value, "IPv4 status address")
+				assert.Equal(t, "2001:db8::1", gw.Status.Addresses[1].Value, "IPv6 status address")
+
+				// restore EDS
+				config.EnableEndpointDiscovery = opdefault.DefaultEnableEndpointDiscovery
+				config.EnableRelayToClusterIP = opdefault.DefaultEnableRelayToClusterIP
+				config.DataplaneMode = config.NewDataplaneMode(opdefault.DefaultDataplaneMode)
+			},
+		},
 		{
 			name: "EDS with relay-to-cluster-IP - E2E test",
 			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
@@ -295,7 +380,7 @@ func TestRenderPipelineLegacyMode(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				s := testutils.TestSvc.DeepCopy()
 				s.Spec.ClusterIP = "4.3.2.1"
@@ -739,7 +824,7 @@ func TestRenderPipelineLegacyMode(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				// a new gatewayclass that specifies a different gateway-config
 				// a new gatewayclass that specifies a different gateway-config
@@ -788,13 +873,16 @@ func TestRenderPipelineLegacyMode(t *testing.T) {
 				dummySvc.SetName("dummy-service")
 				c.svcs = []corev1.Service{*s, *dummySvc}
 
-				dummyEp := testutils.TestEndpoint.DeepCopy()
-				dummyEp.SetName("dummy-service")
-				dummyEp.Subsets = []corev1.EndpointSubset{{
-					Addresses:         []corev1.EndpointAddress{{IP: "4.4.4.4"}},
-					NotReadyAddresses: []corev1.EndpointAddress{{}},
+				dummyEp := testutils.TestEndpointSlice.DeepCopy()
+				dummyEp.SetName("dummy-service-slice")
+				dummyEp.SetLabels(map[string]string{discoveryv1.LabelServiceName: "dummy-service"})
+				dummyEp.Endpoints = []discoveryv1.Endpoint{{
+					Addresses: []string{"4.4.4.4"},
+				}, {
+					Addresses:  []string{},
+					Conditions: discoveryv1.EndpointConditions{Ready: &testutils.TestFalse},
 				}}
-				c.eps = []corev1.Endpoints{testutils.TestEndpoint, *dummyEp}
+				c.eps = []discoveryv1.EndpointSlice{testutils.TestEndpointSlice, *dummyEp}
 			},
 			tester: func(t *testing.T, r *Renderer) {
 				config.DataplaneMode = config.DataplaneModeLegacy
@@ -992,7 +1080,7 @@ func TestRenderPipelineLegacyMode(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			prep: func(c *renderTestConfig) {
 				// a new gatewayclass that specifies a different gateway-config
 				dummyGc := testutils.TestGwClass.DeepCopy()
@@ -1040,13 +1128,16 @@ func TestRenderPipelineLegacyMode(t *testing.T) {
 				dummySvc.SetName("dummy-service")
 				c.svcs = []corev1.Service{*s, *dummySvc}
 
-				dummyEp := testutils.TestEndpoint.DeepCopy()
-				dummyEp.SetName("dummy-service")
-				dummyEp.Subsets = []corev1.EndpointSubset{{
-					Addresses:         []corev1.EndpointAddress{{IP: "4.4.4.4"}},
-					NotReadyAddresses: []corev1.EndpointAddress{{}},
+				dummyEp := testutils.TestEndpointSlice.DeepCopy()
+				dummyEp.SetName("dummy-service-slice")
+				dummyEp.SetLabels(map[string]string{discoveryv1.LabelServiceName: "dummy-service"})
+				dummyEp.Endpoints = []discoveryv1.Endpoint{{
+					Addresses: []string{"4.4.4.4"},
+				}, {
+					Addresses:  []string{},
+					Conditions: discoveryv1.EndpointConditions{Ready: &testutils.TestFalse},
 				}}
-				c.eps = []corev1.Endpoints{testutils.TestEndpoint, *dummyEp}
+				c.eps = []discoveryv1.EndpointSlice{testutils.TestEndpointSlice, *dummyEp}
 			},
 			tester: func(t *testing.T, r *Renderer) {
 				config.DataplaneMode = config.DataplaneModeLegacy
@@ -1610,5 +1701,221 @@ func TestRenderPipelineLegacyMode(t *testing.T) {
 				assert.Len(t, lc.Routes, 0, "route num")
 			},
 		},
+		{
+			name: "multiple gateway-classes with colliding targets",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
+			svcs: []corev1.Service{testutils.TestSvc},
+			prep: func(c *renderTestConfig) {
+				// a newer gateway-class with its own gateway-config that renders into
+				// the same config map
+				gc := testutils.TestGwClass.DeepCopy()
+				gc.SetName("gatewayclass-2")
+				gc.SetCreationTimestamp(metav1.Now())
+				gc.Spec.ParametersRef.Name = "gatewayconfig-2"
+				c.cls = append(c.cls, *gc)
+
+				gwConf := testutils.TestGwConfig.DeepCopy()
+				gwConf.SetName("gatewayconfig-2")
+				c.cfs = append(c.cfs, *gwConf)
+
+				gw := testutils.TestGw.DeepCopy()
+				gw.SetName("gateway-2")
+				gw.Spec.GatewayClassName = "gatewayclass-2"
+				c.gws = append(c.gws, *gw)
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				config.DataplaneMode = config.DataplaneModeLegacy
+
+				gcs := r.getGatewayClasses()
+				assert.Len(t, gcs, 2, "gw-classes found")
+				assert.Equal(t, "gatewayclass-ok", gcs[0].GetName(), "oldest gw-class first")
+
+				ch := make(chan event.Event, 10)
+				r.SetOperatorChannel(ch)
+				r.Render(event.NewEventRender())
+
+				assert.Len(t, ch, 2, "update events sent")
+
+				// the older gateway-class is rendered
+				e := <-ch
+				u, ok := e.(*event.EventUpdate)
+				assert.True(t, ok, "update event")
+
+				cms := u.UpsertQueue.ConfigMaps.Objects()
+				assert.Len(t, cms, 1, "configmap ready")
+				assert.Equal(t, testutils.TestStunnerConfig, cms[0].GetName(), "configmap name")
+
+				gc := u.UpsertQueue.GatewayClasses.GetAll()
+				assert.Len(t, gc, 1, "gateway-class status")
+				assert.Equal(t, "gatewayclass-ok", gc[0].GetName(), "gateway-class name")
+				d := meta.FindStatusCondition(gc[0].Status.Conditions,
+					string(gwapiv1b1.GatewayClassConditionStatusAccepted))
+				assert.NotNil(t, d, "accepted cond found")
+				assert.Equal(t, metav1.ConditionTrue, d.Status, "accepted status")
+
+				// the newer one is rejected
+				e = <-ch
+				u, ok = e.(*event.EventUpdate)
+				assert.True(t, ok, "update event")
+
+				assert.Len(t, u.UpsertQueue.ConfigMaps.Objects(), 0, "no configmap")
+				assert.Len(t, u.DeleteQueue.ConfigMaps.Objects(), 0, "no configmap deleted")
+
+				gc = u.UpsertQueue.GatewayClasses.GetAll()
+				assert.Len(t, gc, 1, "gateway-class status")
+				assert.Equal(t, "gatewayclass-2", gc[0].GetName(), "gateway-class name")
+				d = meta.FindStatusCondition(gc[0].Status.Conditions,
+					string(gwapiv1b1.GatewayClassConditionStatusAccepted))
+				assert.NotNil(t, d, "accepted cond found")
+				assert.Equal(t, metav1.ConditionFalse, d.Status, "accepted status")
+				assert.Equal(t, string(gwapiv1b1.GatewayClassReasonInvalidParameters), d.Reason,
+					"accepted reason")
+				assert.Contains(t, d.Message, "gatewayclass-ok", "accepted message")
+
+				gws := u.UpsertQueue.Gateways.GetAll()
+				assert.Len(t, gws, 1, "gateway status")
+				assert.Equal(t, "gateway-2", gws[0].GetName(), "gateway name")
+				d = meta.FindStatusCondition(gws[0].Status.Conditions,
+					string(gwapiv1b1.GatewayConditionProgrammed))
+				assert.NotNil(t, d, "programmed cond found")
+				assert.Equal(t, metav1.ConditionFalse, d.Status, "programmed status")
+
+				config.DataplaneMode = config.NewDataplaneMode(opdefault.DefaultDataplaneMode)
+			},
+		},
+		{
+			name: "multiple gateway-classes with separate targets",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
+			svcs: []corev1.Service{testutils.TestSvc},
+			prep: func(c *renderTestConfig) {
+				gc := testutils.TestGwClass.DeepCopy()
+				gc.SetName("gatewayclass-2")
+				gc.SetCreationTimestamp(metav1.Now())
+				gc.Spec.ParametersRef.Name = "gatewayconfig-2"
+				c.cls = append(c.cls, *gc)
+
+				gwConf := testutils.TestGwConfig.DeepCopy()
+				gwConf.SetName("gatewayconfig-2")
+				target := "stunnerd-config-2"
+				gwConf.Spec.StunnerConfig = &target
+				c.cfs = append(c.cfs, *gwConf)
+
+				gw := testutils.TestGw.DeepCopy()
+				gw.SetName("gateway-2")
+				gw.Spec.GatewayClassName = "gatewayclass-2"
+				c.gws = append(c.gws, *gw)
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				config.DataplaneMode = config.DataplaneModeLegacy
+
+				ch := make(chan event.Event, 10)
+				r.SetOperatorChannel(ch)
+				r.Render(event.NewEventRender())
+
+				assert.Len(t, ch, 2, "update events sent")
+
+				for _, target := range []string{testutils.TestStunnerConfig, "stunnerd-config-2"} {
+					e := <-ch
+					u, ok := e.(*event.EventUpdate)
+					assert.True(t, ok, "update event")
+
+					cms := u.UpsertQueue.ConfigMaps.Objects()
+					assert.Len(t, cms, 1, "configmap ready")
+					assert.Equal(t, target, cms[0].GetName(), "configmap name")
+
+					gc := u.UpsertQueue.GatewayClasses.GetAll()
+					assert.Len(t, gc, 1, "gateway-class status")
+					d := meta.FindStatusCondition(gc[0].Status.Conditions,
+						string(gwapiv1b1.GatewayClassConditionStatusAccepted))
+					assert.NotNil(t, d, "accepted cond found")
+					assert.Equal(t, metav1.ConditionTrue, d.Status, "accepted status")
+				}
+
+				config.DataplaneMode = config.NewDataplaneMode(opdefault.DefaultDataplaneMode)
+			},
+		},
+		{
+			name: "deleted gateway-class config removed from CDS",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
+			svcs: []corev1.Service{testutils.TestSvc},
+			prep: func(c *renderTestConfig) {
+				gc := testutils.TestGwClass.DeepCopy()
+				gc.SetName("gatewayclass-2")
+				gc.SetCreationTimestamp(metav1.Now())
+				gc.Spec.ParametersRef.Name = "gatewayconfig-2"
+				c.cls = append(c.cls, *gc)
+
+				gwConf := testutils.TestGwConfig.DeepCopy()
+				gwConf.SetName("gatewayconfig-2")
+				target := "stunnerd-config-2"
+				gwConf.Spec.StunnerConfig = &target
+				c.cfs = append(c.cfs, *gwConf)
+
+				gw := testutils.TestGw.DeepCopy()
+				gw.SetName("gateway-2")
+				gw.Spec.GatewayClassName = "gatewayclass-2"
+				c.gws = append(c.gws, *gw)
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				config.DataplaneMode = config.DataplaneModeLegacy
+				defer func() {
+					config.DataplaneMode = config.NewDataplaneMode(opdefault.DefaultDataplaneMode)
+				}()
+
+				srv := cds.NewConfigDiscoveryServer(cds.ConfigDiscoveryConfig{Logger: logr.Discard()})
+				ch := make(chan event.Event, 10)
+				r.SetOperatorChannel(ch)
+
+				// render and pass all updates to the CDS server
+				render := func(e *event.EventRender) {
+					r.Render(e)
+					for len(ch) > 0 {
+						u, ok := (<-ch).(*event.EventUpdate)
+						assert.True(t, ok, "update event")
+						assert.NoError(t, srv.ProcessUpdate(u), "process update")
+					}
+				}
+
+				// check whether the CDS server holds the config of a client
+				hasConfig := func(name string) bool {
+					w := httptest.NewRecorder()
+					srv.HandleReq(w, httptest.NewRequest("GET", opdefault.DefaultConfigDiscoveryEndpoint+
+						"?id="+string(testutils.TestNsName)+"/"+name, nil))
+					return w.Code == http.StatusOK
+				}
+
+				render(event.NewEventRender())
+				assert.True(t, hasConfig(testutils.TestStunnerConfig), "config 1")
+				assert.True(t, hasConfig("stunnerd-config-2"), "config 2")
+
+				// delete the second gateway-class
+				key := types.NamespacedName{Namespace: testutils.TestGwClass.GetNamespace(),
+					Name: "gatewayclass-2"}
+				store.GatewayClasses.Remove(key)
+				render(event.NewEventRender(key))
+				assert.True(t, hasConfig(testutils.TestStunnerConfig), "config 1")
+				assert.False(t, hasConfig("stunnerd-config-2"), "config of deleted gateway-class removed")
+
+				// retarget the remaining gateway-class
+				gwConf := store.GatewayConfigs.GetObject(store.GetNamespacedName(&testutils.TestGwConfig))
+				assert.NotNil(t, gwConf, "gateway-config found")
+				gwConf = gwConf.DeepCopy()
+				target := "stunnerd-config-3"
+				gwConf.Spec.StunnerConfig = &target
+				store.GatewayConfigs.Upsert(gwConf)
+				render(event.NewEventRender(store.GetNamespacedName(gwConf)))
+				assert.False(t, hasConfig(testutils.TestStunnerConfig), "config of retargeted gateway-class removed")
+				assert.True(t, hasConfig("stunnerd-config-3"), "config 3")
+			},
+		},
 	})
 }
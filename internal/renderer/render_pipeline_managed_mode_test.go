@@ -5,15 +5,18 @@ import (
 	"fmt"
 	"testing"
 
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/go-logr/logr"
 	appv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	meta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	// "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	// "sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	stnrv1a1 "github.com/l7mp/stunner-gateway-operator/api/v1alpha1"
@@ -23,6 +26,7 @@ import (
 
 	"github.com/l7mp/stunner-gateway-operator/internal/config"
 	"github.com/l7mp/stunner-gateway-operator/internal/event"
+	"github.com/l7mp/stunner-gateway-operator/internal/metrics"
 	"github.com/l7mp/stunner-gateway-operator/internal/store"
 	"github.com/l7mp/stunner-gateway-operator/internal/testutils"
 	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
@@ -314,7 +318,7 @@ func TestRenderPipelineManagedMode(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			dps:  []stnrv1a1.Dataplane{testutils.TestDataplane},
 			prep: func(c *renderTestConfig) {
 				s := testutils.TestSvc.DeepCopy()
@@ -1102,7 +1106,7 @@ func TestRenderPipelineManagedMode(t *testing.T) {
 			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
 			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
 			svcs: []corev1.Service{testutils.TestSvc},
-			eps:  []corev1.Endpoints{testutils.TestEndpoint},
+			eps:  []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
 			dps:  []stnrv1a1.Dataplane{testutils.TestDataplane},
 			prep: func(c *renderTestConfig) {
 				// a new gatewayclass that specifies a different gateway-config
@@ -1168,13 +1172,16 @@ func TestRenderPipelineManagedMode(t *testing.T) {
 				dummySvc.SetName("dummy-service")
 				c.svcs = []corev1.Service{*s, *dummySvc}
 
-				dummyEp := testutils.TestEndpoint.DeepCopy()
-				dummyEp.SetName("dummy-service")
-				dummyEp.Subsets = []corev1.EndpointSubset{{
-					Addresses:         []corev1.EndpointAddress{{IP: "4.4.4.4"}},
-					NotReadyAddresses: []corev1.EndpointAddress{{}},
+				dummyEp := testutils.TestEndpointSlice.DeepCopy()
+				dummyEp.SetName("dummy-service-slice")
+				dummyEp.SetLabels(map[string]string{discoveryv1.LabelServiceName: "dummy-service"})
+				dummyEp.Endpoints = []discoveryv1.Endpoint{{
+					Addresses: []string{"4.4.4.4"},
+				}, {
+					Addresses:  []string{},
+					Conditions: discoveryv1.EndpointConditions{Ready: &testutils.TestFalse},
 				}}
-				c.eps = []corev1.Endpoints{testutils.TestEndpoint, *dummyEp}
+				c.eps = []discoveryv1.EndpointSlice{testutils.TestEndpointSlice, *dummyEp}
 			},
 			tester: func(t *testing.T, r *Renderer) {
 				config.DataplaneMode = config.DataplaneModeManaged
@@ -1646,5 +1653,301 @@ func TestRenderPipelineManagedMode(t *testing.T) {
 				config.DataplaneMode = config.NewDataplaneMode(opdefault.DefaultDataplaneMode)
 			},
 		},
+		{
+			name: "gateway-config override - E2E test",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
+			svcs: []corev1.Service{testutils.TestSvc},
+			dps:  []stnrv1a1.Dataplane{testutils.TestDataplane},
+			prep: func(c *renderTestConfig) {
+				w := testutils.TestGwConfig.DeepCopy()
+				w.SetName("gatewayconfig-override")
+				realm := "override.example.com"
+				atype := "longterm"
+				secret := "override-secret"
+				w.Spec = stnrv1a1.GatewayConfigSpec{
+					Realm:        &realm,
+					AuthType:     &atype,
+					SharedSecret: &secret,
+				}
+				c.cfs = append(c.cfs, *w)
+
+				gw := testutils.TestGw.DeepCopy()
+				gw.SetName("gateway-2")
+				gw.SetAnnotations(map[string]string{
+					opdefault.GatewayConfigAnnotationKey: "gatewayconfig-override",
+				})
+				c.gws = append(c.gws, *gw)
+
+				gw = testutils.TestGw.DeepCopy()
+				gw.SetName("gateway-3")
+				gw.SetAnnotations(map[string]string{
+					opdefault.GatewayConfigAnnotationKey: "testnamespace/dummy",
+				})
+				c.gws = append(c.gws, *gw)
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				config.DataplaneMode = config.DataplaneModeManaged
+
+				ch := make(chan event.Event, 10)
+				r.SetOperatorChannel(ch)
+				r.renderManagedGateways(event.NewEventRender())
+
+				assert.Len(t, ch, 1, "update event sent")
+				e := <-ch
+				u, ok := e.(*event.EventUpdate)
+				assert.True(t, ok, "update event")
+
+				confs := map[string]string{}
+				for _, o := range u.UpsertQueue.ConfigMaps.Objects() {
+					cm, ok := o.(*corev1.ConfigMap)
+					assert.True(t, ok, "configmap cast")
+					if cm.GetName() == "gateway-3" {
+						// invalidated
+						continue
+					}
+					conf, err := store.UnpackConfigMap(cm)
+					assert.NoError(t, err, "configmap stunner-config unmarshal")
+					confs[cm.GetName()] = conf.Auth.Realm + "/" + conf.Auth.Type
+				}
+
+				// gateway-1 uses the class-level config
+				assert.Equal(t, testutils.TestRealm+"/plaintext", confs["gateway-1"],
+					"gateway-1 auth")
+
+				// gateway-2 uses the override
+				assert.Equal(t, "override.example.com/longterm", confs["gateway-2"],
+					"gateway-2 auth")
+
+				// gateway-3 refers to a nonexistent override: invalidated
+				gw := u.UpsertQueue.Gateways.GetObject(types.NamespacedName{
+					Namespace: "testnamespace", Name: "gateway-3"})
+				assert.NotNil(t, gw, "gateway-3 status updated")
+				d := meta.FindStatusCondition(gw.Status.Conditions,
+					string(gwapiv1b1.GatewayConditionProgrammed))
+				assert.NotNil(t, d, "programmed cond found")
+				assert.Equal(t, metav1.ConditionFalse, d.Status, "programmed status")
+
+				// statuses of the class-level config and the override
+				gwConf := u.UpsertQueue.GatewayConfigs.GetObject(types.NamespacedName{
+					Namespace: "testnamespace", Name: "gatewayconfig-ok"})
+				assert.NotNil(t, gwConf, "class-level gateway-config status updated")
+				assert.Equal(t, []string{"gatewayclass-ok"}, gwConf.Status.GatewayClasses,
+					"class-level gateway-config classes")
+				assert.Equal(t, []string{"testnamespace/gateway-1", "testnamespace/gateway-2",
+					"testnamespace/gateway-3"}, gwConf.Status.Gateways,
+					"class-level gateway-config gateways")
+
+				gwConf = u.UpsertQueue.GatewayConfigs.GetObject(types.NamespacedName{
+					Namespace: "testnamespace", Name: "gatewayconfig-override"})
+				assert.NotNil(t, gwConf, "override gateway-config status updated")
+				assert.Len(t, gwConf.Status.GatewayClasses, 0, "override gateway-config classes")
+				assert.Equal(t, []string{"testnamespace/gateway-2"}, gwConf.Status.Gateways,
+					"override gateway-config gateways")
+				d = meta.FindStatusCondition(gwConf.Status.Conditions,
+					string(stnrv1a1.GatewayConfigConditionAccepted))
+				assert.NotNil(t, d, "accepted cond found")
+				assert.Equal(t, metav1.ConditionTrue, d.Status, "accepted status")
+
+				config.DataplaneMode = config.NewDataplaneMode(opdefault.DefaultDataplaneMode)
+			},
+		},
+		{
+			name: "render metrics",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
+			svcs: []corev1.Service{testutils.TestSvc},
+			dps:  []stnrv1a1.Dataplane{testutils.TestDataplane},
+			prep: func(c *renderTestConfig) {},
+			tester: func(t *testing.T, r *Renderer) {
+				config.DataplaneMode = config.DataplaneModeManaged
+
+				mode := config.DataplaneMode.String()
+				renders := promtestutil.ToFloat64(metrics.RenderTotal.WithLabelValues(mode))
+
+				ch := make(chan event.Event, 10)
+				r.SetOperatorChannel(ch)
+				r.Render(event.NewEventRender())
+
+				assert.Len(t, ch, 1, "update event sent")
+				assert.Equal(t, renders+1,
+					promtestutil.ToFloat64(metrics.RenderTotal.WithLabelValues(mode)),
+					"render count")
+
+				config.DataplaneMode = config.NewDataplaneMode(opdefault.DefaultDataplaneMode)
+			},
+		},
+		{
+			name: "DaemonSet dataplane",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
+			svcs: []corev1.Service{testutils.TestSvc},
+			dps:  []stnrv1a1.Dataplane{testutils.TestDataplane},
+			prep: func(c *renderTestConfig) {
+				dp := testutils.TestDataplane.DeepCopy()
+				resource := stnrv1a1.DataplaneResourceDaemonSet
+				dp.Spec.DataplaneResource = &resource
+				dp.Spec.HostNetwork = true
+				c.dps = []stnrv1a1.Dataplane{*dp}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				config.DataplaneMode = config.DataplaneModeManaged
+				defer func() {
+					config.DataplaneMode = config.NewDataplaneMode(opdefault.DefaultDataplaneMode)
+				}()
+
+				// a stale Deployment left behind from a previous dataplane resource type
+				stale := defaultDeploymentSkeleton(&testutils.TestGw)
+				store.Deployments.Flush()
+				store.Deployments.Upsert(&stale)
+				defer store.Deployments.Flush()
+
+				ch := make(chan event.Event, 10)
+				r.SetOperatorChannel(ch)
+				r.Render(event.NewEventRender())
+
+				assert.Len(t, ch, 1, "update event sent")
+				e := <-ch
+				u, ok := e.(*event.EventUpdate)
+				assert.True(t, ok, "update event")
+
+				assert.Len(t, u.UpsertQueue.Deployments.Objects(), 0, "no deployment upserted")
+				dss := u.UpsertQueue.DaemonSets.GetAll()
+				assert.Len(t, dss, 1, "daemonset upserted")
+				ds := dss[0]
+
+				assert.Equal(t, testutils.TestGw.GetName(), ds.GetName(), "daemonset name")
+				assert.Equal(t, testutils.TestGw.GetNamespace(), ds.GetNamespace(),
+					"daemonset namespace")
+				assert.Len(t, ds.GetOwnerReferences(), 1, "owner ref")
+				assert.NotNil(t, ds.Spec.Selector, "selector")
+				assert.True(t, ds.Spec.Template.Spec.HostNetwork, "hostnetwork")
+				assert.Len(t, ds.Spec.Template.Spec.Containers, 1, "containers")
+				assert.Equal(t, opdefault.DefaultStunnerdInstanceName,
+					ds.Spec.Template.Spec.Containers[0].Name, "stunnerd container")
+
+				// the stale deployment is removed
+				dps := u.DeleteQueue.Deployments.Objects()
+				assert.Len(t, dps, 1, "deployment deleted")
+				assert.Equal(t, store.GetObjectKey(&stale), store.GetObjectKey(dps[0]),
+					"deleted deployment")
+				assert.Len(t, u.DeleteQueue.DaemonSets.Objects(), 0, "no daemonset deleted")
+			},
+		},
+		{
+			name: "autoscaling and disruption budget",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
+			svcs: []corev1.Service{testutils.TestSvc},
+			dps:  []stnrv1a1.Dataplane{testutils.TestDataplane},
+			prep: func(c *renderTestConfig) {
+				dp := testutils.TestDataplane.DeepCopy()
+				replicas, minReplicas := int32(3), int32(2)
+				dp.Spec.Replicas = &replicas
+				dp.Spec.Autoscaling = &stnrv1a1.DataplaneAutoscaling{
+					MinReplicas: &minReplicas,
+					MaxReplicas: 10,
+				}
+				maxUnavailable := intstr.FromInt(1)
+				dp.Spec.DisruptionBudget = &stnrv1a1.DataplaneDisruptionBudget{
+					MaxUnavailable: &maxUnavailable,
+				}
+				c.dps = []stnrv1a1.Dataplane{*dp}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				config.DataplaneMode = config.DataplaneModeManaged
+				defer func() {
+					config.DataplaneMode = config.NewDataplaneMode(opdefault.DefaultDataplaneMode)
+				}()
+
+				ch := make(chan event.Event, 10)
+				r.SetOperatorChannel(ch)
+				r.Render(event.NewEventRender())
+
+				assert.Len(t, ch, 1, "update event sent")
+				e := <-ch
+				u, ok := e.(*event.EventUpdate)
+				assert.True(t, ok, "update event")
+
+				// replicas are left to the HPA
+				dps := u.UpsertQueue.Deployments.GetAll()
+				assert.Len(t, dps, 1, "deployment upserted")
+				assert.Nil(t, dps[0].Spec.Replicas, "replicas unset")
+
+				hpas := u.UpsertQueue.HPAs.GetAll()
+				assert.Len(t, hpas, 1, "hpa upserted")
+				hpa := hpas[0]
+				assert.Equal(t, store.GetObjectKey(dps[0]), store.GetObjectKey(hpa), "hpa name")
+				assert.Equal(t, "Deployment", hpa.Spec.ScaleTargetRef.Kind, "scale target kind")
+				assert.Equal(t, dps[0].GetName(), hpa.Spec.ScaleTargetRef.Name, "scale target name")
+				assert.Equal(t, int32(2), *hpa.Spec.MinReplicas, "min replicas")
+				assert.Equal(t, int32(10), hpa.Spec.MaxReplicas, "max replicas")
+				assert.Len(t, hpa.GetOwnerReferences(), 1, "hpa owner ref")
+
+				pdbs := u.UpsertQueue.PDBs.GetAll()
+				assert.Len(t, pdbs, 1, "pdb upserted")
+				pdb := pdbs[0]
+				assert.Equal(t, store.GetObjectKey(dps[0]), store.GetObjectKey(pdb), "pdb name")
+				assert.Equal(t, dps[0].Spec.Selector, pdb.Spec.Selector, "pdb selector")
+				assert.Nil(t, pdb.Spec.MinAvailable, "min available")
+				assert.Equal(t, intstr.FromInt(1), *pdb.Spec.MaxUnavailable, "max unavailable")
+				assert.Len(t, pdb.GetOwnerReferences(), 1, "pdb owner ref")
+
+				// disable autoscaling and the disruption budget: stale objects are removed
+				// and the replica count is reset
+				staleHPA, stalePDB := defaultHPASkeleton(&testutils.TestGw),
+					defaultPDBSkeleton(&testutils.TestGw)
+				store.HorizontalPodAutoscalers.Upsert(&staleHPA)
+				store.PodDisruptionBudgets.Upsert(&stalePDB)
+				defer store.HorizontalPodAutoscalers.Flush()
+				defer store.PodDisruptionBudgets.Flush()
+
+				dp := store.Dataplanes.GetObject(types.NamespacedName{Name: testutils.TestDataplane.GetName()})
+				assert.NotNil(t, dp, "dataplane found")
+				dp.Spec.Autoscaling = nil
+				dp.Spec.DisruptionBudget = nil
+
+				r.Render(event.NewEventRender())
+				assert.Len(t, ch, 1, "update event sent")
+				e = <-ch
+				u, ok = e.(*event.EventUpdate)
+				assert.True(t, ok, "update event")
+
+				dps = u.UpsertQueue.Deployments.GetAll()
+				assert.Len(t, dps, 1, "deployment upserted")
+				assert.NotNil(t, dps[0].Spec.Replicas, "replicas set")
+				assert.Equal(t, int32(3), *dps[0].Spec.Replicas, "replicas")
+
+				assert.Len(t, u.UpsertQueue.HPAs.Objects(), 0, "no hpa upserted")
+				assert.Len(t, u.UpsertQueue.PDBs.Objects(), 0, "no pdb upserted")
+				assert.Len(t, u.DeleteQueue.HPAs.Objects(), 1, "hpa deleted")
+				assert.Len(t, u.DeleteQueue.PDBs.Objects(), 1, "pdb deleted")
+
+				// no replica count in the Dataplane: the replica count set by the HPA is
+				// reset to the default
+				dp.Spec.Replicas = nil
+
+				r.Render(event.NewEventRender())
+				assert.Len(t, ch, 1, "update event sent")
+				e = <-ch
+				u, ok = e.(*event.EventUpdate)
+				assert.True(t, ok, "update event")
+
+				dps = u.UpsertQueue.Deployments.GetAll()
+				assert.Len(t, dps, 1, "deployment upserted")
+				assert.NotNil(t, dps[0].Spec.Replicas, "replicas set")
+				assert.Equal(t, opdefault.DefaultDataplaneReplicas, *dps[0].Spec.Replicas,
+					"default replicas")
+				assert.Len(t, u.DeleteQueue.HPAs.Objects(), 1, "hpa deleted")
+			},
+		},
 	})
 }
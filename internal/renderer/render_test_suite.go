@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -47,13 +48,15 @@ type renderTestConfig struct {
 	cfs    []stnrv1a1.GatewayConfig
 	gws    []gwapiv1b1.Gateway
 	rs     []gwapiv1a2.UDPRoute
+	tcprs  []gwapiv1a2.TCPRoute
 	svcs   []corev1.Service
 	nodes  []corev1.Node
-	eps    []corev1.Endpoints
+	eps    []discoveryv1.EndpointSlice
 	scrts  []corev1.Secret
 	ascrts []corev1.Secret
 	nss    []corev1.Namespace
 	ssvcs  []stnrv1a1.StaticService
+	rgs    []gwapiv1b1.ReferenceGrant
 	dps    []stnrv1a1.Dataplane
 	prep   func(c *renderTestConfig)
 	tester func(t *testing.T, r *Renderer)
@@ -102,6 +105,11 @@ func renderTester(t *testing.T, testConf []renderTestConfig) {
 				store.UDPRoutes.Upsert(&c.rs[i])
 			}
 
+			store.TCPRoutes.Flush()
+			for i := range c.tcprs {
+				store.TCPRoutes.Upsert(&c.tcprs[i])
+			}
+
 			store.Services.Flush()
 			for i := range c.svcs {
 				store.Services.Upsert(&c.svcs[i])
@@ -112,9 +120,9 @@ func renderTester(t *testing.T, testConf []renderTestConfig) {
 				store.Nodes.Upsert(&c.nodes[i])
 			}
 
-			store.Endpoints.Flush()
+			store.EndpointSlices.Flush()
 			for i := range c.eps {
-				store.Endpoints.Upsert(&c.eps[i])
+				store.EndpointSlices.Upsert(&c.eps[i])
 			}
 
 			store.Secrets.Flush()
@@ -137,6 +145,11 @@ func renderTester(t *testing.T, testConf []renderTestConfig) {
 				store.StaticServices.Upsert(&c.ssvcs[i])
 			}
 
+			store.ReferenceGrants.Flush()
+			for i := range c.rgs {
+				store.ReferenceGrants.Upsert(&c.rgs[i])
+			}
+
 			store.Dataplanes.Flush()
 			for i := range c.dps {
 				store.Dataplanes.Upsert(&c.dps[i])
@@ -3,9 +3,12 @@ package renderer
 import (
 	"context"
 	// "fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 
 	// gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	// stunnerconfv1alpha1 "github.com/l7mp/stunner/pkg/apis/v1alpha1"
@@ -15,7 +18,15 @@ import (
 
 type RendererConfig struct {
 	Scheme *runtime.Scheme
-	Logger logr.Logger
+	// EventRecorder, if set, is used to emit Kubernetes Events for rendering errors.
+	EventRecorder record.EventRecorder
+	// Resolver, if set, is used to resolve the hostnames in the public addresses of Gateways
+	// into IP addresses.
+	Resolver Resolver
+	// Offline makes rendering reproducible when there is no cluster to write back to, e.g.,
+	// shared secrets are never generated or rotated.
+	Offline bool
+	Logger  logr.Logger
 }
 
 type Renderer struct {
@@ -23,17 +34,45 @@ type Renderer struct {
 	scheme               *runtime.Scheme
 	gen                  int
 	renderCh, operatorCh chan event.Event
+	events               *eventRecorder
+	resolver             Resolver
+	resolverCh           chan struct{}
+	sharedSecrets        map[types.NamespacedName]sharedSecretState
+	nextRender           time.Time
+	deps                 *dependencyIndex
+	scope                *renderScope
+	managedConfigs       map[types.NamespacedName]bool
+	offline              bool
 	log                  logr.Logger
 }
 
 // NewRenderer creates a new Renderer
 func NewRenderer(cfg RendererConfig) *Renderer {
-	return &Renderer{
-		scheme:   cfg.Scheme,
-		renderCh: make(chan event.Event, 10),
-		gen:      0,
-		log:      cfg.Logger.WithName("renderer"),
+	r := &Renderer{
+		scheme:         cfg.Scheme,
+		renderCh:       make(chan event.Event, 10),
+		gen:            0,
+		events:         newEventRecorder(cfg.EventRecorder),
+		resolver:       cfg.Resolver,
+		resolverCh:     make(chan struct{}, 1),
+		sharedSecrets:  map[types.NamespacedName]sharedSecretState{},
+		managedConfigs: map[types.NamespacedName]bool{},
+		offline:        cfg.Offline,
+		log:            cfg.Logger.WithName("renderer"),
 	}
+
+	if r.resolver != nil {
+		// re-render when a hostname resolves to new addresses, a render is already pending
+		// if the channel is full
+		r.resolver.SetUpdateHandler(func() {
+			select {
+			case r.resolverCh <- struct{}{}:
+			default:
+			}
+		})
+	}
+
+	return r
 }
 
 func (r *Renderer) Start(ctx context.Context) error {
@@ -43,7 +82,19 @@ func (r *Renderer) Start(ctx context.Context) error {
 	go func() {
 		defer close(r.renderCh)
 
+		var timer *time.Timer
 		for {
+			// re-render at the time requested by the last rendering round (e.g., to rotate
+			// shared secrets), unless a render event arrives earlier
+			if timer != nil {
+				timer.Stop()
+			}
+			var timeout <-chan time.Time
+			if !r.nextRender.IsZero() {
+				timer = time.NewTimer(time.Until(r.nextRender))
+				timeout = timer.C
+			}
+
 			select {
 			case e := <-r.renderCh:
 				if e.GetType() != event.EventTypeRender {
@@ -57,6 +108,14 @@ func (r *Renderer) Start(ctx context.Context) error {
 				ev := e.(*event.EventRender)
 				r.Render(ev)
 
+			case <-timeout:
+				r.log.V(1).Info("scheduled rendering round")
+				r.Render(event.NewEventRender())
+
+			case <-r.resolverCh:
+				r.log.V(1).Info("rendering round after DNS update")
+				r.Render(event.NewEventRender())
+
 			case <-ctx.Done():
 				return
 			}
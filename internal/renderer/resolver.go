@@ -0,0 +1,163 @@
+package renderer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// ResolverTimeout is the maximum time to wait for a DNS lookup to complete.
+var ResolverTimeout = 2 * time.Second
+
+// ResolverCacheTTL is the period for which resolved hostnames are cached. The renderer re-renders
+// the configs after the cache entries expire, which refreshes the cache in the background and
+// triggers yet another render if the resolved addresses have changed.
+var ResolverCacheTTL = 5 * time.Minute
+
+// ErrResolverPending is returned by a Resolver when a hostname is being looked up for the first
+// time.
+var ErrResolverPending = errors.New("hostname resolution in progress")
+
+// Resolver resolves hostnames, like the DNS name of an AWS NLB, into IP addresses.
+type Resolver interface {
+	// Resolve returns the IP addresses of a hostname. Resolve must not block: hostnames that
+	// are not in the cache are looked up in the background.
+	Resolve(hostname string) ([]string, error)
+	// SetUpdateHandler sets a callback that is called whenever the addresses a hostname
+	// resolves to change.
+	SetUpdateHandler(handler func())
+}
+
+type resolverCacheEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// dnsResolver is a Resolver that uses the system DNS resolver, with caching. Lookups run in the
+// background, the cached addresses are returned until the lookup completes. If a lookup fails,
+// the last successfully resolved addresses are returned, if any.
+type dnsResolver struct {
+	lookup   func(ctx context.Context, hostname string) ([]string, error)
+	cache    map[string]resolverCacheEntry
+	inflight map[string]bool
+	handler  func()
+	lock     sync.Mutex
+}
+
+// NewDNSResolver creates a new Resolver that uses the system DNS resolver.
+func NewDNSResolver() Resolver {
+	return &dnsResolver{
+		lookup:   net.DefaultResolver.LookupHost,
+		cache:    make(map[string]resolverCacheEntry),
+		inflight: make(map[string]bool),
+	}
+}
+
+func (d *dnsResolver) SetUpdateHandler(handler func()) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.handler = handler
+}
+
+func (d *dnsResolver) Resolve(hostname string) ([]string, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	e, ok := d.cache[hostname]
+	if ok && time.Now().Before(e.expires) {
+		return e.addrs, nil
+	}
+
+	if !d.inflight[hostname] {
+		d.inflight[hostname] = true
+		go d.refresh(hostname)
+	}
+
+	if ok {
+		// serve stale until the lookup completes
+		return e.addrs, nil
+	}
+
+	return nil, ErrResolverPending
+}
+
+// refresh looks up a hostname and calls the update handler if the addresses have changed.
+func (d *dnsResolver) refresh(hostname string) {
+	ctx, cancel := context.WithTimeout(context.Background(), ResolverTimeout)
+	defer cancel()
+
+	addrs, err := d.lookup(ctx, hostname)
+
+	d.lock.Lock()
+	delete(d.inflight, hostname)
+	if err != nil {
+		// keep serving the stale addresses, if any
+		d.lock.Unlock()
+		return
+	}
+
+	sort.Strings(addrs)
+	e, ok := d.cache[hostname]
+	changed := !ok || !equalStrings(e.addrs, addrs)
+	d.cache[hostname] = resolverCacheEntry{addrs: addrs, expires: time.Now().Add(ResolverCacheTTL)}
+	handler := d.handler
+	d.lock.Unlock()
+
+	if changed && handler != nil {
+		handler()
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveAddrPorts extends a list of public addresses with the IP addresses the hostnames in the
+// list resolve to, right after the hostname. This is a no-op if no resolver is configured.
+func (r *Renderer) resolveAddrPorts(aps []gatewayAddress) []gatewayAddress {
+	if r.resolver == nil {
+		return aps
+	}
+
+	ret := []gatewayAddress{}
+	for _, ap := range aps {
+		ret = append(ret, ap)
+
+		if getIPFamily(ap.addr) != "" {
+			continue
+		}
+
+		// re-render when the cache entry expires to pick up DNS changes
+		r.scheduleRender(time.Now().Add(ResolverCacheTTL))
+
+		addrs, err := r.resolver.Resolve(ap.addr)
+		if err != nil {
+			r.log.V(1).Info("cannot resolve hostname", "hostname", ap.addr, "error",
+				err.Error())
+			continue
+		}
+
+		for _, a := range addrs {
+			ret = append(ret, gatewayAddress{
+				aType: gwapiv1b1.IPAddressType,
+				addr:  a,
+				port:  ap.port,
+			})
+		}
+	}
+
+	return uniqueAddrPorts(ret)
+}
@@ -0,0 +1,254 @@
+package renderer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	stnrv1a1 "github.com/l7mp/stunner-gateway-operator/api/v1alpha1"
+	"github.com/l7mp/stunner-gateway-operator/internal/testutils"
+)
+
+type fakeResolver map[string][]string
+
+func (f fakeResolver) Resolve(hostname string) ([]string, error) {
+	if addrs, ok := f[hostname]; ok {
+		return addrs, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func (f fakeResolver) SetUpdateHandler(func()) {}
+
+func TestDNSResolverCache(t *testing.T) {
+	d := NewDNSResolver().(*dnsResolver)
+
+	var lock sync.Mutex
+	dns := map[string][]string{}
+	d.lookup = func(_ context.Context, hostname string) ([]string, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if addrs, ok := dns[hostname]; ok {
+			return addrs, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	updated := make(chan struct{}, 10)
+	d.SetUpdateHandler(func() { updated <- struct{}{} })
+	// wait for the in-flight lookup of a hostname to finish
+	settle := func(hostname string) {
+		assert.Eventually(t, func() bool {
+			d.lock.Lock()
+			defer d.lock.Unlock()
+			return !d.inflight[hostname]
+		}, time.Second, time.Millisecond, "lookup finished")
+	}
+
+	// fresh cache entry
+	d.cache["dummy.invalid"] = resolverCacheEntry{
+		addrs:   []string{"1.2.3.4"},
+		expires: time.Now().Add(time.Minute),
+	}
+	addrs, err := d.Resolve("dummy.invalid")
+	assert.NoError(t, err, "resolve from cache")
+	assert.Equal(t, []string{"1.2.3.4"}, addrs, "cached addrs")
+	assert.False(t, d.inflight["dummy.invalid"], "no lookup for fresh entry")
+
+	// expired cache entry: lookup fails, serve stale
+	d.cache["dummy.invalid"] = resolverCacheEntry{
+		addrs:   []string{"1.2.3.5"},
+		expires: time.Now().Add(-time.Minute),
+	}
+	addrs, err = d.Resolve("dummy.invalid")
+	assert.NoError(t, err, "resolve stale")
+	assert.Equal(t, []string{"1.2.3.5"}, addrs, "stale addrs")
+	settle("dummy.invalid")
+	addrs, err = d.Resolve("dummy.invalid")
+	assert.NoError(t, err, "resolve stale")
+	assert.Equal(t, []string{"1.2.3.5"}, addrs, "stale addrs")
+	settle("dummy.invalid")
+	assert.Len(t, updated, 0, "no update on failed lookup")
+
+	// no cache entry: returns immediately, lookup runs in the background
+	_, err = d.Resolve("dummy-2.invalid")
+	assert.ErrorIs(t, err, ErrResolverPending, "resolve pending")
+	settle("dummy-2.invalid")
+	_, err = d.Resolve("dummy-2.invalid")
+	assert.ErrorIs(t, err, ErrResolverPending, "resolve still pending on lookup failure")
+	settle("dummy-2.invalid")
+	assert.Len(t, updated, 0, "no update on failed lookup")
+
+	// hostname appears in DNS: update handler called
+	lock.Lock()
+	dns["dummy-2.invalid"] = []string{"1.2.3.7", "1.2.3.6"}
+	lock.Unlock()
+	_, err = d.Resolve("dummy-2.invalid")
+	assert.ErrorIs(t, err, ErrResolverPending, "resolve pending")
+	settle("dummy-2.invalid")
+	assert.Eventually(t, func() bool { return len(updated) == 1 }, time.Second,
+		time.Millisecond, "update on new addresses")
+	<-updated
+	addrs, err = d.Resolve("dummy-2.invalid")
+	assert.NoError(t, err, "resolve from cache")
+	assert.Equal(t, []string{"1.2.3.6", "1.2.3.7"}, addrs, "resolved addrs")
+
+	// entry expires but DNS is unchanged: no update
+	d.lock.Lock()
+	e := d.cache["dummy-2.invalid"]
+	e.expires = time.Now().Add(-time.Minute)
+	d.cache["dummy-2.invalid"] = e
+	d.lock.Unlock()
+	addrs, err = d.Resolve("dummy-2.invalid")
+	assert.NoError(t, err, "resolve stale")
+	assert.Equal(t, []string{"1.2.3.6", "1.2.3.7"}, addrs, "stale addrs")
+	settle("dummy-2.invalid")
+	assert.Len(t, updated, 0, "no update on unchanged addresses")
+
+	// entry expires and DNS changes: update handler called
+	lock.Lock()
+	dns["dummy-2.invalid"] = []string{"1.2.3.8"}
+	lock.Unlock()
+	d.lock.Lock()
+	e = d.cache["dummy-2.invalid"]
+	e.expires = time.Now().Add(-time.Minute)
+	d.cache["dummy-2.invalid"] = e
+	d.lock.Unlock()
+	addrs, err = d.Resolve("dummy-2.invalid")
+	assert.NoError(t, err, "resolve stale")
+	assert.Equal(t, []string{"1.2.3.6", "1.2.3.7"}, addrs, "stale addrs")
+	settle("dummy-2.invalid")
+	assert.Eventually(t, func() bool { return len(updated) == 1 }, time.Second,
+		time.Millisecond, "update on changed addresses")
+	addrs, err = d.Resolve("dummy-2.invalid")
+	assert.NoError(t, err, "resolve from cache")
+	assert.Equal(t, []string{"1.2.3.8"}, addrs, "updated addrs")
+}
+
+func TestRenderResolver(t *testing.T) {
+	renderTester(t, []renderTestConfig{
+		{
+			name: "load-balancer hostname resolved",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{},
+			svcs: []corev1.Service{testutils.TestSvc},
+			prep: func(c *renderTestConfig) {
+				s := testutils.TestSvc.DeepCopy()
+				s.SetOwnerReferences([]metav1.OwnerReference{{
+					APIVersion: gwapiv1b1.GroupVersion.String(),
+					Kind:       "Gateway",
+					UID:        testutils.TestGw.GetUID(),
+					Name:       testutils.TestGw.GetName(),
+				}})
+				s.Status.LoadBalancer.Ingress[0].IP = ""
+				s.Status.LoadBalancer.Ingress[0].Hostname = "dummy-hostname"
+				c.svcs = []corev1.Service{*s}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				r.resolver = fakeResolver{"dummy-hostname": {"2001:db8::1", "1.1.1.1"}}
+				defer func() { r.resolver = nil }()
+
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, log: logr.Discard()}
+
+				gws := r.getGateways4Class(c)
+				assert.Len(t, gws, 1, "gateways for class")
+				gw := gws[0]
+
+				addrs, err := r.getPublicAddrPorts4Gateway(gw)
+				assert.NoError(t, err, "owner ref found")
+				assert.Equal(t, []gatewayAddress{
+					{aType: gwapiv1b1.HostnameAddressType, addr: "dummy-hostname", port: 1},
+					{aType: gwapiv1b1.IPAddressType, addr: "2001:db8::1", port: 1},
+					{aType: gwapiv1b1.IPAddressType, addr: "1.1.1.1", port: 1},
+				}, addrs, "public addrs")
+
+				// listener uses the resolved IP of the primary family
+				ap := getListenerAddrPort(addrs, r.getPrimaryIPFamily4Gateway(gw))
+				assert.NotNil(t, ap, "listener addr")
+				assert.Equal(t, "1.1.1.1", ap.addr, "listener addr ok")
+
+				// no resolver: hostname only
+				r.resolver = nil
+				addrs, err = r.getPublicAddrPorts4Gateway(gw)
+				assert.NoError(t, err, "owner ref found")
+				assert.Len(t, addrs, 1, "public addrs")
+				ap = getListenerAddrPort(addrs, r.getPrimaryIPFamily4Gateway(gw))
+				assert.NotNil(t, ap, "listener addr")
+				assert.Equal(t, "dummy-hostname", ap.addr, "listener addr ok")
+			},
+		},
+		{
+			name: "requested hostname resolved",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{},
+			svcs: []corev1.Service{testutils.TestSvc},
+			prep: func(c *renderTestConfig) {
+				gw := testutils.TestGw.DeepCopy()
+				at := gwapiv1b1.HostnameAddressType
+				gw.Spec.Addresses = []gwapiv1b1.GatewayAddress{{Type: &at, Value: "dummy-hostname"}}
+				c.gws = []gwapiv1b1.Gateway{*gw}
+				s := testutils.TestSvc.DeepCopy()
+				s.SetOwnerReferences([]metav1.OwnerReference{{
+					APIVersion: gwapiv1b1.GroupVersion.String(),
+					Kind:       "Gateway",
+					UID:        testutils.TestGw.GetUID(),
+					Name:       testutils.TestGw.GetName(),
+				}})
+				c.svcs = []corev1.Service{*s}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				r.resolver = fakeResolver{"dummy-hostname": {"1.1.1.1", "1.1.1.2"}}
+				defer func() { r.resolver = nil }()
+
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, log: logr.Discard()}
+				c.gwConf, err = r.getGatewayConfig4Class(c)
+				assert.NoError(t, err, "gw-conf found")
+
+				gws := r.getGateways4Class(c)
+				assert.Len(t, gws, 1, "gateways for class")
+				gw := gws[0]
+
+				// the first resolved IP is forwarded to Kubernetes
+				s := r.createLbService4Gateway(c, gw)
+				assert.NotNil(t, s, "svc create")
+				assert.Equal(t, "1.1.1.1", s.Spec.LoadBalancerIP, "svc loadbalancerip")
+				assert.Equal(t, []string{"1.1.1.1"}, s.Spec.ExternalIPs, "svc externalips")
+
+				// status: requested hostname and IPs first, then the LB IP
+				addrs, err := r.getPublicAddrPorts4Gateway(gw)
+				assert.NoError(t, err, "owner ref found")
+				assert.Equal(t, []gatewayAddress{
+					{aType: gwapiv1b1.HostnameAddressType, addr: "dummy-hostname", port: 1},
+					{aType: gwapiv1b1.IPAddressType, addr: "1.1.1.1", port: 1},
+					{aType: gwapiv1b1.IPAddressType, addr: "1.1.1.2", port: 1},
+					{aType: gwapiv1b1.IPAddressType, addr: "1.2.3.4", port: 1},
+				}, addrs, "public addrs")
+
+				// hostname cannot be resolved
+				r.resolver = fakeResolver{}
+				s = r.createLbService4Gateway(c, gw)
+				assert.NotNil(t, s, "svc create")
+				assert.Empty(t, s.Spec.LoadBalancerIP, "svc loadbalancerip")
+				assert.Empty(t, s.Spec.ExternalIPs, "svc externalips")
+			},
+		},
+	})
+}
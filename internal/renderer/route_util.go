@@ -0,0 +1,197 @@
+package renderer
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+)
+
+// route kinds supported by the operator
+const (
+	udpRouteKind = "UDPRoute"
+	tcpRouteKind = "TCPRoute"
+)
+
+// getRoutes4Listener returns all routes (UDPRoutes and TCPRoutes) that attach to a listener
+func (r *Renderer) getRoutes4Listener(gw *gwapiv1b1.Gateway, l *gwapiv1b1.Listener) []client.Object {
+	ret := []client.Object{}
+
+	for _, ro := range r.getUDPRoutes4Listener(gw, l) {
+		ret = append(ret, ro)
+	}
+
+	for _, ro := range r.getTCPRoutes4Listener(gw, l) {
+		ret = append(ret, ro)
+	}
+
+	return ret
+}
+
+func (r *Renderer) getTCPRoutes4Listener(gw *gwapiv1b1.Gateway, l *gwapiv1b1.Listener) []*gwapiv1a2.TCPRoute {
+	r.log.V(4).Info("getTCPRoutes4Listener", "gateway", store.GetObjectKey(gw), "listener",
+		l.Name)
+
+	ret := make([]*gwapiv1a2.TCPRoute, 0)
+	rs := store.TCPRoutes.GetAll()
+
+	for i := range rs {
+		ro := rs[i]
+		r.log.V(4).Info("getTCPRoutes4Listener: considering route for listener", "gateway",
+			store.GetObjectKey(gw), "listener", l.Name, "route",
+			store.GetObjectKey(ro))
+
+		for j := range ro.Spec.CommonRouteSpec.ParentRefs {
+			p := ro.Spec.CommonRouteSpec.ParentRefs[j]
+
+			found, reason := resolveParentRef(ro, &p, gw, l)
+			if !found {
+				r.log.V(4).Info("getTCPRoutes4Listener: parent rejected for listener",
+					"gateway", store.GetObjectKey(gw), "listener", l.Name,
+					"route", store.GetObjectKey(ro), "parent", dumpParentRef(&p),
+					"reason", reason)
+
+				continue
+			}
+
+			r.log.V(4).Info("getTCPRoutes4Listener: route found", "gateway",
+				store.GetObjectKey(gw), "listener", l.Name, "route",
+				store.GetObjectKey(ro))
+
+			// route made it this far: attach!
+			ret = append(ret, ro)
+		}
+	}
+
+	return ret
+}
+
+// getAllRoutes returns all the routes from the local store, UDPRoutes first
+func getAllRoutes() []client.Object {
+	ret := []client.Object{}
+
+	for _, ro := range store.UDPRoutes.GetAll() {
+		ret = append(ret, ro)
+	}
+
+	for _, ro := range store.TCPRoutes.GetAll() {
+		ret = append(ret, ro)
+	}
+
+	return ret
+}
+
+// upsertRoute schedules a route for a status update in the update queue of a render context
+func upsertRoute(c *RenderContext, ro client.Object) {
+	switch o := ro.(type) {
+	case *gwapiv1a2.UDPRoute:
+		c.update.UpsertQueue.UDPRoutes.Upsert(o)
+	case *gwapiv1a2.TCPRoute:
+		c.update.UpsertQueue.TCPRoutes.Upsert(o)
+	}
+}
+
+func getRouteKind(ro client.Object) string {
+	switch ro.(type) {
+	case *gwapiv1a2.UDPRoute:
+		return udpRouteKind
+	case *gwapiv1a2.TCPRoute:
+		return tcpRouteKind
+	}
+	return ""
+}
+
+// getRouteRef returns the source of the references of a route for checking ReferenceGrants
+func getRouteRef(ro client.Object) objectRef {
+	return objectRef{
+		group:     gwapiv1b1.GroupVersion.Group,
+		kind:      getRouteKind(ro),
+		namespace: ro.GetNamespace(),
+		name:      ro.GetName(),
+	}
+}
+
+func getRouteParentRefs(ro client.Object) []gwapiv1b1.ParentReference {
+	switch o := ro.(type) {
+	case *gwapiv1a2.UDPRoute:
+		return o.Spec.ParentRefs
+	case *gwapiv1a2.TCPRoute:
+		return o.Spec.ParentRefs
+	}
+	return []gwapiv1b1.ParentReference{}
+}
+
+// getRouteBackendRefs returns the backend references per each rule of the route
+func getRouteBackendRefs(ro client.Object) [][]gwapiv1b1.BackendRef {
+	ret := [][]gwapiv1b1.BackendRef{}
+	switch o := ro.(type) {
+	case *gwapiv1a2.UDPRoute:
+		for _, rule := range o.Spec.Rules {
+			ret = append(ret, rule.BackendRefs)
+		}
+	case *gwapiv1a2.TCPRoute:
+		for _, rule := range o.Spec.Rules {
+			ret = append(ret, rule.BackendRefs)
+		}
+	}
+	return ret
+}
+
+func getRouteStatus(ro client.Object) *gwapiv1b1.RouteStatus {
+	switch o := ro.(type) {
+	case *gwapiv1a2.UDPRoute:
+		return &o.Status.RouteStatus
+	case *gwapiv1a2.TCPRoute:
+		return &o.Status.RouteStatus
+	}
+	// this should never happen
+	return &gwapiv1b1.RouteStatus{}
+}
+
+// getSupportedKinds returns the route kinds a listener can accept based on the listener protocol:
+// UDPRoutes can attach to any listener, TCPRoutes only to TCP-based listeners
+func getSupportedKinds(l *gwapiv1b1.Listener) []gwapiv1b1.RouteGroupKind {
+	group := gwapiv1b1.Group(gwapiv1b1.GroupVersion.Group)
+
+	ret := []gwapiv1b1.RouteGroupKind{{Group: &group, Kind: gwapiv1b1.Kind(udpRouteKind)}}
+	switch l.Protocol {
+	case "TCP", "TLS", "TURN-TCP", "TURN-TLS":
+		ret = append(ret, gwapiv1b1.RouteGroupKind{Group: &group, Kind: gwapiv1b1.Kind(tcpRouteKind)})
+	}
+
+	return ret
+}
+
+// listenerAllowsRouteKind checks whether the route kind is supported by the listener and it is
+// admitted by the AllowedRoutes.Kinds of the listener (if specified)
+func listenerAllowsRouteKind(ro client.Object, l *gwapiv1b1.Listener) bool {
+	kind := gwapiv1b1.Kind(getRouteKind(ro))
+
+	supported := false
+	for _, k := range getSupportedKinds(l) {
+		if k.Kind == kind {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return false
+	}
+
+	if l.AllowedRoutes == nil || len(l.AllowedRoutes.Kinds) == 0 {
+		return true
+	}
+
+	for _, k := range l.AllowedRoutes.Kinds {
+		if k.Group != nil && *k.Group != gwapiv1b1.Group(gwapiv1b1.GroupVersion.Group) {
+			continue
+		}
+		if k.Kind == kind {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,239 @@
+package renderer
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	stnrv1a1 "github.com/l7mp/stunner-gateway-operator/api/v1alpha1"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
+)
+
+// SharedSecretLength is the number of random bytes in the shared secrets generated by the
+// operator.
+var SharedSecretLength = 32
+
+// OfflineSharedSecret is the placeholder rendered in offline mode for a rotated shared secret
+// that does not exist yet.
+var OfflineSharedSecret = "offline-placeholder-shared-secret"
+
+// timeNow returns the current time, overridden in tests.
+var timeNow = time.Now
+
+// sharedSecretState is the rotation state of an operator-managed shared secret: active is the
+// secret used by the dataplane, next is the upcoming secret published during the overlap window
+// and previous is the secret replaced by the active one.
+type sharedSecretState struct {
+	active, next, previous string
+	rotatedAt              time.Time
+}
+
+// getSharedSecretState obtains the rotation state from an auth Secret.
+func getSharedSecretState(secret *corev1.Secret) sharedSecretState {
+	state := sharedSecretState{}
+	if secret == nil {
+		return state
+	}
+
+	if s, ok := secret.Data["secret"]; ok {
+		state.active = string(s)
+	} else if s, ok := secret.Data["sharedSecret"]; ok {
+		state.active = string(s)
+	}
+
+	if s, ok := secret.Data[opdefault.NextSharedSecretKey]; ok {
+		state.next = string(s)
+	}
+
+	if s, ok := secret.Data[opdefault.PreviousSharedSecretKey]; ok {
+		state.previous = string(s)
+	}
+
+	if a, ok := secret.GetAnnotations()[opdefault.SharedSecretRotatedAtAnnotationKey]; ok {
+		if t, err := time.Parse(time.RFC3339, a); err == nil {
+			state.rotatedAt = t
+		}
+	}
+
+	return state
+}
+
+// promote makes the upcoming secret active once the overlap window has closed. Returns true if
+// the active secret has changed.
+func (s *sharedSecretState) promote(now time.Time, overlap time.Duration) bool {
+	if s.next == "" || now.Before(s.rotatedAt.Add(overlap)) {
+		return false
+	}
+
+	s.previous, s.active, s.next = s.active, s.next, ""
+	return true
+}
+
+// getSharedSecretOverlap returns the time window after a rotation during which the upcoming
+// shared secret is published while the dataplane keeps using the current one.
+func getSharedSecretOverlap(gwConf *stnrv1a1.GatewayConfig) time.Duration {
+	rot := gwConf.Spec.SharedSecretRotation
+
+	overlap := opdefault.DefaultSharedSecretOverlap
+	if rot.Overlap != nil {
+		overlap = rot.Overlap.Duration
+	} else if gwConf.Spec.AuthLifetime != nil {
+		overlap = time.Duration(*gwConf.Spec.AuthLifetime) * time.Second
+	}
+
+	if overlap > rot.Interval.Duration {
+		overlap = rot.Interval.Duration
+	}
+
+	return overlap
+}
+
+// rotateSharedSecret returns the shared secret the dataplane should use for a GatewayConfig with
+// shared-secret rotation enabled. A new secret is generated if the Secret holds no shared secret
+// or the rotation interval has elapsed, in which case the updated Secret is queued for
+// write-back. Since stunnerd accepts a single shared secret only, a rotated secret is first
+// published under the key "next-secret" and it replaces the secret used by the dataplane (the
+// key "secret") only when the overlap window closes.
+func (r *Renderer) rotateSharedSecret(c *RenderContext, n types.NamespacedName, secret *corev1.Secret) (string, error) {
+	rot := c.gwConf.Spec.SharedSecretRotation
+	interval := rot.Interval.Duration
+	if interval <= 0 {
+		r.log.Info("invalid shared-secret rotation interval", "gateway-config",
+			store.GetObjectKey(c.gwConf), "interval", rot.Interval.Duration.String())
+		return "", NewCriticalError(InvalidSharedSecret)
+	}
+	overlap := getSharedSecretOverlap(c.gwConf)
+
+	if r.offline {
+		// use the Secret as is: a generated secret would change on every run
+		state := getSharedSecretState(secret)
+		if state.active == "" {
+			return OfflineSharedSecret, nil
+		}
+		return state.active, nil
+	}
+
+	// timestamps are stored with a second resolution
+	now := timeNow().UTC().Truncate(time.Second)
+
+	// the Secret in the store may lag behind our last write-back
+	state := getSharedSecretState(secret)
+	if cached, ok := r.sharedSecrets[n]; ok && cached.rotatedAt.After(state.rotatedAt) {
+		state = cached
+	}
+
+	// switch to the upcoming secret before a new rotation could replace it
+	if state.promote(now, overlap) {
+		r.log.Info("switching to the rotated shared secret", "secret", n.String())
+	}
+
+	switch {
+	case state.active == "":
+		r.log.Info("generating shared secret", "secret", n.String())
+		active, err := generateSharedSecret()
+		if err != nil {
+			return "", err
+		}
+		state = sharedSecretState{active: active, rotatedAt: now}
+	case state.rotatedAt.IsZero():
+		// a user-provided shared secret: adopt and rotate it after the first interval
+		r.log.Info("adopting shared secret", "secret", n.String())
+		state.rotatedAt = now
+	case !now.Before(state.rotatedAt.Add(interval)):
+		r.log.Info("rotating shared secret", "secret", n.String(), "last-rotation",
+			state.rotatedAt.Format(time.RFC3339))
+		next, err := generateSharedSecret()
+		if err != nil {
+			return "", err
+		}
+		state.next, state.rotatedAt = next, now
+	}
+
+	// a zero overlap window closes right away
+	if state.promote(now, overlap) {
+		r.log.Info("switching to the rotated shared secret", "secret", n.String())
+	}
+	r.sharedSecrets[n] = state
+
+	if updated := updateSharedSecret(n, secret, state); updated != nil {
+		c.update.UpsertQueue.Secrets.Upsert(updated)
+	}
+
+	// re-render when the next rotation is due or the overlap window closes
+	r.scheduleRender(state.rotatedAt.Add(interval))
+	if state.next != "" {
+		r.scheduleRender(state.rotatedAt.Add(overlap))
+	}
+
+	return state.active, nil
+}
+
+// updateSharedSecret returns a copy of the auth Secret updated with the rotation state, or nil if
+// the Secret is already up to date.
+func updateSharedSecret(n types.NamespacedName, secret *corev1.Secret, state sharedSecretState) *corev1.Secret {
+	rotatedAt := state.rotatedAt.Format(time.RFC3339)
+
+	if secret != nil && string(secret.Data["secret"]) == state.active &&
+		string(secret.Data[opdefault.NextSharedSecretKey]) == state.next &&
+		string(secret.Data[opdefault.PreviousSharedSecretKey]) == state.previous &&
+		secret.GetAnnotations()[opdefault.SharedSecretRotatedAtAnnotationKey] == rotatedAt {
+		return nil
+	}
+
+	var ret *corev1.Secret
+	if secret != nil {
+		ret = secret.DeepCopy()
+	} else {
+		ret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: n.Name, Namespace: n.Namespace},
+			Type:       corev1.SecretTypeOpaque,
+		}
+	}
+
+	if ret.Data == nil {
+		ret.Data = make(map[string][]byte)
+	}
+	if _, ok := ret.Data["type"]; !ok {
+		ret.Data["type"] = []byte("longterm")
+	}
+	ret.Data["secret"] = []byte(state.active)
+	if state.next != "" {
+		ret.Data[opdefault.NextSharedSecretKey] = []byte(state.next)
+	} else {
+		delete(ret.Data, opdefault.NextSharedSecretKey)
+	}
+	if state.previous != "" {
+		ret.Data[opdefault.PreviousSharedSecretKey] = []byte(state.previous)
+	}
+
+	annotations := ret.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[opdefault.SharedSecretRotatedAtAnnotationKey] = rotatedAt
+	ret.SetAnnotations(annotations)
+
+	return ret
+}
+
+// generateSharedSecret creates a new random shared secret.
+func generateSharedSecret() (string, error) {
+	buf := make([]byte, SharedSecretLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", NewCriticalError(InternalError)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// scheduleRender asks the renderer to run again at the given time, in case nothing else triggers
+// a rendering before that.
+func (r *Renderer) scheduleRender(t time.Time) {
+	if r.nextRender.IsZero() || t.Before(r.nextRender) {
+		r.nextRender = t
+	}
+}
@@ -0,0 +1,335 @@
+package renderer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	stnrv1a1 "github.com/l7mp/stunner-gateway-operator/api/v1alpha1"
+	"github.com/l7mp/stunner-gateway-operator/internal/event"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+	"github.com/l7mp/stunner-gateway-operator/internal/testutils"
+	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
+)
+
+var testRotationNow = time.Date(2023, time.September, 1, 12, 0, 0, 0, time.UTC)
+
+// rotationGwConf sets up a GatewayConfig with shared-secret rotation enabled
+func rotationGwConf(c *renderTestConfig) {
+	w := testutils.TestGwConfig.DeepCopy()
+	namespace := gwapiv1b1.Namespace("testnamespace")
+	w.Spec.AuthRef = &gwapiv1b1.SecretObjectReference{
+		Namespace: &namespace,
+		Name:      gwapiv1b1.ObjectName("testauthsecret-ok"),
+	}
+	w.Spec.AuthType = nil
+	w.Spec.Username = nil
+	w.Spec.Password = nil
+	w.Spec.SharedSecret = nil
+	w.Spec.SharedSecretRotation = &stnrv1a1.SharedSecretRotation{
+		Interval: metav1.Duration{Duration: time.Hour},
+		Overlap:  &metav1.Duration{Duration: 30 * time.Minute},
+	}
+	c.cfs = []stnrv1a1.GatewayConfig{*w}
+}
+
+// rotationSecret returns a longterm auth Secret last rotated at the given time
+func rotationSecret(active, next, previous string, rotatedAt time.Time) corev1.Secret {
+	s := testutils.TestAuthSecret.DeepCopy()
+	s.Data = map[string][]byte{
+		"type":   []byte("longterm"),
+		"secret": []byte(active),
+	}
+	if next != "" {
+		s.Data[opdefault.NextSharedSecretKey] = []byte(next)
+	}
+	if previous != "" {
+		s.Data[opdefault.PreviousSharedSecretKey] = []byte(previous)
+	}
+	s.SetAnnotations(map[string]string{
+		opdefault.SharedSecretRotatedAtAnnotationKey: rotatedAt.Format(time.RFC3339),
+	})
+	return *s
+}
+
+func rotationRenderContext(t *testing.T, r *Renderer) *RenderContext {
+	gc, err := r.getGatewayClass()
+	assert.NoError(t, err, "gw-class found")
+	c := &RenderContext{gc: gc, log: logr.Discard()}
+	c.update = event.NewEventUpdate(0)
+	c.gwConf, err = r.getGatewayConfig4Class(c)
+	assert.NoError(t, err, "gw-conf found")
+	return c
+}
+
+func TestSharedSecretRotation(t *testing.T) {
+	timeNow = func() time.Time { return testRotationNow }
+	defer func() { timeNow = time.Now }()
+
+	renderTester(t, []renderTestConfig{
+		{
+			name: "missing secret is generated",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			prep: rotationGwConf,
+			tester: func(t *testing.T, r *Renderer) {
+				c := rotationRenderContext(t, r)
+
+				auth, err := r.renderAuth(c)
+				assert.NoError(t, err, "renderAuth")
+				assert.Equal(t, "longterm", auth.Type, "auth-type")
+				active := auth.Credentials["secret"]
+				assert.NotEmpty(t, active, "secret generated")
+				_, ok := auth.Credentials[opdefault.PreviousSharedSecretKey]
+				assert.False(t, ok, "no previous secret")
+
+				ss := c.update.UpsertQueue.Secrets.GetAll()
+				assert.Len(t, ss, 1, "secret written back")
+				s := ss[0]
+				assert.Equal(t, "testauthsecret-ok", s.GetName(), "secret name")
+				assert.Equal(t, "testnamespace", s.GetNamespace(), "secret namespace")
+				assert.Equal(t, corev1.SecretTypeOpaque, s.Type, "secret type")
+				assert.Equal(t, "longterm", string(s.Data["type"]), "auth type")
+				assert.Equal(t, active, string(s.Data["secret"]), "active secret")
+				assert.Equal(t, testRotationNow.Format(time.RFC3339),
+					s.GetAnnotations()[opdefault.SharedSecretRotatedAtAnnotationKey],
+					"rotated-at")
+
+				assert.Equal(t, testRotationNow.Add(time.Hour), r.nextRender, "next render")
+
+				// the store lags behind: the same secret is rendered again
+				auth, err = r.renderAuth(c)
+				assert.NoError(t, err, "renderAuth")
+				assert.Equal(t, active, auth.Credentials["secret"], "secret unchanged")
+			},
+		},
+		{
+			name:   "user-provided secret is adopted",
+			cls:    []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:    []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			ascrts: []corev1.Secret{testutils.TestAuthSecret},
+			prep: func(c *renderTestConfig) {
+				rotationGwConf(c)
+				s := testutils.TestAuthSecret.DeepCopy()
+				s.Data["type"] = []byte("longterm")
+				c.ascrts = []corev1.Secret{*s}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				c := rotationRenderContext(t, r)
+
+				auth, err := r.renderAuth(c)
+				assert.NoError(t, err, "renderAuth")
+				assert.Equal(t, "ext-secret", auth.Credentials["secret"], "secret adopted")
+
+				ss := c.update.UpsertQueue.Secrets.GetAll()
+				assert.Len(t, ss, 1, "secret written back")
+				s := ss[0]
+				assert.Equal(t, "ext-secret", string(s.Data["secret"]), "active secret")
+				assert.Equal(t, "ext-testuser", string(s.Data["username"]), "other keys kept")
+				assert.Equal(t, testRotationNow.Format(time.RFC3339),
+					s.GetAnnotations()[opdefault.SharedSecretRotatedAtAnnotationKey],
+					"rotated-at")
+			},
+		},
+		{
+			name: "up to date secret is not written back",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			prep: func(c *renderTestConfig) {
+				rotationGwConf(c)
+				c.ascrts = []corev1.Secret{
+					rotationSecret("secret-1", "", "", testRotationNow.Add(-10*time.Minute)),
+				}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				c := rotationRenderContext(t, r)
+
+				auth, err := r.renderAuth(c)
+				assert.NoError(t, err, "renderAuth")
+				assert.Equal(t, "secret-1", auth.Credentials["secret"], "secret")
+				assert.Len(t, c.update.UpsertQueue.Secrets.GetAll(), 0, "no write-back")
+				assert.Equal(t, testRotationNow.Add(50*time.Minute), r.nextRender,
+					"next render")
+			},
+		},
+		{
+			name: "secret rotated after interval",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			prep: func(c *renderTestConfig) {
+				rotationGwConf(c)
+				c.ascrts = []corev1.Secret{
+					rotationSecret("secret-1", "", "secret-0", testRotationNow.Add(-2*time.Hour)),
+				}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				c := rotationRenderContext(t, r)
+
+				auth, err := r.renderAuth(c)
+				assert.NoError(t, err, "renderAuth")
+				assert.Equal(t, "secret-1", auth.Credentials["secret"],
+					"dataplane uses current secret in overlap window")
+				_, ok := auth.Credentials[opdefault.NextSharedSecretKey]
+				assert.False(t, ok, "no next secret in dataplane config")
+
+				ss := c.update.UpsertQueue.Secrets.GetAll()
+				assert.Len(t, ss, 1, "secret written back")
+				s := ss[0]
+				assert.Equal(t, "secret-1", string(s.Data["secret"]),
+					"secret matches the dataplane")
+				next := string(s.Data[opdefault.NextSharedSecretKey])
+				assert.NotEmpty(t, next, "next secret generated")
+				assert.NotEqual(t, "secret-1", next, "secret rotated")
+				assert.Equal(t, "secret-0", string(s.Data[opdefault.PreviousSharedSecretKey]),
+					"previous secret")
+				assert.Equal(t, testRotationNow.Format(time.RFC3339),
+					s.GetAnnotations()[opdefault.SharedSecretRotatedAtAnnotationKey],
+					"rotated-at")
+
+				// re-render when the overlap window closes
+				assert.Equal(t, testRotationNow.Add(30*time.Minute), r.nextRender,
+					"next render")
+
+				// dataplane switches to the new secret when the overlap window closes
+				timeNow = func() time.Time { return testRotationNow.Add(30 * time.Minute) }
+				defer func() { timeNow = func() time.Time { return testRotationNow } }()
+				c.update = event.NewEventUpdate(0)
+				auth, err = r.renderAuth(c)
+				assert.NoError(t, err, "renderAuth")
+				assert.Equal(t, next, auth.Credentials["secret"],
+					"dataplane uses new secret after overlap window")
+
+				ss = c.update.UpsertQueue.Secrets.GetAll()
+				assert.Len(t, ss, 1, "secret written back")
+				s = ss[0]
+				assert.Equal(t, next, string(s.Data["secret"]), "secret matches the dataplane")
+				_, ok = s.Data[opdefault.NextSharedSecretKey]
+				assert.False(t, ok, "next secret removed")
+				assert.Equal(t, "secret-1", string(s.Data[opdefault.PreviousSharedSecretKey]),
+					"previous secret")
+			},
+		},
+		{
+			name: "next secret published in overlap window",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			prep: func(c *renderTestConfig) {
+				rotationGwConf(c)
+				c.ascrts = []corev1.Secret{
+					rotationSecret("secret-1", "secret-2", "secret-0",
+						testRotationNow.Add(-10*time.Minute)),
+				}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				c := rotationRenderContext(t, r)
+
+				auth, err := r.renderAuth(c)
+				assert.NoError(t, err, "renderAuth")
+				assert.Equal(t, "secret-1", auth.Credentials["secret"], "current secret")
+				assert.Len(t, c.update.UpsertQueue.Secrets.GetAll(), 0, "no write-back")
+				assert.Equal(t, testRotationNow.Add(20*time.Minute), r.nextRender,
+					"next render")
+			},
+		},
+		{
+			name: "dataplane uses new secret after overlap window",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			prep: func(c *renderTestConfig) {
+				rotationGwConf(c)
+				c.ascrts = []corev1.Secret{
+					rotationSecret("secret-1", "secret-2", "secret-0",
+						testRotationNow.Add(-45*time.Minute)),
+				}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				c := rotationRenderContext(t, r)
+
+				auth, err := r.renderAuth(c)
+				assert.NoError(t, err, "renderAuth")
+				assert.Equal(t, "secret-2", auth.Credentials["secret"], "new secret")
+				_, ok := auth.Credentials[opdefault.PreviousSharedSecretKey]
+				assert.False(t, ok, "no previous secret")
+
+				ss := c.update.UpsertQueue.Secrets.GetAll()
+				assert.Len(t, ss, 1, "secret written back")
+				assert.Equal(t, "secret-2", string(ss[0].Data["secret"]),
+					"secret matches the dataplane")
+				assert.Equal(t, "secret-1", string(ss[0].Data[opdefault.PreviousSharedSecretKey]),
+					"previous secret")
+				assert.Equal(t, testRotationNow.Add(15*time.Minute), r.nextRender,
+					"next render")
+			},
+		},
+		{
+			name: "overlap defaults to auth lifetime",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			prep: func(c *renderTestConfig) {
+				rotationGwConf(c)
+				lifetime := int32(600)
+				c.cfs[0].Spec.AuthLifetime = &lifetime
+				c.cfs[0].Spec.SharedSecretRotation.Overlap = nil
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				c := rotationRenderContext(t, r)
+				assert.Equal(t, 10*time.Minute, getSharedSecretOverlap(c.gwConf), "overlap")
+
+				c.gwConf.Spec.AuthLifetime = nil
+				assert.Equal(t, opdefault.DefaultSharedSecretOverlap,
+					getSharedSecretOverlap(c.gwConf), "default overlap")
+
+				// never longer than the interval
+				c.gwConf.Spec.SharedSecretRotation.Interval = metav1.Duration{Duration: time.Minute}
+				assert.Equal(t, time.Minute, getSharedSecretOverlap(c.gwConf), "clamped overlap")
+			},
+		},
+		{
+			name: "offline mode never generates or rotates secrets",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			prep: rotationGwConf,
+			tester: func(t *testing.T, r *Renderer) {
+				r.offline = true
+				defer func() { r.offline = false }()
+				c := rotationRenderContext(t, r)
+
+				auth, err := r.renderAuth(c)
+				assert.NoError(t, err, "renderAuth")
+				assert.Equal(t, OfflineSharedSecret, auth.Credentials["secret"], "placeholder")
+
+				s := rotationSecret("secret-1", "secret-2", "secret-0",
+					testRotationNow.Add(-2*time.Hour))
+				store.AuthSecrets.Upsert(&s)
+				auth, err = r.renderAuth(c)
+				assert.NoError(t, err, "renderAuth")
+				assert.Equal(t, "secret-1", auth.Credentials["secret"], "secret not rotated")
+
+				assert.Len(t, c.update.UpsertQueue.Secrets.GetAll(), 0, "no write-back")
+				assert.True(t, r.nextRender.IsZero(), "no scheduled render")
+			},
+		},
+		{
+			name: "invalid interval errs",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			prep: func(c *renderTestConfig) {
+				rotationGwConf(c)
+				c.cfs[0].Spec.SharedSecretRotation.Interval = metav1.Duration{}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				c := rotationRenderContext(t, r)
+
+				_, err := r.renderAuth(c)
+				assert.Error(t, err, "renderAuth")
+				assert.True(t, IsCriticalError(err, InvalidSharedSecret), "error type")
+			},
+		},
+	})
+}
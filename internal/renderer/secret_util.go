@@ -66,6 +66,44 @@ func getSecretNameFromRef(ref *gwapiv1b1.SecretObjectReference, namespace string
 	return ret, nil
 }
 
+// checkCertRefsPermitted checks whether all cross-namespace certificate references in a listener
+// are permitted by a ReferenceGrant.
+func checkCertRefsPermitted(gw *gwapiv1b1.Gateway, l *gwapiv1b1.Listener) error {
+	if l.TLS == nil {
+		return nil
+	}
+
+	for _, ref := range l.TLS.CertificateRefs {
+		ref := ref
+
+		n, err := getSecretNameFromRef(&ref, gw.GetNamespace())
+		if err != nil {
+			continue
+		}
+
+		if !isRefPermitted(getGatewayRef(gw), getSecretRef(n)) {
+			return NewNonCriticalError(RefNotPermitted)
+		}
+	}
+
+	return nil
+}
+
+// getGatewayRef returns the source of the references of a Gateway for checking ReferenceGrants
+func getGatewayRef(gw *gwapiv1b1.Gateway) objectRef {
+	return objectRef{
+		group:     gwapiv1b1.GroupVersion.Group,
+		kind:      "Gateway",
+		namespace: gw.GetNamespace(),
+		name:      gw.GetName(),
+	}
+}
+
+// getSecretRef returns the target of a Secret reference for checking ReferenceGrants
+func getSecretRef(n types.NamespacedName) objectRef {
+	return objectRef{group: corev1.GroupName, kind: "Secret", namespace: n.Namespace, name: n.Name}
+}
+
 // dumpSecretRef is a helper to create a human-readable dump from a secret ref.
 func dumpSecretRef(ref *gwapiv1b1.SecretObjectReference, namespace string) string {
 	if ref == nil {
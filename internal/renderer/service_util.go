@@ -3,6 +3,7 @@ package renderer
 import (
 	"errors"
 	"fmt"
+	"net"
 	"regexp"
 	"strconv"
 	"strings"
@@ -37,31 +38,51 @@ func (ap *gatewayAddress) String() string {
 var annotationRegexProtocol *regexp.Regexp = regexp.MustCompile(`^service\.beta\.kubernetes\.io\/.*health.*protocol$`)
 var annotationRegexPort *regexp.Regexp = regexp.MustCompile(`^service\.beta\.kubernetes\.io\/.*health.*port$`)
 
-// returns the preferred address/port exposition for a gateway
-// preference order:
-// - loadbalancer svc created by us (owned by the gateway)
-// - nodeport svc created by us (owned by the gateway)
-// - load-balancer svc created manually by a user but annotated for the gateway
-// - nodeport svc created manually by a user but annotated for the gateway
-func (r *Renderer) getPublicAddrPort4Gateway(gw *gwapiv1b1.Gateway) (*gatewayAddress, error) {
+// returns all the public addresses/ports of a gateway, in the following order:
+// - addresses requested in Gateway.Spec.Addresses,
+// - load-balancer ingress IPs and hostnames,
+// - external node IPs with the NodePort, used only if no load-balancer address is available.
+// Only the services created for the gateway (annotated and owned by the gateway) are considered,
+// duplicate addresses are removed. If a resolver is configured, each hostname is followed by the
+// IP addresses it resolves to.
+func (r *Renderer) getPublicAddrPorts4Gateway(gw *gwapiv1b1.Gateway) ([]gatewayAddress, error) {
 	r.log.V(4).Info("getPublicAddrs4Gateway", "gateway", store.GetObjectKey(gw))
-	aps := []gatewayAddress{}
 
-	// hint the public address: if the Gateway contains a Spec.Addresses then use that as a
-	// fallback for the public address
-	var addrHint gatewayAddress
-	if len(gw.Spec.Addresses) > 0 && (gw.Spec.Addresses[0].Type == nil ||
-		*gw.Spec.Addresses[0].Type == gwapiv1b1.IPAddressType || *gw.Spec.Addresses[0].Type == gwapiv1b1.HostnameAddressType) &&
-		gw.Spec.Addresses[0].Value != "" {
-		addrHint = gatewayAddress{
-			aType: *gw.Spec.Addresses[0].Type,
-			addr:  gw.Spec.Addresses[0].Value,
-		}
+	// hint the public address: if the Gateway contains a Spec.Addresses then use that as the
+	// preferred public address
+	addrHints := getAddrHints4Gateway(gw)
+	if len(addrHints) > 0 {
 		r.log.V(2).Info("found public address in Gateway.Spec.Addresses",
-			"gateway", store.GetObjectKey(gw), "address", addrHint.String())
+			"gateway", store.GetObjectKey(gw), "addresses", fmt.Sprintf("%v", addrHints))
+	}
+
+	specAps, lbAps, nodeAps := []gatewayAddress{}, []gatewayAddress{}, []gatewayAddress{}
+	for _, svc := range r.getServices4Gateway(gw) {
+		spec, lb, node := r.getPublicAddrPorts4Svc(svc, gw, addrHints)
+		specAps = append(specAps, spec...)
+		lbAps = append(lbAps, lb...)
+		nodeAps = append(nodeAps, node...)
+	}
+
+	aps := uniqueAddrPorts(append(append(specAps, lbAps...), nodeAps...))
+
+	// add the IPs for hostnames
+	aps = r.resolveAddrPorts(aps)
+
+	r.log.V(4).Info("getPublicAddrs4Gateway: ready", "gateway", gw.GetName(), "addresses",
+		fmt.Sprintf("%v", aps))
+
+	if len(aps) == 0 {
+		return aps, NewNonCriticalError(PublicAddressNotFound)
 	}
 
-	err := NewNonCriticalError(PublicAddressNotFound)
+	return aps, nil
+}
+
+// getServices4Gateway returns the services that expose the gateway: the service must be
+// annotated for and owned by the gateway.
+func (r *Renderer) getServices4Gateway(gw *gwapiv1b1.Gateway) []*corev1.Service {
+	ret := []*corev1.Service{}
 	for _, svc := range store.Services.GetAll() {
 		r.log.V(4).Info("considering service", "svc", store.GetObjectKey(svc), "status",
 			fmt.Sprintf("%#v", svc.Status))
@@ -78,35 +99,97 @@ func (r *Renderer) getPublicAddrPort4Gateway(gw *gwapiv1b1.Gateway) (*gatewayAdd
 			continue
 		}
 
-		ap, lb := r.getPublicAddrPort4Svc(svc, gw, addrHint)
-		if ap == nil {
-			r.log.V(4).Info("public address/port not found for service", "svc",
-				store.GetObjectKey(svc), "gateway", store.GetObjectKey(svc))
-			continue
+		ret = append(ret, svc)
+	}
+
+	return ret
+}
+
+// getPrimaryIPFamily4Gateway returns the primary IP family of a gateway, i.e., the first IP family
+// of the service that exposes the gateway. This is the IP family of the pod IP of stunnerd, which
+// is used as the listener address. Defaults to IPv4.
+func (r *Renderer) getPrimaryIPFamily4Gateway(gw *gwapiv1b1.Gateway) corev1.IPFamily {
+	for _, svc := range r.getServices4Gateway(gw) {
+		if len(svc.Spec.IPFamilies) > 0 {
+			return svc.Spec.IPFamilies[0]
 		}
+	}
 
-		r.log.V(4).Info("public address/port found for service", "svc",
-			store.GetObjectKey(svc), "address", ap.String(), "load-balancer", lb)
+	return corev1.IPv4Protocol
+}
 
-		if lb {
-			// prepend!
-			aps = append([]gatewayAddress{*ap}, aps...)
-		} else {
-			// append
-			aps = append(aps, *ap)
+// getListenerAddrPort chooses the public address/port to be used in the STUNner listener config
+// from the list of public addresses of the gateway: this is the first IP address of the primary
+// IP family, or the first IP address of any family, or the first hostname if there is no IP
+// address at all (e.g., an unresolved AWS NLB hostname).
+func getListenerAddrPort(aps []gatewayAddress, primary corev1.IPFamily) *gatewayAddress {
+	for i := range aps {
+		if getIPFamily(aps[i].addr) == primary {
+			return &aps[i]
 		}
+	}
 
-		err = nil
+	for i := range aps {
+		if getIPFamily(aps[i].addr) != "" {
+			return &aps[i]
+		}
 	}
 
-	var ap *gatewayAddress
 	if len(aps) > 0 {
-		ap = &aps[0]
+		return &aps[0]
 	}
 
-	r.log.V(4).Info("getPublicAddrs4Gateway: ready", "gateway", gw.GetName(), "address", ap.String())
+	return nil
+}
 
-	return ap, err
+// getAddrHints4Gateway returns the requested IP addresses and hostnames from
+// Gateway.Spec.Addresses
+func getAddrHints4Gateway(gw *gwapiv1b1.Gateway) []gatewayAddress {
+	ret := []gatewayAddress{}
+	for _, a := range gw.Spec.Addresses {
+		aType := gwapiv1b1.IPAddressType
+		if a.Type != nil {
+			aType = *a.Type
+		}
+
+		if (aType != gwapiv1b1.IPAddressType && aType != gwapiv1b1.HostnameAddressType) ||
+			a.Value == "" {
+			continue
+		}
+
+		ret = append(ret, gatewayAddress{aType: aType, addr: a.Value})
+	}
+
+	return uniqueAddrPorts(ret)
+}
+
+// uniqueAddrPorts removes empty and duplicate addresses, keeping the first occurrence.
+func uniqueAddrPorts(aps []gatewayAddress) []gatewayAddress {
+	ret := []gatewayAddress{}
+	seen := map[string]bool{}
+	for _, ap := range aps {
+		if ap.addr == "" || seen[ap.addr] {
+			continue
+		}
+		seen[ap.addr] = true
+		ret = append(ret, ap)
+	}
+
+	return ret
+}
+
+// getIPFamily returns the IP family of an address, or an empty string if the address is not a
+// valid IP address (e.g., a hostname).
+func getIPFamily(addr string) corev1.IPFamily {
+	ip := net.ParseIP(addr)
+	switch {
+	case ip == nil:
+		return corev1.IPFamily("")
+	case ip.To4() != nil:
+		return corev1.IPv4Protocol
+	default:
+		return corev1.IPv6Protocol
+	}
 }
 
 // we need the namespaced name!
@@ -127,60 +210,58 @@ func (r *Renderer) isServiceAnnotated4Gateway(svc *corev1.Service, gw *gwapiv1b1
 	return false
 }
 
-// for the semantics, see https://github.com/l7mp/stunner-gateway-operator/issues/3
-func (r *Renderer) getPublicAddrPort4Svc(svc *corev1.Service, gw *gwapiv1b1.Gateway, addrHint gatewayAddress) (*gatewayAddress, bool) {
-	var ap *gatewayAddress
+// returns the public addresses requested in the Gateway spec, the LoadBalancer addresses and the
+// NodePort addresses of a service, for the semantics see
+// https://github.com/l7mp/stunner-gateway-operator/issues/3
+func (r *Renderer) getPublicAddrPorts4Svc(svc *corev1.Service, gw *gwapiv1b1.Gateway, addrHints []gatewayAddress) ([]gatewayAddress, []gatewayAddress, []gatewayAddress) {
+	specAps, lbAps, nodeAps := []gatewayAddress{}, []gatewayAddress{}, []gatewayAddress{}
 
 	i, found := r.getServicePort(gw, svc)
+	if !found || i >= len(svc.Spec.Ports) {
+		return specAps, lbAps, nodeAps
+	}
+	svcPort := svc.Spec.Ports[i]
 
-	// The desired selection of public IP should go in the following order: (see
-	// https://github.com/l7mp/stunner-gateway-operator/issues/3)
-	//
-	// 1. Gateway.Spec.Addresses[0] + Gateway.Spec.Listeners[0].Port
-	if found && i < len(svc.Spec.Ports) && addrHint.addr != "" {
-		svcPort := svc.Spec.Ports[i]
-		ap = &gatewayAddress{
-			aType: addrHint.aType,
-			addr:  addrHint.addr,
+	// 1. Gateway.Spec.Addresses + the first matching listener port
+	for _, a := range addrHints {
+		specAps = append(specAps, gatewayAddress{
+			aType: a.aType,
+			addr:  a.addr,
 			port:  int(svcPort.Port),
-		}
-		r.log.V(4).Info("getPublicAddrPort4Svc: using requested address from Gateway spec",
-			"service", store.GetObjectKey(svc), "gateway", store.GetObjectKey(gw), "address", ap.String())
-		return ap, false
+		})
 	}
 
-	// 2. If Address is not set, we use the LoadBalancer IP and the above listener port
-	if found && svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+	// 2. the LoadBalancer IPs and hostnames and the above listener port
+	if svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
 		lbStatus := svc.Status.LoadBalancer
-
-		// we take the first ingress address assigned by the ingress controller
-		if ap := getLBAddrPort4ServicePort(svc, &lbStatus, i); ap != nil {
-			r.log.V(4).Info("getPublicAddrPort4Svc: using LoadBalancer address",
-				"service", store.GetObjectKey(svc), "gateway", store.GetObjectKey(gw),
-				"address", ap.String())
-			return ap, true
+		aps, err := getLBAddrPorts4ServicePort(svc, &lbStatus, i)
+		if err != nil {
+			r.log.Error(err, "cannot obtain load-balancer addresses", "service",
+				store.GetObjectKey(svc), "gateway", store.GetObjectKey(gw))
 		}
+		lbAps = aps
 	}
 
-	// 3. If Address is not set and there is no LoadBalancer IP, we use the first node's IP and
-	// NodePort
-	if found && i < len(svc.Spec.Ports) {
-		svcPort := svc.Spec.Ports[i]
-		addr := getFirstNodeAddr()
-		if svcPort.NodePort > 0 && addr != "" {
-			ap = &gatewayAddress{
-				aType: gwapiv1b1.IPAddressType,
+	// 3. If there is no LoadBalancer address, the first node's IPs and the NodePort
+	if len(lbAps) == 0 && svcPort.NodePort > 0 {
+		for _, addr := range getFirstNodeAddrs() {
+			aType := gwapiv1b1.IPAddressType
+			if getIPFamily(addr) == "" {
+				aType = gwapiv1b1.HostnameAddressType
+			}
+			nodeAps = append(nodeAps, gatewayAddress{
+				aType: aType,
 				addr:  addr,
 				port:  int(svcPort.NodePort),
-			}
-			r.log.V(4).Info("getPublicAddrPort4Svc: using NodePort address",
-				"service", store.GetObjectKey(svc), "gateway", store.GetObjectKey(gw),
-				"address", ap.String())
-			return ap, false
+			})
 		}
 	}
 
-	return nil, false
+	r.log.V(4).Info("getPublicAddrPorts4Svc: ready", "service", store.GetObjectKey(svc),
+		"gateway", store.GetObjectKey(gw), "spec-addresses", fmt.Sprintf("%v", specAps),
+		"lb-addresses", fmt.Sprintf("%v", lbAps), "nodeport-addresses", fmt.Sprintf("%v", nodeAps))
+
+	return specAps, lbAps, nodeAps
 }
 
 func (r *Renderer) createLbService4Gateway(c *RenderContext, gw *gwapiv1b1.Gateway) *corev1.Service {
@@ -296,16 +377,34 @@ func (r *Renderer) createLbService4Gateway(c *RenderContext, gw *gwapiv1b1.Gatew
 		svc.ObjectMeta.Annotations[k] = v
 	}
 
-	// forward the first requested address to Kubernetes
-	if len(gw.Spec.Addresses) > 0 {
-		if gw.Spec.Addresses[0].Type == nil ||
-			(gw.Spec.Addresses[0].Type != nil &&
-				*gw.Spec.Addresses[0].Type == gwapiv1b1.IPAddressType) {
-			// only the first address can be used because
-			// stunner is limited to use a single public address
-			// https://github.com/l7mp/stunner-gateway-operator/issues/32#issuecomment-1648035135
-			svc.Spec.ExternalIPs = []string{gw.Spec.Addresses[0].Value}
-			svc.Spec.LoadBalancerIP = gw.Spec.Addresses[0].Value
+	// set the IP family policy: dual-stack services get both an IPv4 and an IPv6 ClusterIP
+	// and load-balancer address
+	if p, ok := as[opdefault.IPFamilyPolicyAnnotationKey]; ok {
+		switch policy := corev1.IPFamilyPolicy(p); policy {
+		case corev1.IPFamilyPolicySingleStack, corev1.IPFamilyPolicyPreferDualStack,
+			corev1.IPFamilyPolicyRequireDualStack:
+			svc.Spec.IPFamilyPolicy = &policy
+		default:
+			c.log.V(1).Info("createLbService4Gateway: ignoring invalid IP family policy",
+				"gateway", store.GetObjectKey(gw), "policy", p)
+		}
+	}
+
+	// forward the first requested IP address of each IP family to Kubernetes: stunner is
+	// limited to use a single public address per IP family, see
+	// https://github.com/l7mp/stunner-gateway-operator/issues/32#issuecomment-1648035135
+	// requested hostnames are forwarded as the IPs they resolve to, if a resolver is set
+	families := map[corev1.IPFamily]bool{}
+	for _, a := range r.resolveAddrPorts(getAddrHints4Gateway(gw)) {
+		f := getIPFamily(a.addr)
+		if a.aType != gwapiv1b1.IPAddressType || f == "" || families[f] {
+			continue
+		}
+		families[f] = true
+		svc.Spec.ExternalIPs = append(svc.Spec.ExternalIPs, a.addr)
+		// the legacy LoadBalancerIP field supports only a single address
+		if svc.Spec.LoadBalancerIP == "" {
+			svc.Spec.LoadBalancerIP = a.addr
 		}
 	}
 
@@ -368,12 +467,13 @@ func (r *Renderer) getServiceProtocol(proto gwapiv1b1.ProtocolType) (string, err
 	return serviceProto, nil
 }
 
-// first matching service-port and load-balancer service status
-func getLBAddrPort4ServicePort(svc *corev1.Service, st *corev1.LoadBalancerStatus, spIndex int) *gatewayAddress {
+// all matching service-port and load-balancer service status addresses
+func getLBAddrPorts4ServicePort(svc *corev1.Service, st *corev1.LoadBalancerStatus, spIndex int) ([]gatewayAddress, error) {
+	aps := []gatewayAddress{}
+
 	// spIndex must point to a valid service-port
-	if len(svc.Spec.Ports) == 0 || spIndex >= len(svc.Spec.Ports) {
-		fmt.Printf("getLBAddrPort4ServicePort: INTERNAL ERROR: invalid service-port index\n")
-		return nil
+	if spIndex < 0 || spIndex >= len(svc.Spec.Ports) {
+		return aps, fmt.Errorf("invalid service-port index %d", spIndex)
 	}
 
 	proto := svc.Spec.Ports[spIndex].Protocol
@@ -383,40 +483,35 @@ func getLBAddrPort4ServicePort(svc *corev1.Service, st *corev1.LoadBalancerStatu
 		// index i is valid, and the protocol and port match the ones specified for the gateway
 		if len(s.Ports) > 0 && spIndex < len(s.Ports) &&
 			s.Ports[spIndex].Port == port && s.Ports[spIndex].Protocol == proto {
-
-			ap := gatewayAddress{
-				aType: gwapiv1b1.IPAddressType,
-				addr:  s.IP,
-				port:  int(s.Ports[spIndex].Port),
-			}
-			// fallback to Hostname (typically for AWS)
-			if s.IP == "" {
-				ap.aType = gwapiv1b1.HostnameAddressType
-				ap.addr = s.Hostname
-			}
-
-			return &ap
+			aps = append(aps, getLBAddrPort4Ingress(s, int(s.Ports[spIndex].Port)))
 		}
 	}
 
 	// some load-balancer controllers do not include a status.Ingress[x].Ports substatus: we
-	// fall back to the first load-balancer IP we find and use the port from the service-port
-	// as a port
-	if len(st.Ingress) > 0 {
-		ap := gatewayAddress{
-			aType: gwapiv1b1.IPAddressType,
-			addr:  st.Ingress[0].IP,
-			port:  int(port),
-		}
-		// fallback to Hostname (typically for AWS)
-		if ap.addr == "" {
-			ap.aType = gwapiv1b1.HostnameAddressType
-			ap.addr = st.Ingress[0].Hostname
+	// fall back to the load-balancer IPs we find and use the port from the service-port as a
+	// port
+	if len(aps) == 0 {
+		for _, s := range st.Ingress {
+			aps = append(aps, getLBAddrPort4Ingress(s, int(port)))
 		}
-		return &ap
 	}
 
-	return nil
+	return uniqueAddrPorts(aps), nil
+}
+
+func getLBAddrPort4Ingress(s corev1.LoadBalancerIngress, port int) gatewayAddress {
+	ap := gatewayAddress{
+		aType: gwapiv1b1.IPAddressType,
+		addr:  s.IP,
+		port:  port,
+	}
+	// fallback to Hostname (typically for AWS)
+	if s.IP == "" {
+		ap.aType = gwapiv1b1.HostnameAddressType
+		ap.addr = s.Hostname
+	}
+
+	return ap
 }
 
 func setHealthCheck(annotations map[string]string, svc *corev1.Service) (int32, error) {
@@ -470,7 +565,8 @@ func mergeMaps(maps ...map[string]string) map[string]string {
 	return mergedMap
 }
 
-// find the ClusterIP associated with a service
+// find the ClusterIPs associated with a service, this includes the ClusterIP of each IP family for
+// dual-stack services
 func getClusterIP(n types.NamespacedName) ([]string, error) {
 	ret := []string{}
 
@@ -483,7 +579,12 @@ func getClusterIP(n types.NamespacedName) ([]string, error) {
 		return ret, NewNonCriticalError(ClusterIPNotFound)
 	}
 
-	ret = append(ret, s.Spec.ClusterIP)
+	if len(s.Spec.ClusterIPs) == 0 {
+		ret = append(ret, s.Spec.ClusterIP)
+		return ret, nil
+	}
+
+	ret = append(ret, s.Spec.ClusterIPs...)
 
 	return ret, nil
 }
@@ -53,9 +53,10 @@ func TestRenderServiceUtil(t *testing.T) {
 				assert.Len(t, gws, 1, "gateways for class")
 				gw := gws[0]
 
-				addr, err := r.getPublicAddrPort4Gateway(gw)
+				addrs, err := r.getPublicAddrPorts4Gateway(gw)
 				assert.NoError(t, err, "owner ref found")
-				assert.NotNil(t, addr, "public addr-port found")
+				assert.Len(t, addrs, 1, "public addr-port found")
+				addr := addrs[0]
 				assert.Equal(t, "1.2.3.4", addr.addr, "public addr ok")
 				assert.Equal(t, 1, addr.port, "public port ok")
 
@@ -89,9 +90,10 @@ func TestRenderServiceUtil(t *testing.T) {
 				assert.Len(t, gws, 1, "gateways for class")
 				gw := gws[0]
 
-				addr, err := r.getPublicAddrPort4Gateway(gw)
+				addrs, err := r.getPublicAddrPorts4Gateway(gw)
 				assert.NoError(t, err, "owner ref found")
-				assert.NotNil(t, addr, "public hostname found")
+				assert.Len(t, addrs, 1, "public hostname found")
+				addr := addrs[0]
 				assert.Equal(t, 1, addr.port, "public port ok")
 				assert.Equal(t, "dummy-hostname", addr.addr, "public addr ok")
 			},
@@ -124,7 +126,7 @@ func TestRenderServiceUtil(t *testing.T) {
 				assert.Len(t, gws, 1, "gateways for class")
 				gw := gws[0]
 
-				_, err = r.getPublicAddrPort4Gateway(gw)
+				_, err = r.getPublicAddrPorts4Gateway(gw)
 				assert.Error(t, err, "public addr-port found")
 			},
 		},
@@ -155,7 +157,7 @@ func TestRenderServiceUtil(t *testing.T) {
 				assert.Len(t, gws, 1, "gateways for class")
 				gw := gws[0]
 
-				_, err = r.getPublicAddrPort4Gateway(gw)
+				_, err = r.getPublicAddrPorts4Gateway(gw)
 				assert.Error(t, err, "public addr-port found")
 			},
 		},
@@ -189,7 +191,7 @@ func TestRenderServiceUtil(t *testing.T) {
 				assert.Len(t, gws, 1, "gateways for class")
 				gw := gws[0]
 
-				_, err = r.getPublicAddrPort4Gateway(gw)
+				_, err = r.getPublicAddrPorts4Gateway(gw)
 				assert.Error(t, err, "owner ref not found")
 			},
 		},
@@ -220,7 +222,7 @@ func TestRenderServiceUtil(t *testing.T) {
 				assert.Len(t, gws, 1, "gateways for class")
 				gw := gws[0]
 
-				_, err = r.getPublicAddrPort4Gateway(gw)
+				_, err = r.getPublicAddrPorts4Gateway(gw)
 				assert.Error(t, err, "public addr-port found")
 
 			},
@@ -252,7 +254,7 @@ func TestRenderServiceUtil(t *testing.T) {
 				assert.Len(t, gws, 1, "gateways for class")
 				gw := gws[0]
 
-				_, err = r.getPublicAddrPort4Gateway(gw)
+				_, err = r.getPublicAddrPorts4Gateway(gw)
 				assert.Error(t, err, "public addr-port found")
 			},
 		},
@@ -288,9 +290,10 @@ func TestRenderServiceUtil(t *testing.T) {
 				assert.Len(t, gws, 1, "gateways for class")
 				gw := gws[0]
 
-				addr, err := r.getPublicAddrPort4Gateway(gw)
+				addrs, err := r.getPublicAddrPorts4Gateway(gw)
 				assert.NoError(t, err, "owner ref found")
-				assert.NotNil(t, addr, "public addr-port found")
+				assert.Len(t, addrs, 1, "public addr-port found")
+				addr := addrs[0]
 				assert.Equal(t, "1.2.3.4", addr.addr, "public addr ok")
 				assert.Equal(t, 1, addr.port, "public port ok")
 			},
@@ -346,9 +349,10 @@ func TestRenderServiceUtil(t *testing.T) {
 				assert.Len(t, gws, 1, "gateways for class")
 				gw := gws[0]
 
-				addr, err := r.getPublicAddrPort4Gateway(gw)
+				addrs, err := r.getPublicAddrPorts4Gateway(gw)
 				assert.NoError(t, err, "owner ref found")
-				assert.NotNil(t, addr, "public addr-port found")
+				assert.Len(t, addrs, 1, "public addr-port found")
+				addr := addrs[0]
 				assert.Equal(t, "5.6.7.8", addr.addr, "public addr ok")
 				assert.Equal(t, 2, addr.port, "public port ok")
 			},
@@ -384,9 +388,10 @@ func TestRenderServiceUtil(t *testing.T) {
 				assert.NoError(t, controllerutil.SetOwnerReference(gw, svc, r.scheme), "set-owner")
 				store.Services.Upsert(svc)
 
-				addr, err := r.getPublicAddrPort4Gateway(gw)
+				addrs, err := r.getPublicAddrPorts4Gateway(gw)
 				assert.NoError(t, err, "owner ref found")
-				assert.NotNil(t, addr, "public addr-port found")
+				assert.Len(t, addrs, 1, "public addr-port found")
+				addr := addrs[0]
 				assert.Equal(t, "1.2.3.4", addr.addr, "public addr ok")
 				assert.Equal(t, 1, addr.port, "public port ok")
 			},
@@ -1584,5 +1589,297 @@ func TestRenderServiceUtil(t *testing.T) {
 				assert.Equal(t, s.Spec.ExternalIPs[0], "1.1.1.1", "svc externalips")
 			},
 		},
+		{
+			name: "dual-stack public-ip ok",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{},
+			svcs: []corev1.Service{testutils.TestSvcDualStack},
+			prep: func(c *renderTestConfig) {
+				s := testutils.TestSvcDualStack.DeepCopy()
+				s.SetOwnerReferences([]metav1.OwnerReference{{
+					APIVersion: gwapiv1b1.GroupVersion.String(),
+					Kind:       "Gateway",
+					UID:        testutils.TestGw.GetUID(),
+					Name:       testutils.TestGw.GetName(),
+				}})
+				c.svcs = []corev1.Service{*s}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, log: logr.Discard()}
+				c.gwConf, err = r.getGatewayConfig4Class(c)
+				assert.NoError(t, err, "gw-conf found")
+
+				gws := r.getGateways4Class(c)
+				assert.Len(t, gws, 1, "gateways for class")
+				gw := gws[0]
+
+				addrs, err := r.getPublicAddrPorts4Gateway(gw)
+				assert.NoError(t, err, "owner ref found")
+				assert.Len(t, addrs, 2, "public addr-ports found")
+				assert.Equal(t, "1.2.3.4", addrs[0].addr, "public IPv4 addr ok")
+				assert.Equal(t, 1, addrs[0].port, "public IPv4 port ok")
+				assert.Equal(t, "2001:db8::1", addrs[1].addr, "public IPv6 addr ok")
+				assert.Equal(t, 1, addrs[1].port, "public IPv6 port ok")
+			},
+		},
+		{
+			name: "dual-stack public-ip with IPv6 primary ok",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{},
+			svcs: []corev1.Service{testutils.TestSvcDualStack},
+			prep: func(c *renderTestConfig) {
+				s := testutils.TestSvcDualStack.DeepCopy()
+				s.SetOwnerReferences([]metav1.OwnerReference{{
+					APIVersion: gwapiv1b1.GroupVersion.String(),
+					Kind:       "Gateway",
+					UID:        testutils.TestGw.GetUID(),
+					Name:       testutils.TestGw.GetName(),
+				}})
+				s.Spec.IPFamilies = []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol}
+				c.svcs = []corev1.Service{*s}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, log: logr.Discard()}
+				c.gwConf, err = r.getGatewayConfig4Class(c)
+				assert.NoError(t, err, "gw-conf found")
+
+				gws := r.getGateways4Class(c)
+				assert.Len(t, gws, 1, "gateways for class")
+				gw := gws[0]
+
+				addrs, err := r.getPublicAddrPorts4Gateway(gw)
+				assert.NoError(t, err, "owner ref found")
+				assert.Len(t, addrs, 2, "public addr-ports found")
+				assert.Equal(t, "1.2.3.4", addrs[0].addr, "public IPv4 addr ok")
+				assert.Equal(t, "2001:db8::1", addrs[1].addr, "public IPv6 addr ok")
+
+				// listener uses the address of the primary IP family
+				assert.Equal(t, corev1.IPv6Protocol, r.getPrimaryIPFamily4Gateway(gw), "primary")
+				ap := getListenerAddrPort(addrs, r.getPrimaryIPFamily4Gateway(gw))
+				assert.NotNil(t, ap, "listener addr")
+				assert.Equal(t, "2001:db8::1", ap.addr, "listener addr ok")
+			},
+		},
+		{
+			name:  "dual-stack nodeport IP ok",
+			cls:   []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:   []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:   []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:    []gwapiv1a2.UDPRoute{},
+			nodes: []corev1.Node{testutils.TestNodeDualStack},
+			svcs:  []corev1.Service{testutils.TestSvcDualStack},
+			prep: func(c *renderTestConfig) {
+				s := testutils.TestSvcDualStack.DeepCopy()
+				s.SetOwnerReferences([]metav1.OwnerReference{{
+					APIVersion: gwapiv1b1.GroupVersion.String(),
+					Kind:       "Gateway",
+					UID:        testutils.TestGw.GetUID(),
+					Name:       testutils.TestGw.GetName(),
+				}})
+				s.Spec.Type = corev1.ServiceTypeNodePort
+				s.Status = corev1.ServiceStatus{}
+				c.svcs = []corev1.Service{*s}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, log: logr.Discard()}
+				c.gwConf, err = r.getGatewayConfig4Class(c)
+				assert.NoError(t, err, "gw-conf found")
+
+				gws := r.getGateways4Class(c)
+				assert.Len(t, gws, 1, "gateways for class")
+				gw := gws[0]
+
+				addrs, err := r.getPublicAddrPorts4Gateway(gw)
+				assert.NoError(t, err, "owner ref found")
+				assert.Len(t, addrs, 2, "public addr-ports found")
+				assert.Equal(t, "1.2.3.4", addrs[0].addr, "public IPv4 addr ok")
+				assert.Equal(t, 30001, addrs[0].port, "public IPv4 port ok")
+				assert.Equal(t, "2001:db8::4", addrs[1].addr, "public IPv6 addr ok")
+				assert.Equal(t, 30001, addrs[1].port, "public IPv6 port ok")
+			},
+		},
+		{
+			name: "lb service - ip family policy from gw annotation",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{},
+			svcs: []corev1.Service{testutils.TestSvc},
+			prep: func(c *renderTestConfig) {
+				gw := testutils.TestGw.DeepCopy()
+				ann := make(map[string]string)
+				ann[opdefault.IPFamilyPolicyAnnotationKey] = "RequireDualStack"
+				gw.SetAnnotations(ann)
+				c.gws = []gwapiv1b1.Gateway{*gw}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, log: logr.Discard()}
+				c.gwConf, err = r.getGatewayConfig4Class(c)
+				assert.NoError(t, err, "gw-conf found")
+
+				gws := r.getGateways4Class(c)
+				assert.Len(t, gws, 1, "gateways for class")
+				gw := gws[0]
+
+				s := r.createLbService4Gateway(c, gw)
+				assert.NotNil(t, s, "svc create")
+				assert.NotNil(t, s.Spec.IPFamilyPolicy, "ip family policy")
+				assert.Equal(t, corev1.IPFamilyPolicyRequireDualStack, *s.Spec.IPFamilyPolicy,
+					"ip family policy")
+
+				// invalid policy is ignored
+				gw.SetAnnotations(map[string]string{
+					opdefault.IPFamilyPolicyAnnotationKey: "dummy",
+				})
+				s = r.createLbService4Gateway(c, gw)
+				assert.NotNil(t, s, "svc create")
+				assert.Nil(t, s.Spec.IPFamilyPolicy, "no ip family policy")
+			},
+		},
+		{
+			name: "multiple public address hints in Gateway Spec",
+			cls:  []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:  []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:  []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:   []gwapiv1a2.UDPRoute{},
+			svcs: []corev1.Service{testutils.TestSvcDualStack},
+			prep: func(c *renderTestConfig) {
+				gw := testutils.TestGw.DeepCopy()
+				at := gwapiv1b1.IPAddressType
+				gw.Spec.Addresses = []gwapiv1b1.GatewayAddress{
+					{Type: &at, Value: "2001:db8::2"},
+					{Type: &at, Value: "1.1.1.1"},
+					{Type: &at, Value: "1.2.3.4"},
+				}
+				c.gws = []gwapiv1b1.Gateway{*gw}
+				s := testutils.TestSvcDualStack.DeepCopy()
+				s.SetOwnerReferences([]metav1.OwnerReference{{
+					APIVersion: gwapiv1b1.GroupVersion.String(),
+					Kind:       "Gateway",
+					UID:        testutils.TestGw.GetUID(),
+					Name:       testutils.TestGw.GetName(),
+				}})
+				c.svcs = []corev1.Service{*s}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, log: logr.Discard()}
+				c.gwConf, err = r.getGatewayConfig4Class(c)
+				assert.NoError(t, err, "gw-conf found")
+
+				gws := r.getGateways4Class(c)
+				assert.Len(t, gws, 1, "gateways for class")
+				gw := gws[0]
+
+				s := r.createLbService4Gateway(c, gw)
+				assert.NotNil(t, s, "svc create")
+				assert.Equal(t, "2001:db8::2", s.Spec.LoadBalancerIP, "svc loadbalancerip")
+				assert.Equal(t, []string{"2001:db8::2", "1.1.1.1"}, s.Spec.ExternalIPs,
+					"svc externalips")
+
+				// spec addresses first, then the LB addresses, without duplicates
+				addrs, err := r.getPublicAddrPorts4Gateway(gw)
+				assert.NoError(t, err, "owner ref found")
+				assert.Len(t, addrs, 4, "public addr-ports found")
+				assert.Equal(t, "2001:db8::2", addrs[0].addr, "spec IPv6 addr ok")
+				assert.Equal(t, "1.1.1.1", addrs[1].addr, "spec IPv4 addr ok")
+				assert.Equal(t, "1.2.3.4", addrs[2].addr, "spec IPv4 addr ok")
+				assert.Equal(t, "2001:db8::1", addrs[3].addr, "LB IPv6 addr ok")
+
+				// listener uses the first address of the primary IP family
+				ap := getListenerAddrPort(addrs, r.getPrimaryIPFamily4Gateway(gw))
+				assert.NotNil(t, ap, "listener addr")
+				assert.Equal(t, "1.1.1.1", ap.addr, "listener addr ok")
+			},
+		},
+		{
+			name: "dual-stack cluster IPs",
+			svcs: []corev1.Service{testutils.TestSvcDualStack},
+			prep: func(c *renderTestConfig) {},
+			tester: func(t *testing.T, r *Renderer) {
+				ips, err := getClusterIP(store.GetNamespacedName(&testutils.TestSvcDualStack))
+				assert.NoError(t, err, "cluster IPs found")
+				assert.Equal(t, []string{"10.0.0.1", "fd00::1"}, ips, "cluster IPs ok")
+			},
+		},
+		{
+			name:  "all load-balancer addresses published",
+			cls:   []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:   []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:   []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:    []gwapiv1a2.UDPRoute{},
+			nodes: []corev1.Node{testutils.TestNode},
+			svcs:  []corev1.Service{testutils.TestSvc},
+			prep: func(c *renderTestConfig) {
+				s := testutils.TestSvc.DeepCopy()
+				s.SetOwnerReferences([]metav1.OwnerReference{{
+					APIVersion: gwapiv1b1.GroupVersion.String(),
+					Kind:       "Gateway",
+					UID:        testutils.TestGw.GetUID(),
+					Name:       testutils.TestGw.GetName(),
+				}})
+				s.Spec.Ports[0].NodePort = 30001
+				ps := []corev1.PortStatus{{Port: 1, Protocol: corev1.ProtocolUDP}}
+				s.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{
+					{IP: "1.2.3.4", Ports: ps},
+					{Hostname: "dummy-hostname", Ports: ps},
+					{IP: "5.6.7.8", Ports: ps},
+					{IP: "1.2.3.4", Ports: ps},
+				}
+				c.svcs = []corev1.Service{*s}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, log: logr.Discard()}
+
+				gws := r.getGateways4Class(c)
+				assert.Len(t, gws, 1, "gateways for class")
+				gw := gws[0]
+
+				// NodePort addresses are not used when there is a LB address
+				addrs, err := r.getPublicAddrPorts4Gateway(gw)
+				assert.NoError(t, err, "owner ref found")
+				assert.Len(t, addrs, 3, "public addr-ports found")
+				assert.Equal(t, gatewayAddress{aType: gwapiv1b1.IPAddressType, addr: "1.2.3.4", port: 1},
+					addrs[0], "LB addr-1 ok")
+				assert.Equal(t, gatewayAddress{aType: gwapiv1b1.HostnameAddressType, addr: "dummy-hostname", port: 1},
+					addrs[1], "LB addr-2 ok")
+				assert.Equal(t, gatewayAddress{aType: gwapiv1b1.IPAddressType, addr: "5.6.7.8", port: 1},
+					addrs[2], "LB addr-3 ok")
+
+				ap := getListenerAddrPort(addrs, r.getPrimaryIPFamily4Gateway(gw))
+				assert.NotNil(t, ap, "listener addr")
+				assert.Equal(t, "1.2.3.4", ap.addr, "listener addr ok")
+			},
+		},
 	})
 }
+
+func TestRenderLBAddrPorts4ServicePort(t *testing.T) {
+	svc := testutils.TestSvc.DeepCopy()
+	svc.Spec.Ports = []corev1.ServicePort{{Protocol: corev1.ProtocolUDP, Port: 1}}
+	st := &corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}}}
+
+	aps, err := getLBAddrPorts4ServicePort(svc, st, 0)
+	assert.NoError(t, err, "valid service-port index")
+	assert.Len(t, aps, 1, "addresses")
+	assert.Equal(t, "1.2.3.4", aps[0].addr, "address")
+	assert.Equal(t, 1, aps[0].port, "port")
+
+	_, err = getLBAddrPorts4ServicePort(svc, st, 1)
+	assert.Error(t, err, "invalid service-port index")
+}
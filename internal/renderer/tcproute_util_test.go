@@ -0,0 +1,178 @@
+package renderer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	meta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	stnrconfv1a1 "github.com/l7mp/stunner/pkg/apis/v1alpha1"
+
+	stnrv1a1 "github.com/l7mp/stunner-gateway-operator/api/v1alpha1"
+	"github.com/l7mp/stunner-gateway-operator/internal/config"
+	"github.com/l7mp/stunner-gateway-operator/internal/event"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+	"github.com/l7mp/stunner-gateway-operator/internal/testutils"
+	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
+)
+
+func TestRenderTCPRouteUtil(t *testing.T) {
+	renderTester(t, []renderTestConfig{
+		{
+			name:  "tcp route attaches to tcp listener only",
+			cls:   []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:   []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:   []gwapiv1b1.Gateway{testutils.TestGw},
+			rs:    []gwapiv1a2.UDPRoute{testutils.TestUDPRoute},
+			tcprs: []gwapiv1a2.TCPRoute{testutils.TestTCPRoute},
+			svcs:  []corev1.Service{testutils.TestSvc},
+			prep: func(c *renderTestConfig) {
+				// attach to all listeners
+				tcp := testutils.TestTCPRoute.DeepCopy()
+				tcp.Spec.ParentRefs[0].SectionName = nil
+				c.tcprs = []gwapiv1a2.TCPRoute{*tcp}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, log: logr.Discard()}
+
+				gws := r.getGateways4Class(c)
+				assert.Len(t, gws, 1, "gw found")
+				gw := gws[0]
+
+				// udp listener
+				l := gw.Spec.Listeners[0]
+				assert.Len(t, r.getTCPRoutes4Listener(gw, &l), 0, "no tcp route on udp listener")
+				rs := r.getRoutes4Listener(gw, &l)
+				assert.Len(t, rs, 1, "route found")
+				assert.Equal(t, fmt.Sprintf("%s/%s", testutils.TestNsName, "udproute-ok"),
+					store.GetObjectKey(rs[0]), "udp route name found")
+
+				// tcp listener
+				l = gw.Spec.Listeners[2]
+				assert.Len(t, r.getUDPRoutes4Listener(gw, &l), 0, "no udp route on tcp listener")
+				trs := r.getTCPRoutes4Listener(gw, &l)
+				assert.Len(t, trs, 1, "tcp route found")
+				assert.Equal(t, fmt.Sprintf("%s/%s", testutils.TestNsName, "tcproute-ok"),
+					store.GetObjectKey(trs[0]), "tcp route name found")
+
+				rs = r.getRoutes4Listener(gw, &l)
+				assert.Len(t, rs, 1, "route found")
+			},
+		},
+		{
+			name:  "tcp route rejected by allowed kinds",
+			cls:   []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:   []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:   []gwapiv1b1.Gateway{testutils.TestGw},
+			tcprs: []gwapiv1a2.TCPRoute{testutils.TestTCPRoute},
+			svcs:  []corev1.Service{testutils.TestSvc},
+			prep: func(c *renderTestConfig) {
+				gw := testutils.TestGw.DeepCopy()
+				gw.Spec.Listeners[2].AllowedRoutes = &gwapiv1b1.AllowedRoutes{
+					Kinds: []gwapiv1b1.RouteGroupKind{{Kind: "UDPRoute"}},
+				}
+				c.gws = []gwapiv1b1.Gateway{*gw}
+			},
+			tester: func(t *testing.T, r *Renderer) {
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, log: logr.Discard()}
+
+				gws := r.getGateways4Class(c)
+				assert.Len(t, gws, 1, "gw found")
+				gw := gws[0]
+
+				l := gw.Spec.Listeners[2]
+				assert.Len(t, r.getTCPRoutes4Listener(gw, &l), 0, "tcp route rejected")
+
+				ro := store.TCPRoutes.GetAll()[0]
+				p := ro.Spec.ParentRefs[0]
+				assert.False(t, r.isParentAcceptingRoute(ro, &p, gc.GetName()), "parent rejects")
+			},
+		},
+		{
+			name:  "tcp route cluster and status",
+			cls:   []gwapiv1b1.GatewayClass{testutils.TestGwClass},
+			cfs:   []stnrv1a1.GatewayConfig{testutils.TestGwConfig},
+			gws:   []gwapiv1b1.Gateway{testutils.TestGw},
+			tcprs: []gwapiv1a2.TCPRoute{testutils.TestTCPRoute},
+			svcs:  []corev1.Service{testutils.TestSvc},
+			eps:   []discoveryv1.EndpointSlice{testutils.TestEndpointSlice},
+			prep:  func(c *renderTestConfig) {},
+			tester: func(t *testing.T, r *Renderer) {
+				config.EnableEndpointDiscovery = true
+				config.EnableRelayToClusterIP = false
+
+				gc, err := r.getGatewayClass()
+				assert.NoError(t, err, "gw-class found")
+				c := &RenderContext{gc: gc, gws: store.NewGatewayStore(), log: logr.Discard()}
+				c.gwConf, err = r.getGatewayConfig4Class(c)
+				assert.NoError(t, err, "gw-conf found")
+				c.update = event.NewEventUpdate(0)
+				c.gws.ResetGateways(r.getGateways4Class(c))
+
+				ro := store.TCPRoutes.GetAll()[0]
+				rc, err := r.renderCluster(ro)
+				assert.NoError(t, err, "cluster rendered")
+				assert.Equal(t, "testnamespace/tcproute-ok", rc.Name, "cluster name")
+				assert.Equal(t, stnrconfv1a1.ClusterTypeStatic.String(), rc.Type, "cluster type")
+				assert.Equal(t, stnrconfv1a1.ClusterProtocolTCP.String(), rc.Protocol, "cluster protocol")
+				assert.Len(t, rc.Endpoints, 4, "endpoints len")
+
+				err = r.renderForGateways(c)
+				assert.NoError(t, err, "render success")
+
+				// config
+				cms := c.update.UpsertQueue.ConfigMaps.Objects()
+				assert.Len(t, cms, 1, "configmap ready")
+				cm, ok := cms[0].(*corev1.ConfigMap)
+				assert.True(t, ok, "configmap cast")
+				conf, err := store.UnpackConfigMap(cm)
+				assert.NoError(t, err, "configmap stunner-config unmarshal")
+
+				assert.Len(t, conf.Listeners, 2, "listener num")
+				lc := conf.Listeners[1]
+				assert.Equal(t, "TURN-TCP", lc.Protocol, "tcp listener")
+				assert.Equal(t, []string{"testnamespace/tcproute-ok"}, lc.Routes, "tcp listener routes")
+
+				assert.Len(t, conf.Clusters, 1, "cluster num")
+				assert.Equal(t, "TCP", conf.Clusters[0].Protocol, "cluster protocol")
+
+				// gateway listener status
+				gws := c.update.UpsertQueue.Gateways.GetAll()
+				assert.Len(t, gws, 1, "gateway num")
+				assert.Len(t, gws[0].Status.Listeners[0].SupportedKinds, 1, "udp listener kinds")
+				assert.Len(t, gws[0].Status.Listeners[2].SupportedKinds, 2, "tcp listener kinds")
+
+				// route status
+				assert.Equal(t, 0, c.update.UpsertQueue.UDPRoutes.Len(), "udp route num")
+				trs := c.update.UpsertQueue.TCPRoutes.GetAll()
+				assert.Len(t, trs, 1, "tcp route num")
+				ro = trs[0]
+				assert.Len(t, ro.Status.Parents, 1, "parent status len")
+				d := meta.FindStatusCondition(ro.Status.Parents[0].Conditions,
+					string(gwapiv1b1.RouteConditionAccepted))
+				assert.NotNil(t, d, "accepted cond found")
+				assert.Equal(t, metav1.ConditionTrue, d.Status, "accepted status")
+				d = meta.FindStatusCondition(ro.Status.Parents[0].Conditions,
+					string(gwapiv1b1.RouteConditionResolvedRefs))
+				assert.NotNil(t, d, "resolved-refs cond found")
+				assert.Equal(t, metav1.ConditionTrue, d.Status, "resolved-refs status")
+
+				config.EnableEndpointDiscovery = opdefault.DefaultEnableEndpointDiscovery
+				config.EnableRelayToClusterIP = opdefault.DefaultEnableRelayToClusterIP
+			},
+		},
+	})
+}
@@ -11,6 +11,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
@@ -18,6 +19,9 @@ import (
 	"github.com/l7mp/stunner-gateway-operator/internal/store"
 )
 
+// maxConditionMessageLen is the maximum length of a condition message
+const maxConditionMessageLen = 32768
+
 func (r *Renderer) getUDPRoutes4Listener(gw *gwapiv1b1.Gateway, l *gwapiv1b1.Listener) []*gwapiv1a2.UDPRoute {
 	r.log.V(4).Info("getUDPRoutes4Listener", "gateway", store.GetObjectKey(gw), "listener",
 		l.Name)
@@ -57,7 +61,7 @@ func (r *Renderer) getUDPRoutes4Listener(gw *gwapiv1b1.Gateway, l *gwapiv1b1.Lis
 	return ret
 }
 
-func resolveParentRef(ro *gwapiv1a2.UDPRoute, p *gwapiv1b1.ParentReference, gw *gwapiv1b1.Gateway, l *gwapiv1b1.Listener) (bool, string) {
+func resolveParentRef(ro client.Object, p *gwapiv1b1.ParentReference, gw *gwapiv1b1.Gateway, l *gwapiv1b1.Listener) (bool, string) {
 	if p.Group != nil && *p.Group != gwapiv1b1.Group(gwapiv1b1.GroupVersion.Group) {
 		return false, fmt.Sprintf("parent group %q does not match gateway group %q",
 			string(*p.Group), gwapiv1b1.GroupVersion.Group)
@@ -81,6 +85,11 @@ func resolveParentRef(ro *gwapiv1a2.UDPRoute, p *gwapiv1b1.ParentReference, gw *
 		return false, fmt.Sprintf("parent name %q does not match gateway name %q",
 			string(p.Name), gw.GetName())
 	}
+
+	if !listenerAllowsRouteKind(ro, l) {
+		return false, fmt.Sprintf("listener %q does not allow route kind %q",
+			l.Name, getRouteKind(ro))
+	}
 	allowed, msg := gatewayAllowsNamespace(ro, gw, l)
 	if !allowed {
 		return false, msg
@@ -94,7 +103,7 @@ func resolveParentRef(ro *gwapiv1a2.UDPRoute, p *gwapiv1b1.ParentReference, gw *
 	return true, ""
 }
 
-func gatewayAllowsNamespace(ro *gwapiv1a2.UDPRoute, gw *gwapiv1b1.Gateway, l *gwapiv1b1.Listener) (bool, string) {
+func gatewayAllowsNamespace(ro client.Object, gw *gwapiv1b1.Gateway, l *gwapiv1b1.Listener) (bool, string) {
 	// default namespace attachment policy: Same
 	if l.AllowedRoutes == nil || l.AllowedRoutes.Namespaces == nil || l.AllowedRoutes.Namespaces.From == nil {
 		return gatewayAllowsSameNamespace(ro, gw)
@@ -120,7 +129,7 @@ func gatewayAllowsNamespace(ro *gwapiv1a2.UDPRoute, gw *gwapiv1b1.Gateway, l *gw
 		namespace := store.Namespaces.GetObject(ns)
 		if namespace == nil {
 			return false, fmt.Sprintf("parent %s (namespace attachment policy: Selector): cannot "+
-				"find namespace %q for udproute %q in local storage", store.GetObjectKey(gw),
+				"find namespace %q for route %q in local storage", store.GetObjectKey(gw),
 				store.GetObjectKey(ro), ns.String())
 		}
 		res := selector.Matches(labels.Set(namespace.Labels))
@@ -135,7 +144,7 @@ func gatewayAllowsNamespace(ro *gwapiv1a2.UDPRoute, gw *gwapiv1b1.Gateway, l *gw
 	}
 }
 
-func gatewayAllowsSameNamespace(ro *gwapiv1a2.UDPRoute, gw *gwapiv1b1.Gateway) (bool, string) {
+func gatewayAllowsSameNamespace(ro client.Object, gw *gwapiv1b1.Gateway) (bool, string) {
 	allowed := gw.GetNamespace() == ro.GetNamespace()
 	if !allowed {
 		return false, fmt.Sprintf("parent %q/%q (namespace attachment policy: Same) rejects route %q/%q",
@@ -144,16 +153,17 @@ func gatewayAllowsSameNamespace(ro *gwapiv1a2.UDPRoute, gw *gwapiv1b1.Gateway) (
 	return true, ""
 }
 
-func initRouteStatus(ro *gwapiv1a2.UDPRoute) {
-	ro.Status.Parents = []gwapiv1b1.RouteParentStatus{}
+func initRouteStatus(ro client.Object) {
+	getRouteStatus(ro).Parents = []gwapiv1b1.RouteParentStatus{}
 }
 
 // isParentController returns true if at least one of the parents of the route is controlled by us
-func (r *Renderer) isRouteControlled(ro *gwapiv1a2.UDPRoute) bool {
+func (r *Renderer) isRouteControlled(ro client.Object) bool {
 	gcs := r.getGatewayClasses()
 
-	for i := range ro.Spec.ParentRefs {
-		p := &ro.Spec.ParentRefs[i]
+	ps := getRouteParentRefs(ro)
+	for i := range ps {
+		p := &ps[i]
 
 		// obtain the parent gw
 		gw := r.getParentGateway(ro, p)
@@ -183,7 +193,7 @@ func (r *Renderer) isRouteControlled(ro *gwapiv1a2.UDPRoute) bool {
 
 // isParentOutContext returns true if (1) the parent exists and (2) it is NOT included in the
 // gateway context being processed (in which case we do not generate a status for the parent)
-func (r *Renderer) isParentOutContext(gws *store.GatewayStore, ro *gwapiv1a2.UDPRoute, p *gwapiv1b1.ParentReference) bool {
+func (r *Renderer) isParentOutContext(gws *store.GatewayStore, ro client.Object, p *gwapiv1b1.ParentReference) bool {
 	// find the corresponding gateway
 	ns := ro.GetNamespace()
 	if p.Namespace != nil {
@@ -201,7 +211,7 @@ func (r *Renderer) isParentOutContext(gws *store.GatewayStore, ro *gwapiv1a2.UDP
 
 // className == "" means "do not consider classness of parent", this is useful for generating a
 // route status that is consistent across rendering contexts
-func (r *Renderer) isParentAcceptingRoute(ro *gwapiv1a2.UDPRoute, p *gwapiv1b1.ParentReference, className string) bool {
+func (r *Renderer) isParentAcceptingRoute(ro client.Object, p *gwapiv1b1.ParentReference, className string) bool {
 	// r.log.V(4).Info("isParentAcceptingRoute", "route", store.GetObjectKey(ro),
 	// 	"parent", dumpParentRef(p))
 
@@ -246,7 +256,7 @@ func (r *Renderer) isParentAcceptingRoute(ro *gwapiv1a2.UDPRoute, p *gwapiv1b1.P
 	return false
 }
 
-func (r *Renderer) getParentGateway(ro *gwapiv1a2.UDPRoute, p *gwapiv1b1.ParentReference) *gwapiv1b1.Gateway {
+func (r *Renderer) getParentGateway(ro client.Object, p *gwapiv1b1.ParentReference) *gwapiv1b1.Gateway {
 	// find the corresponding gateway
 	ns := ro.GetNamespace()
 	if p.Namespace != nil {
@@ -257,7 +267,7 @@ func (r *Renderer) getParentGateway(ro *gwapiv1a2.UDPRoute, p *gwapiv1b1.ParentR
 	return store.Gateways.GetObject(namespacedName)
 }
 
-func setRouteConditionStatus(ro *gwapiv1a2.UDPRoute, p *gwapiv1b1.ParentReference, controllerName string, accepted bool, backendErr error) {
+func setRouteConditionStatus(ro client.Object, p *gwapiv1b1.ParentReference, controllerName string, accepted bool, backendErr error) {
 	// ns := gwapiv1b1.Namespace(ro.GetNamespace())
 	// gr := gwapiv1b1.Group(gwapiv1b1.GroupVersion.Group)
 	// kind := gwapiv1b1.Kind("Gateway")
@@ -294,7 +304,7 @@ func setRouteConditionStatus(ro *gwapiv1a2.UDPRoute, p *gwapiv1b1.ParentReferenc
 		acceptCond = metav1.Condition{
 			Type:               string(gwapiv1b1.RouteConditionAccepted),
 			Status:             metav1.ConditionTrue,
-			ObservedGeneration: ro.Generation,
+			ObservedGeneration: ro.GetGeneration(),
 			LastTransitionTime: metav1.Now(),
 			Reason:             string(gwapiv1b1.RouteReasonAccepted),
 			Message:            "parent accepts the route",
@@ -303,7 +313,7 @@ func setRouteConditionStatus(ro *gwapiv1a2.UDPRoute, p *gwapiv1b1.ParentReferenc
 		acceptCond = metav1.Condition{
 			Type:               string(gwapiv1b1.RouteConditionAccepted),
 			Status:             metav1.ConditionFalse,
-			ObservedGeneration: ro.Generation,
+			ObservedGeneration: ro.GetGeneration(),
 			LastTransitionTime: metav1.Now(),
 			Reason:             string(gwapiv1b1.RouteReasonNotAllowedByListeners),
 			Message:            "parent rejects the route",
@@ -320,13 +330,18 @@ func setRouteConditionStatus(ro *gwapiv1a2.UDPRoute, p *gwapiv1b1.ParentReferenc
 			// one of the Route's rules has a reference to an unknown or unsupported
 			// Group and/or Kind.
 			reason = gwapiv1b1.RouteReasonInvalidKind
+		case IsNonCriticalError(backendErr, RefNotPermitted):
+			// "RouteReasonRefNotPermitted" is used with the "ResolvedRefs" condition
+			// when one of the Route's rules has a cross-namespace backend reference
+			// that is not permitted by any ReferenceGrant.
+			reason = gwapiv1b1.RouteReasonRefNotPermitted
 		default:
 			reason = gwapiv1b1.RouteReasonBackendNotFound
 		}
 		resolvedCond = metav1.Condition{
 			Type:               string(gwapiv1b1.RouteConditionResolvedRefs),
 			Status:             metav1.ConditionFalse,
-			ObservedGeneration: ro.Generation,
+			ObservedGeneration: ro.GetGeneration(),
 			LastTransitionTime: metav1.Now(),
 			Reason:             string(reason),
 			Message:            "at least one backend reference failed to be successfully resolved",
@@ -335,7 +350,7 @@ func setRouteConditionStatus(ro *gwapiv1a2.UDPRoute, p *gwapiv1b1.ParentReferenc
 		resolvedCond = metav1.Condition{
 			Type:               string(gwapiv1b1.RouteConditionResolvedRefs),
 			Status:             metav1.ConditionTrue,
-			ObservedGeneration: ro.Generation,
+			ObservedGeneration: ro.GetGeneration(),
 			LastTransitionTime: metav1.Now(),
 			Reason:             string(gwapiv1b1.RouteReasonResolvedRefs),
 			Message:            "all backend references successfully resolved",
@@ -344,7 +359,30 @@ func setRouteConditionStatus(ro *gwapiv1a2.UDPRoute, p *gwapiv1b1.ParentReferenc
 
 	meta.SetStatusCondition(&s.Conditions, resolvedCond)
 
-	ro.Status.Parents = append(ro.Status.Parents, s)
+	st := getRouteStatus(ro)
+	st.Parents = append(st.Parents, s)
+}
+
+// setRouteRuleStatus adds the per-rule backend status to the ResolvedRefs condition of each parent
+func setRouteRuleStatus(ro client.Object, rules []ruleStatus) {
+	if len(rules) == 0 {
+		return
+	}
+
+	st := getRouteStatus(ro)
+	for i := range st.Parents {
+		cond := meta.FindStatusCondition(st.Parents[i].Conditions,
+			string(gwapiv1b1.RouteConditionResolvedRefs))
+		if cond == nil {
+			continue
+		}
+
+		msg := fmt.Sprintf("%s: %s", cond.Message, dumpRuleStatus(rules))
+		if len(msg) > maxConditionMessageLen {
+			msg = msg[:maxConditionMessageLen-3] + "..."
+		}
+		cond.Message = msg
+	}
 }
 
 func dumpParentRef(p *gwapiv1b1.ParentReference) string {
@@ -62,3 +62,16 @@ func GetExternalAddress(n *corev1.Node) string {
 
 	return ""
 }
+
+// GetExternalAddresses returns all the external IP and DNS addresses of a node, e.g., both the
+// IPv4 and the IPv6 address of a dual-stack node
+func GetExternalAddresses(n *corev1.Node) []string {
+	ret := []string{}
+	for _, a := range n.Status.Addresses {
+		if a.Type == corev1.NodeExternalIP || a.Type == corev1.NodeExternalDNS {
+			ret = append(ret, a.Address)
+		}
+	}
+
+	return ret
+}
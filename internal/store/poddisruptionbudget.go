@@ -0,0 +1,53 @@
+package store
+
+import (
+	policyv1 "k8s.io/api/policy/v1"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var PodDisruptionBudgets = NewPodDisruptionBudgetStore()
+
+type PodDisruptionBudgetStore struct {
+	Store
+}
+
+func NewPodDisruptionBudgetStore() *PodDisruptionBudgetStore {
+	return &PodDisruptionBudgetStore{
+		Store: NewStore(),
+	}
+}
+
+// GetAll returns all PodDisruptionBudget objects from the global storage
+func (s *PodDisruptionBudgetStore) GetAll() []*policyv1.PodDisruptionBudget {
+	ret := make([]*policyv1.PodDisruptionBudget, 0)
+
+	objects := s.Objects()
+	for i := range objects {
+		r, ok := objects[i].(*policyv1.PodDisruptionBudget)
+		if !ok {
+			// this is critical: throw up hands and die
+			panic("access to an invalid object in the global PodDisruptionBudgetStore")
+		}
+
+		ret = append(ret, r)
+	}
+
+	return ret
+}
+
+// GetObject returns a named PodDisruptionBudget object from the global storage
+func (s *PodDisruptionBudgetStore) GetObject(nsName types.NamespacedName) *policyv1.PodDisruptionBudget {
+	o := s.Get(nsName)
+	if o == nil {
+		return nil
+	}
+
+	r, ok := o.(*policyv1.PodDisruptionBudget)
+	if !ok {
+		// this is critical: throw up hands and die
+		panic("access to an invalid object in the global PodDisruptionBudgetStore")
+	}
+
+	return r
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+var ReferenceGrants = NewReferenceGrantStore()
+
+type ReferenceGrantStore struct {
+	Store
+}
+
+func NewReferenceGrantStore() *ReferenceGrantStore {
+	return &ReferenceGrantStore{
+		Store: NewStore(),
+	}
+}
+
+// GetAll returns all ReferenceGrant objects from the global storage
+func (s *ReferenceGrantStore) GetAll() []*gwapiv1b1.ReferenceGrant {
+	ret := make([]*gwapiv1b1.ReferenceGrant, 0)
+
+	objects := s.Objects()
+	for i := range objects {
+		r, ok := objects[i].(*gwapiv1b1.ReferenceGrant)
+		if !ok {
+			// this is critical: throw up hands and die
+			panic("access to an invalid object in the global ReferenceGrantStore")
+		}
+
+		ret = append(ret, r)
+	}
+
+	return ret
+}
+
+// GetObject returns a named ReferenceGrant object from the global storage
+func (s *ReferenceGrantStore) GetObject(nsName types.NamespacedName) *gwapiv1b1.ReferenceGrant {
+	o := s.Get(nsName)
+	if o == nil {
+		return nil
+	}
+
+	r, ok := o.(*gwapiv1b1.ReferenceGrant)
+	if !ok {
+		// this is critical: throw up hands and die
+		panic("access to an invalid object in the global ReferenceGrantStore")
+	}
+
+	return r
+}
+
+// // AddReferenceGrant adds a ReferenceGrant object to the the global storage (this is used mainly for testing)
+// func (s *ReferenceGrantStore) AddReferenceGrant(gc *gwapiv1b1.ReferenceGrant) {
+// 	s.Upsert(gc)
+// }
@@ -0,0 +1,51 @@
+package store
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type RoleStore struct {
+	Store
+}
+
+func NewRoleStore() *RoleStore {
+	return &RoleStore{
+		Store: NewStore(),
+	}
+}
+
+// GetAll returns all Role objects from the global storage
+func (s *RoleStore) GetAll() []*rbacv1.Role {
+	ret := make([]*rbacv1.Role, 0)
+
+	objects := s.Objects()
+	for i := range objects {
+		r, ok := objects[i].(*rbacv1.Role)
+		if !ok {
+			// this is critical: throw up hands and die
+			panic("access to an invalid object in the global RoleStore")
+		}
+
+		ret = append(ret, r)
+	}
+
+	return ret
+}
+
+// GetObject returns a named Role object from the global storage
+func (s *RoleStore) GetObject(nsName types.NamespacedName) *rbacv1.Role {
+	o := s.Get(nsName)
+	if o == nil {
+		return nil
+	}
+
+	r, ok := o.(*rbacv1.Role)
+	if !ok {
+		// this is critical: throw up hands and die
+		panic("access to an invalid object in the global RoleStore")
+	}
+
+	return r
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type RoleBindingStore struct {
+	Store
+}
+
+func NewRoleBindingStore() *RoleBindingStore {
+	return &RoleBindingStore{
+		Store: NewStore(),
+	}
+}
+
+// GetAll returns all RoleBinding objects from the global storage
+func (s *RoleBindingStore) GetAll() []*rbacv1.RoleBinding {
+	ret := make([]*rbacv1.RoleBinding, 0)
+
+	objects := s.Objects()
+	for i := range objects {
+		r, ok := objects[i].(*rbacv1.RoleBinding)
+		if !ok {
+			// this is critical: throw up hands and die
+			panic("access to an invalid object in the global RoleBindingStore")
+		}
+
+		ret = append(ret, r)
+	}
+
+	return ret
+}
+
+// GetObject returns a named RoleBinding object from the global storage
+func (s *RoleBindingStore) GetObject(nsName types.NamespacedName) *rbacv1.RoleBinding {
+	o := s.Get(nsName)
+	if o == nil {
+		return nil
+	}
+
+	r, ok := o.(*rbacv1.RoleBinding)
+	if !ok {
+		// this is critical: throw up hands and die
+		panic("access to an invalid object in the global RoleBindingStore")
+	}
+
+	return r
+}
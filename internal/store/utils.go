@@ -96,6 +96,12 @@ func DumpObject(o client.Object) string {
 		} else {
 			output = string(json)
 		}
+	case *gwapiv1a2.TCPRoute:
+		if json, err := json.Marshal(strip(ro)); err != nil {
+			fmt.Printf("---------------ERROR-----------: %s\n", err)
+		} else {
+			output = string(json)
+		}
 	case *corev1.Service:
 		if json, err := json.Marshal(strip(ro)); err != nil {
 			fmt.Printf("---------------ERROR-----------: %s\n", err)
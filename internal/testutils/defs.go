@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -15,29 +16,32 @@ import (
 )
 
 var (
-	TestTrue                = true
-	TestNsName              = gwapiv1b1.Namespace("testnamespace")
-	TestStunnerConfig       = "stunner-config"
-	TestRealm               = "testrealm"
-	TestMetricsEndpoint     = "testmetrics"
-	TestHealthCheckEndpoint = "testhealth"
-	TestAuthType            = "plaintext"
-	TestUsername            = "testuser"
-	TestPassword            = "testpass"
-	TestLogLevel            = "testloglevel"
-	TestMinPort             = int32(1)
-	TestMaxPort             = int32(2)
-	TestLabelName           = "testlabel"
-	TestLabelValue          = "testvalue"
-	TestSectionName         = gwapiv1b1.SectionName("gateway-1-listener-udp")
-	TestCert64              = "dGVzdGNlcnQ=" // "testcert"
-	TestKey64               = "dGVzdGtleQ==" // "testkey"
-	TestReplicas            = int32(3)
-	TestTerminationGrace    = int64(60)
-	TestImagePullPolicy     = corev1.PullAlways
-	TestCPURequest          = resource.MustParse("250m")
-	TestMemoryLimit         = resource.MustParse("10M")
-	TestResourceRequest     = corev1.ResourceList(map[corev1.ResourceName]resource.Quantity{
+	TestTrue                    = true
+	TestFalse                   = false
+	TestNsName                  = gwapiv1b1.Namespace("testnamespace")
+	TestStunnerConfig           = "stunner-config"
+	TestRealm                   = "testrealm"
+	TestMetricsEndpoint         = "testmetrics"
+	TestHealthCheckEndpoint     = "testhealth"
+	TestAuthType                = "plaintext"
+	TestUsername                = "testuser"
+	TestPassword                = "testpass"
+	TestLogLevel                = "testloglevel"
+	TestMinPort                 = int32(1)
+	TestMaxPort                 = int32(2)
+	TestLabelName               = "testlabel"
+	TestLabelValue              = "testvalue"
+	TestSectionName             = gwapiv1b1.SectionName("gateway-1-listener-udp")
+	TestTCPSectionName          = gwapiv1b1.SectionName("gateway-1-listener-tcp")
+	TestCert64                  = "dGVzdGNlcnQ=" // "testcert"
+	TestKey64                   = "dGVzdGtleQ==" // "testkey"
+	TestReplicas                = int32(3)
+	TestTerminationGrace        = int64(60)
+	TestImagePullPolicy         = corev1.PullAlways
+	TestIPFamilyPolicyDualStack = corev1.IPFamilyPolicyPreferDualStack
+	TestCPURequest              = resource.MustParse("250m")
+	TestMemoryLimit             = resource.MustParse("10M")
+	TestResourceRequest         = corev1.ResourceList(map[corev1.ResourceName]resource.Quantity{
 		corev1.ResourceCPU: TestCPURequest,
 	})
 	TestResourceLimit = corev1.ResourceList(map[corev1.ResourceName]resource.Quantity{
@@ -151,6 +155,29 @@ var TestUDPRoute = gwapiv1a2.UDPRoute{
 	},
 }
 
+// TCPRoute
+var TestTCPRoute = gwapiv1a2.TCPRoute{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "tcproute-ok",
+		Namespace: "testnamespace",
+	},
+	Spec: gwapiv1a2.TCPRouteSpec{
+		CommonRouteSpec: gwapiv1b1.CommonRouteSpec{
+			ParentRefs: []gwapiv1b1.ParentReference{{
+				Name:        "gateway-1",
+				SectionName: &TestTCPSectionName,
+			}},
+		},
+		Rules: []gwapiv1a2.TCPRouteRule{{
+			BackendRefs: []gwapiv1b1.BackendRef{{
+				BackendObjectReference: gwapiv1b1.BackendObjectReference{
+					Name: gwapiv1b1.ObjectName("testservice-ok"),
+				},
+			}},
+		}},
+	},
+}
+
 // Service
 var TestSvc = corev1.Service{
 	ObjectMeta: metav1.ObjectMeta{
@@ -206,27 +233,114 @@ var TestNode = corev1.Node{
 	},
 }
 
-// Endpoints for the TestSvc
-var TestEndpoint = corev1.Endpoints{
+// Dual-stack service: an IPv4 and an IPv6 ClusterIP and load-balancer address
+var TestSvcDualStack = corev1.Service{
 	ObjectMeta: metav1.ObjectMeta{
 		Namespace: "testnamespace",
-		Name:      "testservice-ok", // must be the same as the service!
+		Name:      "testservice-ok",
+		Annotations: map[string]string{
+			opdefault.RelatedGatewayKey: "testnamespace/gateway-1",
+		},
 	},
-	Subsets: []corev1.EndpointSubset{{
-		Addresses: []corev1.EndpointAddress{{
-			IP: "1.2.3.4",
-		}, {
-			IP: "1.2.3.5",
+	Spec: corev1.ServiceSpec{
+		Type:           corev1.ServiceTypeLoadBalancer,
+		Selector:       map[string]string{"app": "dummy"},
+		IPFamilyPolicy: &TestIPFamilyPolicyDualStack,
+		IPFamilies:     []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol},
+		ClusterIP:      "10.0.0.1",
+		ClusterIPs:     []string{"10.0.0.1", "fd00::1"},
+		Ports: []corev1.ServicePort{
+			{
+				Name:     "udp-ok",
+				Protocol: corev1.ProtocolUDP,
+				Port:     1,
+				NodePort: 30001,
+			},
+		},
+	},
+	Status: corev1.ServiceStatus{
+		LoadBalancer: corev1.LoadBalancerStatus{
+			Ingress: []corev1.LoadBalancerIngress{{
+				IP: "1.2.3.4",
+				Ports: []corev1.PortStatus{{
+					Port:     1,
+					Protocol: corev1.ProtocolUDP,
+				}},
+			}, {
+				IP: "2001:db8::1",
+				Ports: []corev1.PortStatus{{
+					Port:     1,
+					Protocol: corev1.ProtocolUDP,
+				}},
+			}},
 		}},
-		NotReadyAddresses: []corev1.EndpointAddress{{
-			IP: "1.2.3.6",
+}
+
+// Dual-stack node
+var TestNodeDualStack = corev1.Node{
+	ObjectMeta: metav1.ObjectMeta{
+		Name: "testnode-ok",
+	},
+	Spec: corev1.NodeSpec{},
+	Status: corev1.NodeStatus{
+		Addresses: []corev1.NodeAddress{{
+			Type:    corev1.NodeInternalIP,
+			Address: "255.255.255.255",
+		}, {
+			Type:    corev1.NodeExternalIP,
+			Address: "1.2.3.4",
+		}, {
+			Type:    corev1.NodeExternalIP,
+			Address: "2001:db8::4",
 		}},
-		Ports: []corev1.EndpointPort{},
+	},
+}
+
+// EndpointSlice for the TestSvc
+var TestEndpointSlice = discoveryv1.EndpointSlice{
+	ObjectMeta: metav1.ObjectMeta{
+		Namespace: "testnamespace",
+		Name:      "testservice-ok-slice",
+		Labels: map[string]string{
+			// must be the same as the service!
+			discoveryv1.LabelServiceName: "testservice-ok",
+		},
+	},
+	AddressType: discoveryv1.AddressTypeIPv4,
+	Endpoints: []discoveryv1.Endpoint{{
+		Addresses:  []string{"1.2.3.4"},
+		Conditions: discoveryv1.EndpointConditions{Ready: &TestTrue},
 	}, {
-		Addresses: []corev1.EndpointAddress{{
-			IP: "1.2.3.7",
-		}},
+		Addresses:  []string{"1.2.3.5"},
+		Conditions: discoveryv1.EndpointConditions{Ready: &TestTrue},
+	}, {
+		Addresses:  []string{"1.2.3.6"},
+		Conditions: discoveryv1.EndpointConditions{Ready: &TestFalse},
+	}, {
+		Addresses:  []string{"1.2.3.7"},
+		Conditions: discoveryv1.EndpointConditions{Ready: &TestTrue},
 	}},
+	Ports: []discoveryv1.EndpointPort{},
+}
+
+// IPv6 EndpointSlice for the TestSvc
+var TestEndpointSliceIPv6 = discoveryv1.EndpointSlice{
+	ObjectMeta: metav1.ObjectMeta{
+		Namespace: "testnamespace",
+		Name:      "testservice-ok-slice-ipv6",
+		Labels: map[string]string{
+			discoveryv1.LabelServiceName: "testservice-ok",
+		},
+	},
+	AddressType: discoveryv1.AddressTypeIPv6,
+	Endpoints: []discoveryv1.Endpoint{{
+		Addresses:  []string{"fd00::4"},
+		Conditions: discoveryv1.EndpointConditions{Ready: &TestTrue},
+	}, {
+		Addresses:  []string{"fd00::5"},
+		Conditions: discoveryv1.EndpointConditions{Ready: &TestFalse},
+	}},
+	Ports: []discoveryv1.EndpointPort{},
 }
 
 // TestSecret for TLS tests
@@ -256,6 +370,26 @@ var TestAuthSecret = corev1.Secret{
 	},
 }
 
+// ReferenceGrant permitting UDPRoutes in the test namespace to refer to Services in the "dummy"
+// namespace
+var TestReferenceGrant = gwapiv1b1.ReferenceGrant{
+	ObjectMeta: metav1.ObjectMeta{
+		Namespace: "dummy",
+		Name:      "testreferencegrant-ok",
+	},
+	Spec: gwapiv1b1.ReferenceGrantSpec{
+		From: []gwapiv1b1.ReferenceGrantFrom{{
+			Group:     gwapiv1b1.Group(gwapiv1b1.GroupVersion.Group),
+			Kind:      gwapiv1b1.Kind("UDPRoute"),
+			Namespace: TestNsName,
+		}},
+		To: []gwapiv1b1.ReferenceGrantTo{{
+			Group: gwapiv1b1.Group(corev1.GroupName),
+			Kind:  gwapiv1b1.Kind("Service"),
+		}},
+	},
+}
+
 // StaticService
 var TestStaticSvc = stnrv1a1.StaticService{
 	ObjectMeta: metav1.ObjectMeta{
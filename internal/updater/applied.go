@@ -0,0 +1,157 @@
+package updater
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/l7mp/stunner-gateway-operator/internal/metrics"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+)
+
+// appliedKey identifies an object written by the updater.
+type appliedKey struct {
+	kind string
+	name types.NamespacedName
+}
+
+// appliedState is the hash of the last version of an object written by the updater, plus the
+// resource version of the live object right after the write.
+type appliedState struct {
+	hash, resourceVersion string
+}
+
+// appliedCache remembers the last-applied state of the objects written by the updater.
+type appliedCache struct {
+	lock    sync.Mutex
+	objects map[appliedKey]appliedState
+}
+
+func newAppliedCache() *appliedCache {
+	return &appliedCache{objects: make(map[appliedKey]appliedState)}
+}
+
+func getAppliedKey(o client.Object) appliedKey {
+	t := reflect.TypeOf(o)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return appliedKey{kind: t.Name(), name: store.GetNamespacedName(o)}
+}
+
+// normalizeObject returns the content of an object as written by the updater. Metadata set by the
+// API server and the transition timestamps of status conditions, which the renderer resets on
+// every render, are removed.
+func normalizeObject(o client.Object) (map[string]any, error) {
+	buf, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+
+	var content map[string]any
+	if err := json.Unmarshal(buf, &content); err != nil {
+		return nil, err
+	}
+
+	if meta, ok := content["metadata"].(map[string]any); ok {
+		for _, k := range []string{"resourceVersion", "uid", "generation", "creationTimestamp",
+			"managedFields", "selfLink"} {
+			delete(meta, k)
+		}
+	}
+	removeTransitionTimes(content)
+
+	return content, nil
+}
+
+// objectHash returns a hash of the normalized content of an object.
+func objectHash(o client.Object) (string, error) {
+	content, err := normalizeObject(o)
+	if err != nil {
+		return "", err
+	}
+
+	// map keys are marshaled in sorted order so the result is deterministic
+	buf, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func removeTransitionTimes(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		delete(v, "lastTransitionTime")
+		for _, e := range v {
+			removeTransitionTimes(e)
+		}
+	case []any:
+		for _, e := range v {
+			removeTransitionTimes(e)
+		}
+	}
+}
+
+// isUnchanged returns true if an object is the same as the last time it was written by the updater
+// and the live object has not been modified since then.
+func (u *Updater) isUnchanged(o client.Object) bool {
+	hash, err := objectHash(o)
+	if err != nil {
+		return false
+	}
+
+	key := getAppliedKey(o)
+	u.applied.lock.Lock()
+	last, ok := u.applied.objects[key]
+	u.applied.lock.Unlock()
+	if !ok || last.hash != hash {
+		return false
+	}
+
+	current, ok := o.DeepCopyObject().(client.Object)
+	if !ok {
+		return false
+	}
+	if err := u.getClient().Get(u.ctx, key.name, current); err != nil {
+		return false
+	}
+
+	return current.GetResourceVersion() == last.resourceVersion
+}
+
+// setApplied records that an object has been written by the updater, resulting in the current
+// live object. The generation is the render generation of the write.
+func (u *Updater) setApplied(o, current client.Object, gen int) {
+	if u.dryRun {
+		// nothing has been written: report what would have changed
+		u.setDiff(o, current, metrics.OperationUpsert, gen)
+		return
+	}
+
+	hash, err := objectHash(o)
+	if err != nil {
+		return
+	}
+
+	u.applied.lock.Lock()
+	defer u.applied.lock.Unlock()
+	u.applied.objects[getAppliedKey(o)] = appliedState{
+		hash:            hash,
+		resourceVersion: current.GetResourceVersion(),
+	}
+}
+
+// removeApplied forgets an object removed by the updater.
+func (u *Updater) removeApplied(o client.Object) {
+	u.applied.lock.Lock()
+	defer u.applied.lock.Unlock()
+	delete(u.applied.objects, getAppliedKey(o))
+}
@@ -0,0 +1,123 @@
+package updater
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// fakeManager is a manager that provides a client only.
+type fakeManager struct {
+	manager.Manager
+	client client.Client
+}
+
+func (m *fakeManager) GetClient() client.Client { return m.client }
+
+func newTestUpdater(objs ...client.Object) (*Updater, client.Client) {
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(objs...).Build()
+	u := NewUpdater(UpdaterConfig{
+		Manager: &fakeManager{client: c},
+		Logger:  logr.Discard(),
+	})
+	u.ctx = context.Background()
+	return u, c
+}
+
+func testConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "stunnerd-config", Namespace: "testnamespace"},
+		Data:       map[string]string{"stunnerd.conf": `{"version":"v1alpha1"}`},
+	}
+}
+
+func testService() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateway-1", Namespace: "testnamespace"},
+	}
+}
+
+func TestNormalizeObject(t *testing.T) {
+	cm := testConfigMap()
+	cm.SetResourceVersion("1")
+	cm.SetUID("uid-1")
+	cm.SetGeneration(2)
+	cm.SetCreationTimestamp(metav1.Now())
+	cm.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "kubectl"}})
+
+	content, err := normalizeObject(cm)
+	assert.NoError(t, err, "normalize")
+	meta, ok := content["metadata"].(map[string]any)
+	assert.True(t, ok, "metadata")
+	for _, k := range []string{"resourceVersion", "uid", "generation", "creationTimestamp",
+		"managedFields"} {
+		assert.NotContains(t, meta, k, "server-side metadata removed")
+	}
+	assert.Equal(t, "stunnerd-config", meta["name"], "name kept")
+
+	// the server-side metadata and the condition timestamps do not affect the hash
+	h1, err := objectHash(cm)
+	assert.NoError(t, err, "hash")
+	h2, err := objectHash(testConfigMap())
+	assert.NoError(t, err, "hash")
+	assert.Equal(t, h1, h2, "same hash")
+
+	svc1 := &corev1.Service{Status: corev1.ServiceStatus{Conditions: []metav1.Condition{{
+		Type: "Ready", LastTransitionTime: metav1.Unix(1, 0)}}}}
+	svc2 := &corev1.Service{Status: corev1.ServiceStatus{Conditions: []metav1.Condition{{
+		Type: "Ready", LastTransitionTime: metav1.Unix(2, 0)}}}}
+	h1, err = objectHash(svc1)
+	assert.NoError(t, err, "hash")
+	h2, err = objectHash(svc2)
+	assert.NoError(t, err, "hash")
+	assert.Equal(t, h1, h2, "transition times ignored")
+
+	// content changes do
+	h1, err = objectHash(testConfigMap())
+	assert.NoError(t, err, "hash")
+	cm = testConfigMap()
+	cm.Data["stunnerd.conf"] = `{"version":"v1alpha2"}`
+	h2, err = objectHash(cm)
+	assert.NoError(t, err, "hash")
+	assert.NotEqual(t, h1, h2, "different hash")
+}
+
+func TestIsUnchanged(t *testing.T) {
+	u, c := newTestUpdater(testConfigMap())
+
+	// never written
+	cm := testConfigMap()
+	assert.False(t, u.isUnchanged(cm), "not applied yet")
+
+	live := &corev1.ConfigMap{}
+	assert.NoError(t, c.Get(u.ctx, client.ObjectKeyFromObject(cm), live), "get")
+	u.setApplied(cm, live, 0)
+
+	// the same object is skipped
+	assert.True(t, u.isUnchanged(testConfigMap()), "unchanged")
+
+	// a changed object is written
+	cm = testConfigMap()
+	cm.Data["stunnerd.conf"] = `{"version":"v1alpha2"}`
+	assert.False(t, u.isUnchanged(cm), "content changed")
+
+	// a live object modified by someone else is written
+	live.Data["stunnerd.conf"] = `{}`
+	assert.NoError(t, c.Update(u.ctx, live), "update")
+	assert.False(t, u.isUnchanged(testConfigMap()), "resource version changed")
+
+	// a removed object is written
+	u.setApplied(testConfigMap(), live, 0)
+	assert.True(t, u.isUnchanged(testConfigMap()), "unchanged")
+	u.removeApplied(testConfigMap())
+	assert.False(t, u.isUnchanged(testConfigMap()), "removed")
+}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 
 	appv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -14,6 +17,8 @@ import (
 	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
+	stnrv1a1 "github.com/l7mp/stunner-gateway-operator/api/v1alpha1"
+	"github.com/l7mp/stunner-gateway-operator/internal/metrics"
 	"github.com/l7mp/stunner-gateway-operator/internal/store"
 )
 
@@ -21,7 +26,7 @@ func (u *Updater) updateGatewayClass(gc *gwapiv1b1.GatewayClass, gen int) error
 	u.log.V(2).Info("update gateway class", "resource", store.GetObjectKey(gc), "generation",
 		gen)
 
-	cli := u.manager.GetClient()
+	cli := u.getClient()
 	current := &gwapiv1b1.GatewayClass{ObjectMeta: metav1.ObjectMeta{
 		Name:      gc.GetName(),
 		Namespace: gc.GetNamespace(),
@@ -41,6 +46,37 @@ func (u *Updater) updateGatewayClass(gc *gwapiv1b1.GatewayClass, gen int) error
 	u.log.V(1).Info("gateway-class updated", "resource", store.GetObjectKey(gc), "generation",
 		gen, "result", store.DumpObject(current))
 
+	u.setApplied(gc, current, gen)
+
+	return nil
+}
+
+func (u *Updater) updateGatewayConfig(gwConf *stnrv1a1.GatewayConfig, gen int) error {
+	u.log.V(2).Info("updating gateway-config", "resource", store.GetObjectKey(gwConf),
+		"generation", gen)
+
+	cli := u.getClient()
+	current := &stnrv1a1.GatewayConfig{ObjectMeta: metav1.ObjectMeta{
+		Name:      gwConf.GetName(),
+		Namespace: gwConf.GetNamespace(),
+	}}
+
+	if err := cli.Get(u.ctx, client.ObjectKeyFromObject(current), current); err != nil {
+		return err
+	}
+
+	// the only thing we change on gateway-configs is the status: copy
+	gwConf.Status.DeepCopyInto(&current.Status)
+
+	if err := cli.Status().Update(u.ctx, current); err != nil {
+		return err
+	}
+
+	u.log.V(1).Info("gateway-config updated", "resource", store.GetObjectKey(gwConf),
+		"generation", gen, "result", store.DumpObject(current))
+
+	u.setApplied(gwConf, current, gen)
+
 	return nil
 }
 
@@ -48,7 +84,7 @@ func (u *Updater) updateGateway(gw *gwapiv1b1.Gateway, gen int) error {
 	u.log.V(2).Info("updating gateway", "resource", store.GetObjectKey(gw), "generation",
 		gen)
 
-	cli := u.manager.GetClient()
+	cli := u.getClient()
 	current := &gwapiv1b1.Gateway{ObjectMeta: metav1.ObjectMeta{
 		Name:      gw.GetName(),
 		Namespace: gw.GetNamespace(),
@@ -67,6 +103,8 @@ func (u *Updater) updateGateway(gw *gwapiv1b1.Gateway, gen int) error {
 	u.log.V(1).Info("gateway updated", "resource", store.GetObjectKey(gw), "generation", gen,
 		"result", store.DumpObject(current))
 
+	u.setApplied(gw, current, gen)
+
 	return nil
 }
 
@@ -74,7 +112,7 @@ func (u *Updater) updateUDPRoute(ro *gwapiv1a2.UDPRoute, gen int) error {
 	u.log.V(2).Info("updating UDP-route", "resource", store.GetObjectKey(ro), "generation",
 		gen)
 
-	cli := u.manager.GetClient()
+	cli := u.getClient()
 	current := &gwapiv1a2.UDPRoute{ObjectMeta: metav1.ObjectMeta{
 		Name:      ro.GetName(),
 		Namespace: ro.GetNamespace(),
@@ -93,13 +131,43 @@ func (u *Updater) updateUDPRoute(ro *gwapiv1a2.UDPRoute, gen int) error {
 	u.log.V(1).Info("UDP-route updated", "resource", store.GetObjectKey(ro), "generation",
 		gen, "result", store.DumpObject(current))
 
+	u.setApplied(ro, current, gen)
+
+	return nil
+}
+
+func (u *Updater) updateTCPRoute(ro *gwapiv1a2.TCPRoute, gen int) error {
+	u.log.V(2).Info("updating TCP-route", "resource", store.GetObjectKey(ro), "generation",
+		gen)
+
+	cli := u.getClient()
+	current := &gwapiv1a2.TCPRoute{ObjectMeta: metav1.ObjectMeta{
+		Name:      ro.GetName(),
+		Namespace: ro.GetNamespace(),
+	}}
+
+	if err := cli.Get(u.ctx, client.ObjectKeyFromObject(current), current); err != nil {
+		return err
+	}
+
+	ro.Status.DeepCopyInto(&current.Status)
+
+	if err := cli.Status().Update(u.ctx, current); err != nil {
+		return err
+	}
+
+	u.log.V(1).Info("TCP-route updated", "resource", store.GetObjectKey(ro), "generation",
+		gen, "result", store.DumpObject(current))
+
+	u.setApplied(ro, current, gen)
+
 	return nil
 }
 
 func (u *Updater) upsertService(svc *corev1.Service, gen int) (ctrlutil.OperationResult, error) {
 	u.log.V(2).Info("upsert service", "resource", store.GetObjectKey(svc), "generation", gen)
 
-	client := u.manager.GetClient()
+	client := u.getClient()
 	current := &corev1.Service{ObjectMeta: metav1.ObjectMeta{
 		Name:      svc.GetName(),
 		Namespace: svc.GetNamespace(),
@@ -110,8 +178,29 @@ func (u *Updater) upsertService(svc *corev1.Service, gen int) (ctrlutil.Operatio
 			return nil
 		}
 
-		// rewrite spec
+		// rewrite spec, but keep the ClusterIPs and the IP families allocated by
+		// Kubernetes: these are immutable and may differ per IP family
+		clusterIP, clusterIPs := current.Spec.ClusterIP, current.Spec.ClusterIPs
+		ipFamilies, ipFamilyPolicy := current.Spec.IPFamilies, current.Spec.IPFamilyPolicy
 		svc.Spec.DeepCopyInto(&current.Spec)
+		if current.Spec.ClusterIP == "" {
+			current.Spec.ClusterIP, current.Spec.ClusterIPs = clusterIP, clusterIPs
+		}
+		if len(current.Spec.IPFamilies) == 0 {
+			current.Spec.IPFamilies = ipFamilies
+		}
+		if current.Spec.IPFamilyPolicy == nil {
+			current.Spec.IPFamilyPolicy = ipFamilyPolicy
+		}
+		// downgrade from dual-stack: drop the secondary IP family
+		if p := current.Spec.IPFamilyPolicy; p != nil && *p == corev1.IPFamilyPolicySingleStack {
+			if len(current.Spec.IPFamilies) > 1 {
+				current.Spec.IPFamilies = current.Spec.IPFamilies[:1]
+			}
+			if len(current.Spec.ClusterIPs) > 1 {
+				current.Spec.ClusterIPs = current.Spec.ClusterIPs[:1]
+			}
+		}
 
 		return nil
 	})
@@ -124,6 +213,8 @@ func (u *Updater) upsertService(svc *corev1.Service, gen int) (ctrlutil.Operatio
 	u.log.V(1).Info("service upserted", "resource", store.GetObjectKey(svc), "generation",
 		gen, "result", store.DumpObject(current))
 
+	u.setApplied(svc, current, gen)
+
 	return op, nil
 }
 
@@ -131,7 +222,7 @@ func (u *Updater) upsertConfigMap(cm *corev1.ConfigMap, gen int) (ctrlutil.Opera
 	u.log.V(2).Info("upsert config-map", "resource", store.GetObjectKey(cm), "generation",
 		gen)
 
-	client := u.manager.GetClient()
+	client := u.getClient()
 	current := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
 		Name:      cm.GetName(),
 		Namespace: cm.GetNamespace(),
@@ -165,13 +256,64 @@ func (u *Updater) upsertConfigMap(cm *corev1.ConfigMap, gen int) (ctrlutil.Opera
 	u.log.V(1).Info("config-map upserted", "resource", store.GetObjectKey(cm), "generation",
 		gen, "result", store.DumpObject(current))
 
+	u.setApplied(cm, current, gen)
+
+	return op, nil
+}
+
+// upsertSecret writes the data of an auth Secret. The Secret is not owned by the operator so only
+// the data and the annotations are updated, everything else is left intact.
+func (u *Updater) upsertSecret(secret *corev1.Secret, gen int) (ctrlutil.OperationResult, error) {
+	u.log.V(2).Info("upsert secret", "resource", store.GetObjectKey(secret), "generation", gen)
+
+	client := u.getClient()
+	current := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:      secret.GetName(),
+		Namespace: secret.GetNamespace(),
+	}}
+
+	op, err := ctrlutil.CreateOrUpdate(u.ctx, client, current, func() error {
+		annotations := current.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		for k, v := range secret.GetAnnotations() {
+			annotations[k] = v
+		}
+		current.SetAnnotations(annotations)
+
+		if current.Type == "" {
+			current.Type = secret.Type
+		}
+
+		if current.Data == nil {
+			current.Data = make(map[string][]byte)
+		}
+		for k, v := range secret.Data {
+			current.Data[k] = v
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return ctrlutil.OperationResultNone, fmt.Errorf("cannot upsert secret %q: %w",
+			store.GetObjectKey(secret), err)
+	}
+
+	// never dump the secret data into the logs
+	u.log.V(1).Info("secret upserted", "resource", store.GetObjectKey(secret), "generation",
+		gen, "result", op)
+
+	u.setApplied(secret, current, gen)
+
 	return op, nil
 }
 
 func (u *Updater) upsertDeployment(dp *appv1.Deployment, gen int) (ctrlutil.OperationResult, error) {
 	u.log.V(2).Info("upsert deployment", "resource", store.GetObjectKey(dp), "generation", gen)
 
-	client := u.manager.GetClient()
+	client := u.getClient()
 	current := &appv1.Deployment{ObjectMeta: metav1.ObjectMeta{
 		Name:      dp.GetName(),
 		Namespace: dp.GetNamespace(),
@@ -189,63 +331,302 @@ func (u *Updater) upsertDeployment(dp *appv1.Deployment, gen int) (ctrlutil.Oper
 		}
 
 		// the pod template is copied verbatim
-		dp.Spec.Template.ObjectMeta.DeepCopyInto(&current.Spec.Template.ObjectMeta)
-		dpspec := &dp.Spec.Template.Spec
-		currentspec := &current.Spec.Template.Spec
-		currentspec.Containers = make([]corev1.Container, len(dpspec.Containers))
-		for i := range dpspec.Containers {
-			dpspec.Containers[i].DeepCopyInto(&currentspec.Containers[i])
-		}
-		currentspec.Volumes = make([]corev1.Volume, len(dpspec.Volumes))
-		for i := range dpspec.Volumes {
-			dpspec.Volumes[i].DeepCopyInto(&currentspec.Volumes[i])
+		mergePodTemplate(&current.Spec.Template, &dp.Spec.Template)
+
+		// u.log.Info("after", "cm", fmt.Sprintf("%#v\n", current))
+
+		return nil
+	})
+
+	// u.log.V(4).Info("upserting deployment", "resource", store.GetObjectKey(dp), "generation",
+	// 	gen, "deployment", store.DumpObject(dp))
+
+	if err != nil {
+		return ctrlutil.OperationResultNone, fmt.Errorf("cannot upsert deployment %q: %w",
+			store.GetObjectKey(dp), err)
+	}
+
+	u.log.V(1).Info("deployment upserted", "resource", store.GetObjectKey(dp), "generation",
+		gen, "result", store.DumpObject(current))
+
+	u.setApplied(dp, current, gen)
+
+	return op, nil
+}
+
+func (u *Updater) upsertDaemonSet(ds *appv1.DaemonSet, gen int) (ctrlutil.OperationResult, error) {
+	u.log.V(2).Info("upsert daemonset", "resource", store.GetObjectKey(ds), "generation", gen)
+
+	client := u.getClient()
+	current := &appv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{
+		Name:      ds.GetName(),
+		Namespace: ds.GetNamespace(),
+	}}
+
+	op, err := ctrlutil.CreateOrPatch(u.ctx, client, current, func() error {
+		if err := mergeMetadata(current, ds); err != nil {
+			return nil
 		}
 
-		// rest is optional
-		if dpspec.TerminationGracePeriodSeconds != nil {
-			currentspec.TerminationGracePeriodSeconds = dpspec.TerminationGracePeriodSeconds
+		current.Spec.Selector = ds.Spec.Selector
+
+		// the pod template is copied verbatim
+		mergePodTemplate(&current.Spec.Template, &ds.Spec.Template)
+
+		return nil
+	})
+
+	if err != nil {
+		return ctrlutil.OperationResultNone, fmt.Errorf("cannot upsert daemonset %q: %w",
+			store.GetObjectKey(ds), err)
+	}
+
+	u.log.V(1).Info("daemonset upserted", "resource", store.GetObjectKey(ds), "generation",
+		gen, "result", store.DumpObject(current))
+
+	u.setApplied(ds, current, gen)
+
+	return op, nil
+}
+
+func (u *Updater) upsertHPA(hpa *autoscalingv2.HorizontalPodAutoscaler, gen int) (ctrlutil.OperationResult, error) {
+	u.log.V(2).Info("upsert hpa", "resource", store.GetObjectKey(hpa), "generation", gen)
+
+	client := u.getClient()
+	current := &autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{
+		Name:      hpa.GetName(),
+		Namespace: hpa.GetNamespace(),
+	}}
+
+	op, err := ctrlutil.CreateOrPatch(u.ctx, client, current, func() error {
+		if err := mergeMetadata(current, hpa); err != nil {
+			return nil
 		}
 
-		currentspec.HostNetwork = dpspec.HostNetwork
+		hpa.Spec.DeepCopyInto(&current.Spec)
+
+		return nil
+	})
+
+	if err != nil {
+		return ctrlutil.OperationResultNone, fmt.Errorf("cannot upsert hpa %q: %w",
+			store.GetObjectKey(hpa), err)
+	}
+
+	u.log.V(1).Info("hpa upserted", "resource", store.GetObjectKey(hpa), "generation",
+		gen, "result", store.DumpObject(current))
+
+	u.setApplied(hpa, current, gen)
+
+	return op, nil
+}
+
+func (u *Updater) upsertPDB(pdb *policyv1.PodDisruptionBudget, gen int) (ctrlutil.OperationResult, error) {
+	u.log.V(2).Info("upsert pdb", "resource", store.GetObjectKey(pdb), "generation", gen)
+
+	client := u.getClient()
+	current := &policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{
+		Name:      pdb.GetName(),
+		Namespace: pdb.GetNamespace(),
+	}}
 
-		// affinity
-		if dpspec.Affinity != nil {
-			currentspec.Affinity = dpspec.Affinity
+	op, err := ctrlutil.CreateOrPatch(u.ctx, client, current, func() error {
+		if err := mergeMetadata(current, pdb); err != nil {
+			return nil
 		}
 
-		// tolerations
-		if dpspec.Tolerations != nil {
-			currentspec.Tolerations = dpspec.Tolerations
+		current.Spec.Selector = pdb.Spec.Selector
+		current.Spec.MinAvailable = pdb.Spec.MinAvailable
+		current.Spec.MaxUnavailable = pdb.Spec.MaxUnavailable
+
+		return nil
+	})
+
+	if err != nil {
+		return ctrlutil.OperationResultNone, fmt.Errorf("cannot upsert pdb %q: %w",
+			store.GetObjectKey(pdb), err)
+	}
+
+	u.log.V(1).Info("pdb upserted", "resource", store.GetObjectKey(pdb), "generation",
+		gen, "result", store.DumpObject(current))
+
+	u.setApplied(pdb, current, gen)
+
+	return op, nil
+}
+
+func (u *Updater) upsertServiceAccount(sa *corev1.ServiceAccount, gen int) (ctrlutil.OperationResult, error) {
+	u.log.V(2).Info("upsert service-account", "resource", store.GetObjectKey(sa), "generation", gen)
+
+	client := u.getClient()
+	current := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+		Name:      sa.GetName(),
+		Namespace: sa.GetNamespace(),
+	}}
+
+	op, err := ctrlutil.CreateOrPatch(u.ctx, client, current, func() error {
+		if err := mergeMetadata(current, sa); err != nil {
+			return nil
 		}
 
-		// security context
-		if dpspec.SecurityContext != nil {
-			currentspec.SecurityContext = dpspec.SecurityContext
+		return nil
+	})
+
+	if err != nil {
+		return ctrlutil.OperationResultNone, fmt.Errorf("cannot upsert service-account %q: %w",
+			store.GetObjectKey(sa), err)
+	}
+
+	u.log.V(1).Info("service-account upserted", "resource", store.GetObjectKey(sa), "generation",
+		gen, "result", store.DumpObject(current))
+
+	u.setApplied(sa, current, gen)
+
+	return op, nil
+}
+
+func (u *Updater) upsertRole(role *rbacv1.Role, gen int) (ctrlutil.OperationResult, error) {
+	u.log.V(2).Info("upsert role", "resource", store.GetObjectKey(role), "generation", gen)
+
+	client := u.getClient()
+	current := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{
+		Name:      role.GetName(),
+		Namespace: role.GetNamespace(),
+	}}
+
+	op, err := ctrlutil.CreateOrPatch(u.ctx, client, current, func() error {
+		if err := mergeMetadata(current, role); err != nil {
+			return nil
 		}
 
-		// u.log.Info("after", "cm", fmt.Sprintf("%#v\n", current))
+		current.Rules = make([]rbacv1.PolicyRule, len(role.Rules))
+		for i := range role.Rules {
+			role.Rules[i].DeepCopyInto(&current.Rules[i])
+		}
 
 		return nil
 	})
 
-	// u.log.V(4).Info("upserting deployment", "resource", store.GetObjectKey(dp), "generation",
-	// 	gen, "deployment", store.DumpObject(dp))
+	if err != nil {
+		return ctrlutil.OperationResultNone, fmt.Errorf("cannot upsert role %q: %w",
+			store.GetObjectKey(role), err)
+	}
+
+	u.log.V(1).Info("role upserted", "resource", store.GetObjectKey(role), "generation",
+		gen, "result", store.DumpObject(current))
+
+	u.setApplied(role, current, gen)
+
+	return op, nil
+}
+
+func (u *Updater) upsertRoleBinding(binding *rbacv1.RoleBinding, gen int) (ctrlutil.OperationResult, error) {
+	u.log.V(2).Info("upsert role-binding", "resource", store.GetObjectKey(binding), "generation", gen)
+
+	client := u.getClient()
+	current := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{
+		Name:      binding.GetName(),
+		Namespace: binding.GetNamespace(),
+	}}
+
+	op, err := ctrlutil.CreateOrPatch(u.ctx, client, current, func() error {
+		if err := mergeMetadata(current, binding); err != nil {
+			return nil
+		}
+
+		current.Subjects = make([]rbacv1.Subject, len(binding.Subjects))
+		copy(current.Subjects, binding.Subjects)
+
+		// the role ref is immutable
+		if current.RoleRef.Name == "" {
+			current.RoleRef = binding.RoleRef
+		}
+
+		return nil
+	})
 
 	if err != nil {
-		return ctrlutil.OperationResultNone, fmt.Errorf("cannot upsert deployment %q: %w",
-			store.GetObjectKey(dp), err)
+		return ctrlutil.OperationResultNone, fmt.Errorf("cannot upsert role-binding %q: %w",
+			store.GetObjectKey(binding), err)
 	}
 
-	u.log.V(1).Info("deployment upserted", "resource", store.GetObjectKey(dp), "generation",
+	u.log.V(1).Info("role-binding upserted", "resource", store.GetObjectKey(binding), "generation",
 		gen, "result", store.DumpObject(current))
 
+	u.setApplied(binding, current, gen)
+
 	return op, nil
 }
 
+// mergePodTemplate copies the fields of a rendered dataplane pod template into the current one.
+func mergePodTemplate(current, template *corev1.PodTemplateSpec) {
+	template.ObjectMeta.DeepCopyInto(&current.ObjectMeta)
+	dpspec := &template.Spec
+	currentspec := &current.Spec
+	currentspec.Containers = make([]corev1.Container, len(dpspec.Containers))
+	for i := range dpspec.Containers {
+		dpspec.Containers[i].DeepCopyInto(&currentspec.Containers[i])
+	}
+	currentspec.Volumes = make([]corev1.Volume, len(dpspec.Volumes))
+	for i := range dpspec.Volumes {
+		dpspec.Volumes[i].DeepCopyInto(&currentspec.Volumes[i])
+	}
+
+	// rest is optional
+	if dpspec.TerminationGracePeriodSeconds != nil {
+		currentspec.TerminationGracePeriodSeconds = dpspec.TerminationGracePeriodSeconds
+	}
+
+	currentspec.HostNetwork = dpspec.HostNetwork
+
+	// affinity
+	if dpspec.Affinity != nil {
+		currentspec.Affinity = dpspec.Affinity
+	}
+
+	// tolerations
+	if dpspec.Tolerations != nil {
+		currentspec.Tolerations = dpspec.Tolerations
+	}
+
+	// security context
+	if dpspec.SecurityContext != nil {
+		currentspec.SecurityContext = dpspec.SecurityContext
+	}
+
+	// the rest is owned by the operator: copy even if empty so that removing a setting from
+	// the Dataplane reaches the live object
+
+	// scheduling
+	currentspec.NodeSelector = dpspec.NodeSelector
+	currentspec.TopologySpreadConstraints = dpspec.TopologySpreadConstraints
+	currentspec.PriorityClassName = dpspec.PriorityClassName
+
+	// service account: the API server would restore the service account from the deprecated
+	// field if only the name were cleared
+	currentspec.ServiceAccountName = dpspec.ServiceAccountName
+	currentspec.DeprecatedServiceAccount = dpspec.ServiceAccountName
+
+	// image pull secrets
+	currentspec.ImagePullSecrets = dpspec.ImagePullSecrets
+}
+
 func (u *Updater) deleteObject(o client.Object, gen int) error {
 	u.log.V(1).Info("delete objec", "resource", store.GetObjectKey(o), "generation", gen)
 
-	return u.manager.GetClient().Delete(u.ctx, o)
+	// the object may have already been removed, e.g., by the garbage collector
+	if err := client.IgnoreNotFound(u.getClient().Delete(u.ctx, o)); err != nil {
+		return err
+	}
+
+	if u.dryRun {
+		u.setDiff(o, nil, metrics.OperationDelete, gen)
+		return nil
+	}
+
+	u.removeApplied(o)
+
+	return nil
 }
 
 func mergeMetadata(dst, src client.Object) error {
@@ -0,0 +1,51 @@
+package updater
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestMergePodTemplate(t *testing.T) {
+	current := corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		NodeSelector: map[string]string{"node-type": "turn"},
+		TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{
+			MaxSkew:     1,
+			TopologyKey: "topology.kubernetes.io/zone",
+		}},
+		PriorityClassName:        "high-priority",
+		ServiceAccountName:       "stunnerd",
+		DeprecatedServiceAccount: "stunnerd",
+		ImagePullSecrets:         []corev1.LocalObjectReference{{Name: "regcred"}},
+		NodeName:                 "node-1",
+	}}
+
+	// settings removed from the Dataplane are removed from the live object
+	mergePodTemplate(&current, &corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "stunnerd"}},
+	}})
+
+	assert.Len(t, current.Spec.Containers, 1, "containers")
+	assert.Empty(t, current.Spec.NodeSelector, "node selector")
+	assert.Empty(t, current.Spec.TopologySpreadConstraints, "topology spread constraints")
+	assert.Empty(t, current.Spec.PriorityClassName, "priority class")
+	assert.Empty(t, current.Spec.ServiceAccountName, "service account")
+	assert.Empty(t, current.Spec.DeprecatedServiceAccount, "deprecated service account")
+	assert.Empty(t, current.Spec.ImagePullSecrets, "image pull secrets")
+
+	// fields not managed by the operator are kept
+	assert.Equal(t, "node-1", current.Spec.NodeName, "node name")
+
+	// new settings are copied
+	mergePodTemplate(&current, &corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		NodeSelector:       map[string]string{"node-type": "edge"},
+		PriorityClassName:  "low-priority",
+		ServiceAccountName: "other",
+	}})
+
+	assert.Equal(t, map[string]string{"node-type": "edge"}, current.Spec.NodeSelector, "node selector")
+	assert.Equal(t, "low-priority", current.Spec.PriorityClassName, "priority class")
+	assert.Equal(t, "other", current.Spec.ServiceAccountName, "service account")
+	assert.Equal(t, "other", current.Spec.DeprecatedServiceAccount, "deprecated service account")
+}
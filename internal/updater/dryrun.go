@@ -0,0 +1,202 @@
+package updater
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Diff is a change the updater would make to an object in dry-run mode.
+type Diff struct {
+	// Kind is the kind of the object.
+	Kind string `json:"kind"`
+	// Object is the namespaced name of the object.
+	Object types.NamespacedName `json:"object"`
+	// Operation is either "upsert" or "delete".
+	Operation string `json:"operation"`
+	// Generation is the render generation that produced the change.
+	Generation int `json:"generation"`
+	// Diff is the difference between the live and the new object: lines starting with "-"
+	// would be removed and lines starting with "+" would be added.
+	Diff string `json:"diff"`
+	// Time is the time the change was last seen.
+	Time time.Time `json:"time"`
+}
+
+// getClient returns the client used for talking to Kubernetes. In dry-run mode all writes are sent
+// with the dry-run option set, so that the API server validates and defaults them but does not
+// persist anything.
+func (u *Updater) getClient() client.Client {
+	c := u.manager.GetClient()
+	if u.dryRun {
+		return client.NewDryRunClient(c)
+	}
+	return c
+}
+
+// setDiff records the difference between the live object and the result of a dry-run write. A
+// nil result means the object would be deleted. The generation is the render generation that
+// produced the change.
+func (u *Updater) setDiff(o, result client.Object, operation string, gen int) {
+	key := getAppliedKey(o)
+
+	var live client.Object
+	if current, ok := o.DeepCopyObject().(client.Object); ok {
+		if err := u.getClient().Get(u.ctx, key.name, current); err == nil {
+			live = current
+		} else if !apierrors.IsNotFound(err) {
+			u.log.Error(err, "dry-run: cannot obtain live object", "kind", key.kind,
+				"resource", key.name.String())
+			return
+		}
+	}
+
+	diff, err := diffObjects(live, result)
+	if err != nil {
+		u.log.Error(err, "dry-run: cannot diff object", "kind", key.kind,
+			"resource", key.name.String())
+		return
+	}
+
+	u.diffLock.Lock()
+	defer u.diffLock.Unlock()
+
+	last, ok := u.diffs[key]
+	if diff == "" {
+		delete(u.diffs, key)
+		return
+	}
+
+	// report each change only once
+	if !ok || last.Diff != diff {
+		u.log.Info("dry-run: object would be changed", "operation", operation,
+			"kind", key.kind, "resource", key.name.String(), "generation", gen,
+			"diff", diff)
+	}
+
+	u.diffs[key] = Diff{
+		Kind:       key.kind,
+		Object:     key.name,
+		Operation:  operation,
+		Generation: gen,
+		Diff:       diff,
+		Time:       time.Now(),
+	}
+}
+
+// GetDiffs returns the changes the updater would make in dry-run mode, sorted by kind and name.
+func (u *Updater) GetDiffs() []Diff {
+	u.diffLock.Lock()
+	defer u.diffLock.Unlock()
+
+	ret := make([]Diff, 0, len(u.diffs))
+	for _, d := range u.diffs {
+		ret = append(ret, d)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Kind != ret[j].Kind {
+			return ret[i].Kind < ret[j].Kind
+		}
+		return ret[i].Object.String() < ret[j].Object.String()
+	})
+
+	return ret
+}
+
+// HandleDiffs serves the changes the updater would make in dry-run mode. The diffs are returned in
+// plain text, or in JSON if the request accepts "application/json".
+func (u *Updater) HandleDiffs(w http.ResponseWriter, r *http.Request) {
+	diffs := u.GetDiffs()
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(diffs); err != nil {
+			u.log.Error(err, "dry-run: cannot send diffs")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, d := range diffs {
+		fmt.Fprintf(w, "%s %s/%s (generation: %d):\n%s\n", d.Operation, d.Kind,
+			d.Object.String(), d.Generation, d.Diff)
+	}
+}
+
+// diffObjects returns the difference between two objects, ignoring the fields set by the API
+// server. A nil object stands for a missing object.
+func diffObjects(from, to client.Object) (string, error) {
+	var fromContent, toContent map[string]any
+	var err error
+
+	if from != nil {
+		if fromContent, err = normalizeDiffObject(from); err != nil {
+			return "", err
+		}
+	}
+	if to != nil {
+		if toContent, err = normalizeDiffObject(to); err != nil {
+			return "", err
+		}
+	}
+
+	return cmp.Diff(fromContent, toContent), nil
+}
+
+// normalizeDiffObject normalizes an object for diffing: the data of Secrets is replaced with a
+// hash so that diffs never expose secrets, and JSON documents in ConfigMaps (e.g., the stunnerd
+// config) are indented so that changes are shown line by line.
+func normalizeDiffObject(o client.Object) (map[string]any, error) {
+	content, err := normalizeObject(o)
+	if err != nil {
+		return nil, err
+	}
+
+	switch getAppliedKey(o).kind {
+	case "Secret":
+		for _, field := range []string{"data", "stringData"} {
+			data, ok := content[field].(map[string]any)
+			if !ok {
+				continue
+			}
+			for k, v := range data {
+				sum := sha256.Sum256([]byte(fmt.Sprintf("%v", v)))
+				data[k] = "<redacted sha256:" + hex.EncodeToString(sum[:])[:8] + ">"
+			}
+		}
+
+	case "ConfigMap":
+		data, ok := content["data"].(map[string]any)
+		if !ok {
+			break
+		}
+		for k, v := range data {
+			s, ok := v.(string)
+			if !ok {
+				continue
+			}
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, []byte(s), "", "  "); err == nil {
+				data[k] = buf.String()
+			}
+		}
+	}
+
+	// the type meta may or may not be set on typed objects returned by the client: the kind is
+	// reported separately anyway
+	delete(content, "kind")
+	delete(content, "apiVersion")
+
+	return content, nil
+}
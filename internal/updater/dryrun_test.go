@@ -0,0 +1,120 @@
+package updater
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/l7mp/stunner-gateway-operator/internal/metrics"
+)
+
+func testSecret(secret string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-secret", Namespace: "testnamespace"},
+		Type:       corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"type":   []byte("longterm"),
+			"secret": []byte(secret),
+		},
+		StringData: map[string]string{"password": secret + "-password"},
+	}
+}
+
+// assertNoSecret checks that a text contains neither the given secrets nor their base64 encoding.
+func assertNoSecret(t *testing.T, text string, secrets ...string) {
+	t.Helper()
+	for _, s := range secrets {
+		assert.NotContains(t, text, s, "secret leaked")
+		assert.NotContains(t, text, base64.StdEncoding.EncodeToString([]byte(s)),
+			"base64 secret leaked")
+	}
+}
+
+func TestDiffObjects(t *testing.T) {
+	// new Secret
+	diff, err := diffObjects(nil, testSecret("new-secret-value"))
+	assert.NoError(t, err, "diff")
+	assert.Contains(t, diff, "<redacted sha256:", "redacted")
+	assertNoSecret(t, diff, "new-secret-value", "new-secret-value-password", "longterm")
+
+	// changed Secret: the change is visible but the values are not
+	diff, err = diffObjects(testSecret("old-secret-value"), testSecret("new-secret-value"))
+	assert.NoError(t, err, "diff")
+	assert.NotEmpty(t, diff, "changed")
+	assertNoSecret(t, diff, "old-secret-value", "old-secret-value-password",
+		"new-secret-value", "new-secret-value-password")
+
+	// deleted Secret
+	diff, err = diffObjects(testSecret("old-secret-value"), nil)
+	assert.NoError(t, err, "diff")
+	assertNoSecret(t, diff, "old-secret-value", "old-secret-value-password")
+
+	// unchanged Secret
+	diff, err = diffObjects(testSecret("secret-value"), testSecret("secret-value"))
+	assert.NoError(t, err, "diff")
+	assert.Empty(t, diff, "unchanged")
+
+	// JSON in ConfigMaps is shown line by line
+	cm := testConfigMap()
+	cm.Data["stunnerd.conf"] = `{"version":"v1alpha1","admin":{"name":"dummy"}}`
+	content, err := normalizeDiffObject(cm)
+	assert.NoError(t, err, "normalize")
+	assert.NotContains(t, content, "kind", "kind removed")
+	data, ok := content["data"].(map[string]any)
+	assert.True(t, ok, "data")
+	assert.Contains(t, data["stunnerd.conf"], "\n  \"admin\": {", "indented")
+}
+
+func TestDryRunDiffs(t *testing.T) {
+	u, _ := newTestUpdater(testSecret("old-secret-value"))
+	u.dryRun = true
+
+	u.setDiff(testSecret("new-secret-value"), testSecret("new-secret-value"),
+		metrics.OperationUpsert, 7)
+	u.setDiff(testSecret("old-secret-value"), nil, metrics.OperationDelete, 7)
+	secrets := []string{"old-secret-value", "old-secret-value-password", "new-secret-value",
+		"new-secret-value-password"}
+
+	diffs := u.GetDiffs()
+	assert.Len(t, diffs, 1, "diffs")
+	assert.Equal(t, "Secret", diffs[0].Kind, "kind")
+	assert.Equal(t, metrics.OperationDelete, diffs[0].Operation, "last operation")
+	assert.Equal(t, 7, diffs[0].Generation, "generation")
+	for _, d := range diffs {
+		assertNoSecret(t, d.Diff, secrets...)
+	}
+
+	u.setDiff(testSecret("new-secret-value"), testSecret("new-secret-value"),
+		metrics.OperationUpsert, 7)
+	diffs = u.GetDiffs()
+	assert.Len(t, diffs, 1, "diffs")
+	assert.Equal(t, metrics.OperationUpsert, diffs[0].Operation, "last operation")
+	assertNoSecret(t, diffs[0].Diff, secrets...)
+
+	// plain text
+	w := httptest.NewRecorder()
+	u.HandleDiffs(w, httptest.NewRequest(http.MethodGet, "/dry-run", nil))
+	assert.True(t, strings.HasPrefix(w.Body.String(),
+		"upsert Secret/testnamespace/auth-secret (generation: 7)"), "text")
+	assertNoSecret(t, w.Body.String(), secrets...)
+
+	// json
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/dry-run", nil)
+	r.Header.Set("Accept", "application/json")
+	u.HandleDiffs(w, r)
+	assert.Contains(t, w.Body.String(), `"kind":"Secret"`, "json")
+	assertNoSecret(t, w.Body.String(), secrets...)
+
+	// no change: the diff is removed
+	u.setDiff(testSecret("old-secret-value"), testSecret("old-secret-value"),
+		metrics.OperationUpsert, 7)
+	assert.Len(t, u.GetDiffs(), 0, "no diffs")
+}
@@ -3,6 +3,7 @@ package updater
 // updater uploads client updates
 import (
 	"context"
+	"sync"
 	// "fmt"
 	// "reflect"
 
@@ -11,6 +12,8 @@ import (
 	// corev1 "k8s.io/api/core/v1"
 	// metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	// "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/flowcontrol"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	// ctlr "sigs.k8s.io/controller-runtime"
 	// "sigs.k8s.io/controller-runtime/pkg/manager" corev1 "k8s.io/api/core/v1"
@@ -20,26 +23,78 @@ import (
 	// gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	"github.com/l7mp/stunner-gateway-operator/internal/event"
-	"github.com/l7mp/stunner-gateway-operator/internal/store"
+	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
 )
 
 type UpdaterConfig struct {
 	Manager manager.Manager
-	Logger  logr.Logger
+	// Workers is the number of objects written in parallel.
+	Workers int
+	// QPS and Burst configure the client-side rate limiter for the writes. Zero values fall
+	// back to the defaults.
+	QPS   float32
+	Burst int
+	// DryRun disables all writes: the changes that would be made are logged and collected
+	// for inspection instead.
+	DryRun bool
+	// MaxRetries is the number of retries after a conflict or a transient error, a negative
+	// value disables retrying.
+	MaxRetries int
+	Logger     logr.Logger
 }
 
 type Updater struct {
-	ctx       context.Context
-	manager   manager.Manager
-	updaterCh chan event.Event
-	log       logr.Logger
+	ctx        context.Context
+	manager    manager.Manager
+	updaterCh  chan event.Event
+	applied    *appliedCache
+	workers    int
+	limiter    flowcontrol.RateLimiter
+	backoff    wait.Backoff
+	failed     map[appliedKey]FailedUpdate
+	failedLock sync.Mutex
+	dryRun     bool
+	diffs      map[appliedKey]Diff
+	diffLock   sync.Mutex
+	log        logr.Logger
 }
 
 func NewUpdater(cfg UpdaterConfig) *Updater {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = opdefault.DefaultUpdaterWorkers
+	}
+	qps := cfg.QPS
+	if qps <= 0 {
+		qps = opdefault.DefaultUpdaterQPS
+	}
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = opdefault.DefaultUpdaterBurst
+	}
+	retries := cfg.MaxRetries
+	if retries == 0 {
+		retries = opdefault.DefaultUpdaterMaxRetries
+	} else if retries < 0 {
+		retries = 0
+	}
+
 	return &Updater{
 		manager:   cfg.Manager,
 		updaterCh: make(chan event.Event, 10),
-		log:       cfg.Logger.WithName("updater"),
+		applied:   newAppliedCache(),
+		workers:   workers,
+		limiter:   flowcontrol.NewTokenBucketRateLimiter(qps, burst),
+		backoff: wait.Backoff{
+			Duration: opdefault.DefaultUpdaterRetryBackoff,
+			Factor:   2.0,
+			Jitter:   0.1,
+			Steps:    retries + 1,
+		},
+		failed: make(map[appliedKey]FailedUpdate),
+		dryRun: cfg.DryRun,
+		diffs:  make(map[appliedKey]Diff),
+		log:    cfg.Logger.WithName("updater"),
 	}
 }
 
@@ -58,8 +113,7 @@ func (u *Updater) Start(ctx context.Context) error {
 					continue
 				}
 
-				// FIXME should run in separate thread
-				err := u.ProcessUpdate(e.(*event.EventUpdate))
+				err := u.ProcessUpdate(u.coalesce(e.(*event.EventUpdate)))
 
 				if err != nil {
 					u.log.Error(err, "could not update process event", "event",
@@ -75,114 +129,251 @@ func (u *Updater) Start(ctx context.Context) error {
 	return nil
 }
 
+// coalesce merges the updates queued up behind an update into a single update, so that objects
+// changed in several consecutive render generations are written only once, with their latest
+// version.
+func (u *Updater) coalesce(e *event.EventUpdate) *event.EventUpdate {
+	var merged *event.EventUpdate
+	n := 1
+	for {
+		select {
+		case next := <-u.updaterCh:
+			if next.GetType() != event.EventTypeUpdate {
+				u.log.Info("updater thread received unknown event",
+					"event", next.String())
+				continue
+			}
+
+			// the update events are shared with the config discovery server: never modify
+			// them in place
+			if merged == nil {
+				merged = event.NewEventUpdate(e.Generation)
+				merged.Merge(e)
+			}
+			merged.Merge(next.(*event.EventUpdate))
+			n++
+
+		default:
+			if merged == nil {
+				return e
+			}
+
+			u.log.V(1).Info("coalesced update events", "events", n, "generation",
+				merged.Generation, "objects", merged.Len())
+			return merged
+		}
+	}
+}
+
 // GetUpdaterChannel returns the channel on which the updater listenens to update resuests
 func (u *Updater) GetUpdaterChannel() chan event.Event {
 	return u.updaterCh
 }
 
+// ProcessUpdate writes the objects of an update event to Kubernetes. Objects of the same kind are
+// written in parallel by the worker pool, but the kinds are processed in a fixed order, e.g., the
+// config-watcher RBAC resources are created before the dataplane pods.
 func (u *Updater) ProcessUpdate(e *event.EventUpdate) error {
 	gen := e.Generation
-	u.log.Info("processing update event", "generation", gen, "update", e.String())
+	u.log.Info("processing update event", "generation", gen, "update", e.String(),
+		"dry-run", u.dryRun)
+
+	stats := &updateStats{}
+	defer func() {
+		u.log.Info("update event processed", "generation", gen, "applied", stats.applied,
+			"skipped", stats.skipped, "failed", stats.failed)
+	}()
 
 	// run the upsert queue
 	q := e.UpsertQueue
+
+	ops := []updateOp{}
 	for _, gc := range q.GatewayClasses.GetAll() {
-		if err := u.updateGatewayClass(gc, gen); err != nil {
-			u.log.Error(err, "cannot update gateway-class",
-				"gateway-class", store.DumpObject(gc))
-			continue
-		}
+		gc := gc
+		ops = append(ops, upsertOp("GatewayClass", gc, func() error {
+			return u.updateGatewayClass(gc, gen)
+		}))
+	}
+	u.runOps(ops, gen, stats)
+
+	ops = []updateOp{}
+	for _, gwConf := range q.GatewayConfigs.GetAll() {
+		gwConf := gwConf
+		ops = append(ops, upsertOp("GatewayConfig", gwConf, func() error {
+			return u.updateGatewayConfig(gwConf, gen)
+		}))
 	}
+	u.runOps(ops, gen, stats)
 
+	ops = []updateOp{}
 	for _, gw := range q.Gateways.GetAll() {
-		if err := u.updateGateway(gw, gen); err != nil {
-			u.log.Error(err, "cannot update gateway",
-				"gateway", store.DumpObject(gw))
-			continue
-		}
+		gw := gw
+		ops = append(ops, upsertOp("Gateway", gw, func() error {
+			return u.updateGateway(gw, gen)
+		}))
 	}
+	u.runOps(ops, gen, stats)
 
+	ops = []updateOp{}
 	for _, ro := range q.UDPRoutes.GetAll() {
-		if err := u.updateUDPRoute(ro, gen); err != nil {
-			u.log.Error(err, "cannot update UDP route",
-				"route", store.DumpObject(ro))
-			continue
-		}
+		ro := ro
+		ops = append(ops, upsertOp("UDPRoute", ro, func() error {
+			return u.updateUDPRoute(ro, gen)
+		}))
+	}
+	for _, ro := range q.TCPRoutes.GetAll() {
+		ro := ro
+		ops = append(ops, upsertOp("TCPRoute", ro, func() error {
+			return u.updateTCPRoute(ro, gen)
+		}))
 	}
+	u.runOps(ops, gen, stats)
 
+	ops = []updateOp{}
 	for _, svc := range q.Services.GetAll() {
-		if op, err := u.upsertService(svc, gen); err != nil {
-			u.log.Error(err, "cannot update service", "operation", op,
-				"service", store.DumpObject(svc))
-			continue
-		}
+		svc := svc
+		ops = append(ops, upsertOp("Service", svc, func() error {
+			_, err := u.upsertService(svc, gen)
+			return err
+		}))
 	}
+	u.runOps(ops, gen, stats)
 
+	ops = []updateOp{}
 	for _, cm := range q.ConfigMaps.GetAll() {
-		if op, err := u.upsertConfigMap(cm, gen); err != nil {
-			u.log.Error(err, "cannot upsert config-map", "operation", op,
-				"config-map", store.DumpObject(cm))
-			continue
-		}
+		cm := cm
+		ops = append(ops, upsertOp("ConfigMap", cm, func() error {
+			_, err := u.upsertConfigMap(cm, gen)
+			return err
+		}))
 	}
+	u.runOps(ops, gen, stats)
 
-	for _, dp := range q.Deployments.GetAll() {
-		if op, err := u.upsertDeployment(dp, gen); err != nil {
-			u.log.Error(err, "cannot upsert deployment", "operation", op,
-				"deployment", store.DumpObject(dp))
-			continue
-		}
+	// auth secrets go after the config-maps so that the dataplane already knows a rotated
+	// shared secret by the time signaling servers start to use it
+	ops = []updateOp{}
+	for _, secret := range q.Secrets.GetAll() {
+		secret := secret
+		ops = append(ops, upsertOp("Secret", secret, func() error {
+			_, err := u.upsertSecret(secret, gen)
+			return err
+		}))
 	}
+	u.runOps(ops, gen, stats)
 
-	// run the delete queue
-	q = e.DeleteQueue
-	for _, gc := range q.GatewayClasses.Objects() {
-		if err := u.deleteObject(gc, gen); err != nil {
-			u.log.Error(err, "cannot delete gateway-class",
-				"gateway-class", store.DumpObject(gc))
-			continue
-		}
+	// the config-watcher RBAC resources must exist before the dataplane pods are created
+	ops = []updateOp{}
+	for _, sa := range q.ServiceAccounts.GetAll() {
+		sa := sa
+		ops = append(ops, upsertOp("ServiceAccount", sa, func() error {
+			_, err := u.upsertServiceAccount(sa, gen)
+			return err
+		}))
+	}
+	for _, role := range q.Roles.GetAll() {
+		role := role
+		ops = append(ops, upsertOp("Role", role, func() error {
+			_, err := u.upsertRole(role, gen)
+			return err
+		}))
 	}
+	u.runOps(ops, gen, stats)
 
-	for _, gw := range q.Gateways.Objects() {
-		if err := u.deleteObject(gw, gen); err != nil {
-			u.log.Error(err, "cannot delete gateway",
-				"gateway", store.DumpObject(gw))
-			continue
-		}
+	ops = []updateOp{}
+	for _, binding := range q.RoleBindings.GetAll() {
+		binding := binding
+		ops = append(ops, upsertOp("RoleBinding", binding, func() error {
+			_, err := u.upsertRoleBinding(binding, gen)
+			return err
+		}))
 	}
+	u.runOps(ops, gen, stats)
 
-	for _, ro := range q.UDPRoutes.Objects() {
-		if err := u.deleteObject(ro, gen); err != nil {
-			u.log.Error(err, "cannot delete UDP route",
-				"route", store.DumpObject(ro))
-			continue
-		}
+	ops = []updateOp{}
+	for _, dp := range q.Deployments.GetAll() {
+		dp := dp
+		ops = append(ops, upsertOp("Deployment", dp, func() error {
+			_, err := u.upsertDeployment(dp, gen)
+			return err
+		}))
 	}
+	for _, ds := range q.DaemonSets.GetAll() {
+		ds := ds
+		ops = append(ops, upsertOp("DaemonSet", ds, func() error {
+			_, err := u.upsertDaemonSet(ds, gen)
+			return err
+		}))
+	}
+	u.runOps(ops, gen, stats)
 
-	for _, svc := range q.Services.Objects() {
-		if err := u.deleteObject(svc, gen); err != nil {
-			u.log.Error(err, "cannot delete service",
-				"service", store.DumpObject(svc))
-			continue
-		}
+	ops = []updateOp{}
+	for _, hpa := range q.HPAs.GetAll() {
+		hpa := hpa
+		ops = append(ops, upsertOp("HorizontalPodAutoscaler", hpa, func() error {
+			_, err := u.upsertHPA(hpa, gen)
+			return err
+		}))
+	}
+	for _, pdb := range q.PDBs.GetAll() {
+		pdb := pdb
+		ops = append(ops, upsertOp("PodDisruptionBudget", pdb, func() error {
+			_, err := u.upsertPDB(pdb, gen)
+			return err
+		}))
 	}
+	u.runOps(ops, gen, stats)
 
-	for _, cm := range q.ConfigMaps.Objects() {
-		if err := u.deleteObject(cm, gen); err != nil {
-			u.log.Error(err, "cannot delete config-map",
-				"config-map", store.DumpObject(cm))
-			continue
-		}
+	// run the delete queue: the dataplane resources go before the RBAC resources they use,
+	// auth secrets are owned by the user and they are never deleted by the operator
+	q = e.DeleteQueue
+
+	ops = []updateOp{}
+	for _, o := range q.GatewayClasses.Objects() {
+		ops = append(ops, u.deleteOp("GatewayClass", o, gen))
 	}
+	for _, o := range q.GatewayConfigs.Objects() {
+		ops = append(ops, u.deleteOp("GatewayConfig", o, gen))
+	}
+	for _, o := range q.Gateways.Objects() {
+		ops = append(ops, u.deleteOp("Gateway", o, gen))
+	}
+	for _, o := range q.UDPRoutes.Objects() {
+		ops = append(ops, u.deleteOp("UDPRoute", o, gen))
+	}
+	for _, o := range q.TCPRoutes.Objects() {
+		ops = append(ops, u.deleteOp("TCPRoute", o, gen))
+	}
+	for _, o := range q.Services.Objects() {
+		ops = append(ops, u.deleteOp("Service", o, gen))
+	}
+	for _, o := range q.ConfigMaps.Objects() {
+		ops = append(ops, u.deleteOp("ConfigMap", o, gen))
+	}
+	for _, o := range q.Deployments.Objects() {
+		ops = append(ops, u.deleteOp("Deployment", o, gen))
+	}
+	for _, o := range q.DaemonSets.Objects() {
+		ops = append(ops, u.deleteOp("DaemonSet", o, gen))
+	}
+	for _, o := range q.HPAs.Objects() {
+		ops = append(ops, u.deleteOp("HorizontalPodAutoscaler", o, gen))
+	}
+	for _, o := range q.PDBs.Objects() {
+		ops = append(ops, u.deleteOp("PodDisruptionBudget", o, gen))
+	}
+	u.runOps(ops, gen, stats)
 
-	for _, dp := range q.Deployments.Objects() {
-		if err := u.deleteObject(dp, gen); err != nil {
-			u.log.Error(err, "cannot delete deployment",
-				"deployment", store.DumpObject(dp))
-			continue
-		}
+	ops = []updateOp{}
+	for _, o := range q.ServiceAccounts.Objects() {
+		ops = append(ops, u.deleteOp("ServiceAccount", o, gen))
+	}
+	for _, o := range q.Roles.Objects() {
+		ops = append(ops, u.deleteOp("Role", o, gen))
+	}
+	for _, o := range q.RoleBindings.Objects() {
+		ops = append(ops, u.deleteOp("RoleBinding", o, gen))
 	}
+	u.runOps(ops, gen, stats)
 
 	return nil
 }
@@ -0,0 +1,59 @@
+package updater
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/l7mp/stunner-gateway-operator/internal/event"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+)
+
+func TestCoalesce(t *testing.T) {
+	u, _ := newTestUpdater()
+
+	// a single update is passed through
+	e1 := event.NewEventUpdate(1)
+	cm1 := testConfigMap()
+	e1.UpsertQueue.ConfigMaps.Upsert(cm1)
+	assert.Same(t, e1, u.coalesce(e1), "single update")
+
+	// queued updates are merged
+	e2 := event.NewEventUpdate(2)
+	cm2 := testConfigMap()
+	cm2.Data["stunnerd.conf"] = `{"version":"v1alpha2"}`
+	e2.UpsertQueue.ConfigMaps.Upsert(cm2)
+	svc := testService()
+	e2.UpsertQueue.Services.Upsert(svc)
+
+	e3 := event.NewEventUpdate(3)
+	e3.DeleteQueue.Services.Upsert(svc)
+	e3.Incremental = true
+
+	u.updaterCh <- e2
+	u.updaterCh <- e3
+	merged := u.coalesce(e1)
+	assert.Equal(t, 3, merged.Generation, "latest generation")
+	assert.True(t, merged.Incremental, "incremental")
+	assert.Len(t, u.updaterCh, 0, "queue drained")
+
+	// the latest version wins
+	cms := merged.UpsertQueue.ConfigMaps.GetAll()
+	assert.Len(t, cms, 1, "config maps")
+	assert.Equal(t, `{"version":"v1alpha2"}`, cms[0].Data["stunnerd.conf"], "latest config map")
+	assert.Len(t, merged.UpsertQueue.Services.GetAll(), 0, "service upsert overridden")
+	assert.Len(t, merged.DeleteQueue.Services.GetAll(), 1, "service deleted")
+
+	// the shared events are not modified in place
+	assert.NotSame(t, e1, merged, "new update")
+	assert.Equal(t, 1, e1.Generation, "generation unchanged")
+	assert.False(t, e1.Incremental, "incremental unchanged")
+	cms = e1.UpsertQueue.ConfigMaps.GetAll()
+	assert.Len(t, cms, 1, "config maps unchanged")
+	assert.Equal(t, `{"version":"v1alpha1"}`, cms[0].Data["stunnerd.conf"], "config map unchanged")
+	assert.Len(t, e1.UpsertQueue.Services.GetAll(), 0, "services unchanged")
+	assert.NotNil(t, e2.UpsertQueue.Services.GetObject(store.GetNamespacedName(svc)),
+		"service upsert unchanged")
+	assert.Len(t, e2.DeleteQueue.Services.GetAll(), 0, "deletes unchanged")
+	assert.Len(t, e3.UpsertQueue.Services.GetAll(), 0, "upserts unchanged")
+}
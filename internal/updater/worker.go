@@ -0,0 +1,228 @@
+package updater
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/l7mp/stunner-gateway-operator/internal/metrics"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+)
+
+// updateOp is a single upsert or delete operation run by the updater.
+type updateOp struct {
+	kind, operation string
+	object          client.Object
+	// skippable is set if the operation can be skipped when the object has not changed
+	skippable bool
+	run       func() error
+}
+
+func upsertOp(kind string, o client.Object, run func() error) updateOp {
+	return updateOp{kind: kind, operation: metrics.OperationUpsert, object: o, skippable: true,
+		run: run}
+}
+
+func (u *Updater) deleteOp(kind string, o client.Object, gen int) updateOp {
+	return updateOp{kind: kind, operation: metrics.OperationDelete, object: o,
+		run: func() error { return u.deleteObject(o, gen) }}
+}
+
+// updateStats counts the outcome of the operations run while processing an update.
+type updateStats struct {
+	lock                     sync.Mutex
+	applied, skipped, failed int
+}
+
+func (s *updateStats) observe(kind, operation string, err error) {
+	metrics.ObserveUpdate(kind, operation, err)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err != nil {
+		s.failed++
+	} else {
+		s.applied++
+	}
+}
+
+func (s *updateStats) skip(kind string) {
+	metrics.ObserveSkippedUpdate(kind)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.skipped++
+}
+
+// FailedUpdate describes an object the updater could not write even after retrying.
+type FailedUpdate struct {
+	// Kind is the kind of the object.
+	Kind string `json:"kind"`
+	// Object is the namespaced name of the object.
+	Object types.NamespacedName `json:"object"`
+	// Operation is either "upsert" or "delete".
+	Operation string `json:"operation"`
+	// Generation is the render generation of the failed update.
+	Generation int `json:"generation"`
+	// Attempts is the number of times the operation was tried.
+	Attempts int `json:"attempts"`
+	// Error is the error returned by the last attempt.
+	Error string `json:"error"`
+	// Time is the time of the last attempt.
+	Time time.Time `json:"time"`
+}
+
+// GetFailedUpdates returns the objects that could not be written in the last update of each
+// object, sorted by kind and name. An object is removed from the list once it is successfully
+// written or deleted.
+func (u *Updater) GetFailedUpdates() []FailedUpdate {
+	u.failedLock.Lock()
+	defer u.failedLock.Unlock()
+
+	ret := make([]FailedUpdate, 0, len(u.failed))
+	for _, f := range u.failed {
+		ret = append(ret, f)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Kind != ret[j].Kind {
+			return ret[i].Kind < ret[j].Kind
+		}
+		return ret[i].Object.String() < ret[j].Object.String()
+	})
+
+	return ret
+}
+
+// HandleFailedUpdates serves the objects the updater could not write. The list is returned in
+// plain text, or in JSON if the request accepts "application/json".
+func (u *Updater) HandleFailedUpdates(w http.ResponseWriter, r *http.Request) {
+	failed := u.GetFailedUpdates()
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(failed); err != nil {
+			u.log.Error(err, "cannot send failed updates")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, f := range failed {
+		fmt.Fprintf(w, "%s %s/%s (generation: %d, attempts: %d, time: %s): %s\n", f.Operation,
+			f.Kind, f.Object.String(), f.Generation, f.Attempts, f.Time.Format(time.RFC3339),
+			f.Error)
+	}
+}
+
+func (u *Updater) setFailed(op updateOp, gen, attempts int, err error) {
+	u.failedLock.Lock()
+	defer u.failedLock.Unlock()
+
+	u.failed[getAppliedKey(op.object)] = FailedUpdate{
+		Kind:       op.kind,
+		Object:     store.GetNamespacedName(op.object),
+		Operation:  op.operation,
+		Generation: gen,
+		Attempts:   attempts,
+		Error:      err.Error(),
+		Time:       time.Now(),
+	}
+	metrics.UpdaterFailedObjects.Set(float64(len(u.failed)))
+}
+
+func (u *Updater) clearFailed(op updateOp) {
+	u.failedLock.Lock()
+	defer u.failedLock.Unlock()
+
+	delete(u.failed, getAppliedKey(op.object))
+	metrics.UpdaterFailedObjects.Set(float64(len(u.failed)))
+}
+
+// runOps runs a set of operations on the worker pool and waits until all of them finish.
+func (u *Updater) runOps(ops []updateOp, gen int, stats *updateStats) {
+	if len(ops) == 0 {
+		return
+	}
+
+	workers := u.workers
+	if workers > len(ops) {
+		workers = len(ops)
+	}
+
+	queue := make(chan updateOp)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for op := range queue {
+				u.runOp(op, gen, stats)
+			}
+		}()
+	}
+
+	for _, op := range ops {
+		queue <- op
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// runOp runs an operation, retrying it with an exponential backoff on conflicts and transient
+// errors.
+func (u *Updater) runOp(op updateOp, gen int, stats *updateStats) {
+	if op.skippable && u.isUnchanged(op.object) {
+		stats.skip(op.kind)
+		u.clearFailed(op)
+		return
+	}
+
+	attempts := 0
+	err := retry.OnError(u.backoff, isRetriable, func() error {
+		if attempts > 0 {
+			metrics.UpdaterRetriesTotal.WithLabelValues(op.kind).Inc()
+			u.log.V(1).Info("retrying", "operation", op.operation, "kind", op.kind,
+				"resource", store.GetObjectKey(op.object), "attempt", attempts+1)
+		}
+		attempts++
+
+		// client-side rate limiting
+		if err := u.limiter.Wait(u.ctx); err != nil {
+			return err
+		}
+
+		return op.run()
+	})
+
+	stats.observe(op.kind, op.operation, err)
+	if err != nil {
+		u.log.Error(err, "cannot "+op.operation+" object", "kind", op.kind,
+			"resource", store.GetObjectKey(op.object), "generation", gen,
+			"attempts", attempts)
+		u.setFailed(op, gen, attempts, err)
+		return
+	}
+
+	u.clearFailed(op)
+}
+
+// isRetriable returns true for the errors that may go away on retrying: conflicts and transient
+// API server errors.
+func isRetriable(err error) bool {
+	return apierrors.IsConflict(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsUnexpectedServerError(err)
+}
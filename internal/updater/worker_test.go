@@ -0,0 +1,151 @@
+package updater
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/l7mp/stunner-gateway-operator/internal/metrics"
+)
+
+func TestIsRetriable(t *testing.T) {
+	gr := schema.GroupResource{Resource: "configmaps"}
+
+	for _, err := range []error{
+		apierrors.NewConflict(gr, "stunnerd-config", errors.New("conflict")),
+		apierrors.NewServerTimeout(gr, "update", 1),
+		apierrors.NewTimeoutError("timeout", 1),
+		apierrors.NewTooManyRequests("too many requests", 1),
+		apierrors.NewServiceUnavailable("unavailable"),
+		apierrors.NewInternalError(errors.New("internal")),
+	} {
+		assert.True(t, isRetriable(err), "retriable: %s", err.Error())
+	}
+
+	for _, err := range []error{
+		apierrors.NewNotFound(gr, "stunnerd-config"),
+		apierrors.NewBadRequest("bad request"),
+		apierrors.NewForbidden(gr, "stunnerd-config", errors.New("forbidden")),
+		errors.New("dummy"),
+	} {
+		assert.False(t, isRetriable(err), "not retriable: %s", err.Error())
+	}
+}
+
+func TestRunOp(t *testing.T) {
+	u, c := newTestUpdater(testConfigMap())
+	u.backoff.Duration = time.Millisecond
+	u.backoff.Steps = 3
+	gr := schema.GroupResource{Resource: "configmaps"}
+	conflict := apierrors.NewConflict(gr, "stunnerd-config", errors.New("conflict"))
+
+	// a conflict is retried
+	attempts := 0
+	op := updateOp{kind: "ConfigMap", operation: metrics.OperationUpsert, object: testConfigMap(),
+		run: func() error {
+			attempts++
+			if attempts < 2 {
+				return conflict
+			}
+			return nil
+		}}
+	stats := &updateStats{}
+	u.runOp(op, 1, stats)
+	assert.Equal(t, 2, attempts, "retried")
+	assert.Equal(t, 1, stats.applied, "applied")
+	assert.Len(t, u.GetFailedUpdates(), 0, "no failed updates")
+
+	// give up after the retries are exhausted
+	attempts = 0
+	op.run = func() error { attempts++; return conflict }
+	stats = &updateStats{}
+	u.runOp(op, 2, stats)
+	assert.Equal(t, 3, attempts, "all attempts used")
+	assert.Equal(t, 1, stats.failed, "failed")
+	fs := u.GetFailedUpdates()
+	assert.Len(t, fs, 1, "failed update")
+	assert.Equal(t, "ConfigMap", fs[0].Kind, "kind")
+	assert.Equal(t, "testnamespace/stunnerd-config", fs[0].Object.String(), "object")
+	assert.Equal(t, metrics.OperationUpsert, fs[0].Operation, "operation")
+	assert.Equal(t, 2, fs[0].Generation, "generation")
+	assert.Equal(t, 3, fs[0].Attempts, "attempts")
+	assert.Equal(t, conflict.Error(), fs[0].Error, "error")
+
+	// other errors are not retried
+	attempts = 0
+	op.run = func() error { attempts++; return apierrors.NewBadRequest("bad request") }
+	u.runOp(op, 3, &updateStats{})
+	assert.Equal(t, 1, attempts, "not retried")
+	fs = u.GetFailedUpdates()
+	assert.Len(t, fs, 1, "failed update")
+	assert.Equal(t, 3, fs[0].Generation, "generation")
+	assert.Equal(t, 1, fs[0].Attempts, "attempts")
+
+	// success clears the failure
+	op.run = func() error { return nil }
+	u.runOp(op, 4, &updateStats{})
+	assert.Len(t, u.GetFailedUpdates(), 0, "failure cleared")
+
+	// skipping an unchanged object clears the failure too
+	u.setFailed(op, 5, 1, conflict)
+	live := testConfigMap()
+	assert.NoError(t, c.Get(u.ctx, client.ObjectKeyFromObject(live), live), "get")
+	u.setApplied(testConfigMap(), live, 0)
+	op.skippable = true
+	op.run = func() error { t.Error("unchanged object written"); return nil }
+	stats = &updateStats{}
+	u.runOp(op, 6, stats)
+	assert.Equal(t, 1, stats.skipped, "skipped")
+	assert.Len(t, u.GetFailedUpdates(), 0, "failure cleared")
+}
+
+func TestFailedUpdates(t *testing.T) {
+	u, _ := newTestUpdater()
+	err := errors.New("dummy")
+
+	cmOp := updateOp{kind: "ConfigMap", operation: metrics.OperationUpsert, object: testConfigMap()}
+	svcOp := updateOp{kind: "Service", operation: metrics.OperationDelete, object: testService()}
+	u.setFailed(svcOp, 1, 2, err)
+	u.setFailed(cmOp, 1, 1, err)
+	u.setFailed(cmOp, 2, 3, err)
+
+	// sorted by kind, a single entry per object
+	fs := u.GetFailedUpdates()
+	assert.Len(t, fs, 2, "failed updates")
+	assert.Equal(t, "ConfigMap", fs[0].Kind, "kind")
+	assert.Equal(t, 2, fs[0].Generation, "last failure kept")
+	assert.Equal(t, 3, fs[0].Attempts, "attempts")
+	assert.Equal(t, "Service", fs[1].Kind, "kind")
+	assert.Equal(t, metrics.OperationDelete, fs[1].Operation, "operation")
+
+	// plain text
+	w := httptest.NewRecorder()
+	u.HandleFailedUpdates(w, httptest.NewRequest(http.MethodGet, "/failed-updates", nil))
+	assert.Contains(t, w.Body.String(),
+		"upsert ConfigMap/testnamespace/stunnerd-config (generation: 2, attempts: 3", "text")
+	assert.Contains(t, w.Body.String(), "delete Service/testnamespace/gateway-1", "text")
+
+	// json
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/failed-updates", nil)
+	r.Header.Set("Accept", "application/json")
+	u.HandleFailedUpdates(w, r)
+	ret := []FailedUpdate{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &ret), "json")
+	assert.Len(t, ret, 2, "failed updates")
+	assert.Equal(t, "stunnerd-config", ret[0].Object.Name, "object")
+
+	u.clearFailed(cmOp)
+	fs = u.GetFailedUpdates()
+	assert.Len(t, fs, 1, "failure cleared")
+	assert.Equal(t, "Service", fs[0].Kind, "kind")
+}
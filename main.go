@@ -17,8 +17,12 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -31,6 +35,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -40,8 +45,10 @@ import (
 	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	"github.com/l7mp/stunner-gateway-operator/internal/config"
+	"github.com/l7mp/stunner-gateway-operator/internal/offline"
 	"github.com/l7mp/stunner-gateway-operator/internal/operator"
 	"github.com/l7mp/stunner-gateway-operator/internal/renderer"
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
 	"github.com/l7mp/stunner-gateway-operator/internal/updater"
 	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
 	cds "github.com/l7mp/stunner-gateway-operator/pkg/config/server"
@@ -66,8 +73,16 @@ func init() {
 }
 
 func main() {
+	// offline rendering: no cluster needed
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		os.Exit(offline.Run(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
 	var controllerName, dataplaneMode, metricsAddr, cdsAddr, throttleTimeout, probeAddr string
-	var enableLeaderElection, enableEDS bool
+	var cdsTLSSecret, cdsAuth string
+	var enableLeaderElection, enableEDS, resolveHostnames, dryRun bool
+	var updaterWorkers, updaterBurst int
+	var updaterQPS float64
 
 	flag.StringVar(&controllerName, "controller-name", opdefault.DefaultControllerName,
 		"The conroller name to be used in the GatewayClass resource to bind it to this operator.")
@@ -78,6 +93,20 @@ func main() {
 	flag.StringVar(&dataplaneMode, "dataplane-mode", opdefault.DefaultDataplaneMode,
 		`Managed dataplane mode: either "managed" (automatic dataplane provisioning using the config discovery service) or "legacy" (dataplane(s) provided by the user).`)
 	flag.StringVar(&cdsAddr, "config-discovery-address", opdefault.DefaultConfigDiscoveryAddress, `Config discovery server endpoint.`)
+	flag.StringVar(&cdsTLSSecret, "config-discovery-tls-secret", "",
+		`Secret (in the form "namespace/name") holding the TLS certificate/key of the config discovery server and optionally a CA certificate ("ca.crt") to verify client certificates. If unset, the server uses plain HTTP. Available only in legacy dataplane mode.`)
+	flag.StringVar(&cdsAuth, "config-discovery-auth", opdefault.DefaultConfigDiscoveryAuth,
+		`Config discovery client authentication: either "none", "token" (ServiceAccount tokens) or "cert" (TLS client certificates). Must be "none" in managed dataplane mode.`)
+	flag.BoolVar(&resolveHostnames, "resolve-hostnames", false,
+		"Resolve hostnames in the public addresses of Gateways (e.g., load-balancer DNS names) into IP addresses for the STUNner listeners and the Gateway status.")
+	flag.IntVar(&updaterWorkers, "updater-workers", opdefault.DefaultUpdaterWorkers,
+		"Number of Kubernetes objects the operator writes in parallel.")
+	flag.Float64Var(&updaterQPS, "updater-qps", opdefault.DefaultUpdaterQPS,
+		"Maximum number of write requests per second the operator sends to the Kubernetes API server.")
+	flag.IntVar(&updaterBurst, "updater-burst", opdefault.DefaultUpdaterBurst,
+		"Maximum burst of write requests the operator sends to the Kubernetes API server.")
+	flag.BoolVar(&dryRun, "dry-run", false,
+		"Do not write anything to Kubernetes: log the changes the operator would make to each object and serve them at the /dry-run endpoint of the metrics server.")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -136,11 +165,28 @@ func main() {
 
 	setupLog.Info("setting up Kubernetes controller manager")
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme: scheme,
-		Metrics: metricsserver.Options{
-			BindAddress: metricsAddr,
+	// the updater is set up only once the manager exists
+	var u *updater.Updater
+	metricsOpts := metricsserver.Options{
+		BindAddress: metricsAddr,
+		ExtraHandlers: map[string]http.Handler{
+			"/failed-updates": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				u.HandleFailedUpdates(w, r)
+			}),
 		},
+	}
+	if dryRun {
+		setupLog.Info("dry-run mode: no changes will be written to Kubernetes")
+		metricsOpts.ExtraHandlers["/dry-run"] = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u.HandleDiffs(w, r)
+		})
+		// leader election would write a lease
+		enableLeaderElection = false
+	}
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+		Scheme:                 scheme,
+		Metrics:                metricsOpts,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "92062b70.l7mp.io",
@@ -160,21 +206,79 @@ func main() {
 	}
 
 	setupLog.Info("setting up STUNner config renderer")
+	var resolver renderer.Resolver
+	if resolveHostnames {
+		setupLog.Info("hostname resolution enabled")
+		resolver = renderer.NewDNSResolver()
+	}
+	// Kubernetes Events are writes too
+	var recorder record.EventRecorder
+	if !dryRun {
+		recorder = mgr.GetEventRecorderFor("stunner-gateway-operator")
+	}
 	r := renderer.NewRenderer(renderer.RendererConfig{
-		Scheme: scheme,
-		Logger: logger,
+		Scheme:        scheme,
+		EventRecorder: recorder,
+		Resolver:      resolver,
+		Logger:        logger,
 	})
 
 	setupLog.Info("setting up updater client")
-	u := updater.NewUpdater(updater.UpdaterConfig{
+	u = updater.NewUpdater(updater.UpdaterConfig{
 		Manager: mgr,
+		Workers: updaterWorkers,
+		QPS:     float32(updaterQPS),
+		Burst:   updaterBurst,
+		DryRun:  dryRun,
 		Logger:  logger,
 	})
 
-	setupLog.Info("setting up CDS server", "address", cdsAddr)
+	var cdsTLSConfig *tls.Config
+	if cdsTLSSecret != "" {
+		setupLog.Info("setting up TLS for the CDS server", "secret", cdsTLSSecret)
+		tlsConf, err := cds.NewTLSConfigFromSecret(context.Background(), mgr.GetAPIReader(),
+			store.GetNameFromKey(cdsTLSSecret), logger)
+		if err != nil {
+			setupLog.Error(err, "unable to set up TLS for the CDS server")
+			os.Exit(1)
+		}
+		cdsTLSConfig = tlsConf
+	}
+
+	var cdsAuthenticator cds.Authenticator
+	switch cdsAuth {
+	case opdefault.ConfigDiscoveryAuthNone:
+	case opdefault.ConfigDiscoveryAuthToken:
+		cdsAuthenticator = cds.NewTokenAuthenticator(mgr.GetClient(), mgr.GetAPIReader(),
+			opdefault.DefaultConfigDiscoveryTokenAudience)
+	case opdefault.ConfigDiscoveryAuthCert:
+		if cdsTLSConfig == nil || cdsTLSConfig.ClientCAs == nil {
+			setupLog.Error(errors.New("TLS with a CA certificate is required"),
+				"unable to set up certificate-based CDS client authentication")
+			os.Exit(1)
+		}
+		cdsAuthenticator = cds.NewCertAuthenticator()
+	default:
+		setupLog.Error(fmt.Errorf("unknown authentication mode %q", cdsAuth),
+			"unable to set up CDS client authentication")
+		os.Exit(1)
+	}
+
+	if config.DataplaneMode == config.DataplaneModeManaged &&
+		(cdsTLSConfig != nil || cdsAuthenticator != nil) {
+		// the managed dataplane template connects over plain HTTP without credentials
+		setupLog.Error(errors.New("stunnerd supports neither TLS nor client authentication for config discovery"),
+			"CDS TLS and client authentication are available only in legacy dataplane mode")
+		os.Exit(1)
+	}
+
+	setupLog.Info("setting up CDS server", "address", cdsAddr, "tls", cdsTLSConfig != nil,
+		"authentication", cdsAuth)
 	c := cds.NewConfigDiscoveryServer(cds.ConfigDiscoveryConfig{
-		Addr:   config.ConfigDiscoveryAddress,
-		Logger: logger,
+		Addr:          config.ConfigDiscoveryAddress,
+		TLSConfig:     cdsTLSConfig,
+		Authenticator: cdsAuthenticator,
+		Logger:        logger,
 	})
 
 	setupLog.Info("setting up operator")
@@ -184,6 +288,7 @@ func main() {
 		RenderCh:       r.GetRenderChannel(),
 		ConfigCh:       c.GetConfigUpdateChannel(),
 		UpdaterCh:      u.GetUpdaterChannel(),
+		DryRun:         dryRun,
 		Logger:         logger,
 	})
 
@@ -203,10 +308,15 @@ func main() {
 		os.Exit(1)
 	}
 
-	setupLog.Info("starting config discovery server")
-	if err := c.Start(ctx); err != nil {
-		setupLog.Error(err, "could not run config discovery server")
-		os.Exit(1)
+	if dryRun {
+		// the dataplane must not receive the configs rendered in dry-run mode
+		setupLog.Info("dry-run mode: config discovery server disabled")
+	} else {
+		setupLog.Info("starting config discovery server")
+		if err := c.Start(ctx); err != nil {
+			setupLog.Error(err, "could not run config discovery server")
+			os.Exit(1)
+		}
 	}
 
 	setupLog.Info("starting operator thread")
@@ -21,6 +21,10 @@ const (
 	// DefaultDataplaneName is the name of the default Dataplane to use when no dataplane is specified explicitly.
 	DefaultDataplaneName = "default"
 
+	// DefaultDataplaneReplicas is the default number of stunnerd pods of a Gateway when no
+	// replica count is specified in the Dataplane.
+	DefaultDataplaneReplicas = int32(1)
+
 	// DefaultDataplaneMode is the default dataplane" mode.
 	DefaultDataplaneMode = "legacy"
 	// DefaultDataplaneMode = "managed"
@@ -63,6 +67,13 @@ const (
 	// to external clients.
 	DefaultServiceType = corev1.ServiceTypeLoadBalancer
 
+	// IPFamilyPolicyAnnotationKey defines the IP family policy of the service created to expose
+	// each Gateway to external clients. Can be either `SingleStack`, `PreferDualStack` or
+	// `RequireDualStack`. Default is to use the cluster default (usually `SingleStack`). Note
+	// that stunnerd listens on the primary IP address of the pod, public addresses of the
+	// other IP family are reported in the Gateway status only.
+	IPFamilyPolicyAnnotationKey = "stunner.l7mp.io/ip-family-policy"
+
 	// // GatewayManagedLabelValue indicates that the object's lifecycle is managed by
 	// // the gateway controller.
 	// GatewayManagedLabelValue = "gateway"
@@ -97,10 +108,58 @@ const (
 	// service. The config watcher is avaialble at `<DefaultConfigDiscoveryEndpoint>/watch`.
 	DefaultConfigDiscoveryEndpoint = "/api/v1/config"
 
+	// DefaultConfigDiscoveryListEndpoint is the API endpoint that lists all configs held by the
+	// config discovery service. The configs of a single namespace are available at
+	// `<DefaultConfigDiscoveryListEndpoint>/<namespace>`.
+	DefaultConfigDiscoveryListEndpoint = "/api/v1/configs"
+
+	// DefaultConfigDiscoveryClientsEndpoint is the API endpoint that lists the clients
+	// connected to the config discovery service.
+	DefaultConfigDiscoveryClientsEndpoint = "/api/v1/clients"
+
+	// ConfigDiscoveryAuthNone disables the authentication of config discovery clients.
+	ConfigDiscoveryAuthNone = "none"
+
+	// ConfigDiscoveryAuthToken makes the config discovery server authenticate clients using
+	// Kubernetes ServiceAccount tokens bound to the stunnerd pods.
+	ConfigDiscoveryAuthToken = "token"
+
+	// ConfigDiscoveryAuthCert makes the config discovery server authenticate clients using TLS
+	// client certificates. The CommonName of the certificate must be the name of the Gateway
+	// of the client in the form "namespace/name".
+	ConfigDiscoveryAuthCert = "cert"
+
+	// DefaultConfigDiscoveryAuth is the default client authentication mode of the config
+	// discovery server.
+	DefaultConfigDiscoveryAuth = ConfigDiscoveryAuthNone
+
+	// DefaultConfigDiscoveryTokenAudience is the audience of the ServiceAccount tokens
+	// stunnerd pods use to authenticate with the config discovery server.
+	DefaultConfigDiscoveryTokenAudience = "stunner.l7mp.io/config-discovery"
+
 	// DefaultThrottleTimeout is the default time interval to wait between subsequent config
 	// renders.
 	DefaultThrottleTimeout = 250 * time.Millisecond
 
+	// DefaultUpdaterWorkers is the default number of objects the updater writes in parallel.
+	DefaultUpdaterWorkers = 4
+
+	// DefaultUpdaterQPS is the default maximum number of write requests per second the
+	// updater sends to the Kubernetes API server.
+	DefaultUpdaterQPS = 20
+
+	// DefaultUpdaterBurst is the default maximum burst of write requests the updater sends to
+	// the Kubernetes API server.
+	DefaultUpdaterBurst = 40
+
+	// DefaultUpdaterMaxRetries is the default number of times the updater retries writing an
+	// object after a conflict or a transient error.
+	DefaultUpdaterMaxRetries = 5
+
+	// DefaultUpdaterRetryBackoff is the default initial delay before the updater retries
+	// writing an object. The delay is doubled on each retry.
+	DefaultUpdaterRetryBackoff = 100 * time.Millisecond
+
 	// MixedProtocolAnnotationKey is the name(key) of the annotation that is used to
 	// disable STUNner's blocking of mixed-protocol LBs for specific Gateways.
 	// If false or any other string other than true the LB's proto defaults to the first
@@ -110,4 +169,32 @@ const (
 
 	// MixedProtocolAnnotationValue is the expected value in order to enable mixed protocol LBs
 	MixedProtocolAnnotationValue = "true"
+
+	// GatewayConfigAnnotationKey is the name(key) of the Gateway annotation that can be used
+	// to specify a GatewayConfig that overrides the settings of the GatewayConfig of the
+	// GatewayClass for a single Gateway. The value is the name of the GatewayConfig, either in
+	// the form "namespace/name" or simply "name", in which case the GatewayConfig is looked up
+	// in the namespace of the Gateway. Only supported in managed dataplane mode.
+	GatewayConfigAnnotationKey = "stunner.l7mp.io/gateway-config"
+
+	// SharedSecretRotatedAtAnnotationKey is the name(key) of the annotation the operator puts
+	// on the auth Secret of a GatewayConfig with shared-secret rotation enabled to record the
+	// time of the last rotation, in RFC 3339 format.
+	SharedSecretRotatedAtAnnotationKey = "stunner.l7mp.io/shared-secret-rotated-at"
+
+	// NextSharedSecretKey is the key in the auth Secret of a GatewayConfig with shared-secret
+	// rotation enabled that holds the upcoming shared secret during the overlap window after a
+	// rotation. The key "secret" always holds the shared secret used by the dataplane, the
+	// upcoming secret replaces it when the overlap window closes.
+	NextSharedSecretKey = "next-secret"
+
+	// PreviousSharedSecretKey is the key in the auth Secret of a GatewayConfig with
+	// shared-secret rotation enabled that holds the shared secret replaced by the current one
+	// at the end of the last overlap window.
+	PreviousSharedSecretKey = "previousSecret"
+
+	// DefaultSharedSecretOverlap is the default overlap window during which the upcoming
+	// shared secret is published before the dataplane switches to it, if the GatewayConfig
+	// specifies neither an overlap window nor an auth lifetime.
+	DefaultSharedSecretOverlap = time.Hour
 )
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/l7mp/stunner-gateway-operator/internal/store"
+	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
+)
+
+const (
+	serviceAccountUserPrefix = "system:serviceaccount:"
+	podNameExtraKey          = "authentication.kubernetes.io/pod-name"
+	podUIDExtraKey           = "authentication.kubernetes.io/pod-uid"
+)
+
+// Authenticator authenticates config discovery clients and authorizes them to access configs.
+type Authenticator interface {
+	// Authorize checks whether the client that issued the request is allowed to access the
+	// config of the Gateway with the given id (in the form "namespace/name").
+	Authorize(r *http.Request, id string) error
+}
+
+// certAuthenticator authenticates clients using TLS client certificates.
+type certAuthenticator struct{}
+
+// NewCertAuthenticator creates an authenticator that identifies clients by the CommonName of
+// their verified TLS client certificate, which must be of the form "namespace/name" of the
+// Gateway the client belongs to. Requires TLS with client certificate verification.
+func NewCertAuthenticator() Authenticator {
+	return &certAuthenticator{}
+}
+
+// Authorize implements Authenticator.
+func (a *certAuthenticator) Authorize(r *http.Request, id string) error {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return errors.New("no verified client certificate")
+	}
+
+	cn := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	if cn != id {
+		return fmt.Errorf("client certificate for %q is not authorized to access config %q",
+			cn, id)
+	}
+
+	return nil
+}
+
+// tokenAuthenticator authenticates clients using Kubernetes ServiceAccount tokens.
+type tokenAuthenticator struct {
+	client   client.Client
+	reader   client.Reader
+	audience string
+}
+
+// NewTokenAuthenticator creates an authenticator that validates the ServiceAccount token in the
+// "Authorization: Bearer" header of the request with the Kubernetes TokenReview API. The token
+// must be bound to a stunnerd pod of the Gateway the client asks the config for. The client is
+// used to create TokenReviews and the reader is used to look up the pod, preferably without
+// caching.
+func NewTokenAuthenticator(c client.Client, r client.Reader, audience string) Authenticator {
+	return &tokenAuthenticator{client: c, reader: r, audience: audience}
+}
+
+// Authorize implements Authenticator.
+func (a *tokenAuthenticator) Authorize(r *http.Request, id string) error {
+	token, err := getBearerToken(r)
+	if err != nil {
+		return err
+	}
+
+	review := &authnv1.TokenReview{
+		Spec: authnv1.TokenReviewSpec{
+			Token:     token,
+			Audiences: []string{a.audience},
+		},
+	}
+	if err := a.client.Create(r.Context(), review); err != nil {
+		return fmt.Errorf("token review failed: %w", err)
+	}
+
+	if !review.Status.Authenticated {
+		return fmt.Errorf("invalid token: %s", review.Status.Error)
+	}
+
+	// the stunnerd pods of a Gateway run in the namespace of the Gateway
+	user := review.Status.User
+	if !strings.HasPrefix(user.Username, serviceAccountUserPrefix) {
+		return fmt.Errorf("user %q is not a service account", user.Username)
+	}
+	ss := strings.Split(strings.TrimPrefix(user.Username, serviceAccountUserPrefix), ":")
+	if len(ss) != 2 {
+		return fmt.Errorf("malformed service account name %q", user.Username)
+	}
+	namespace := ss[0]
+
+	podNames := user.Extra[podNameExtraKey]
+	if len(podNames) != 1 {
+		return errors.New("token is not bound to a pod")
+	}
+
+	pod := &corev1.Pod{}
+	if err := a.reader.Get(r.Context(), types.NamespacedName{Namespace: namespace,
+		Name: podNames[0]}, pod); err != nil {
+		return fmt.Errorf("cannot find pod %s/%s: %w", namespace, podNames[0], err)
+	}
+
+	if podUIDs := user.Extra[podUIDExtraKey]; len(podUIDs) == 1 && podUIDs[0] != string(pod.GetUID()) {
+		return fmt.Errorf("token is bound to a stale pod %s/%s", namespace, podNames[0])
+	}
+
+	return authorizePod(pod, id)
+}
+
+// authorizePod checks whether the pod is a stunnerd pod of the Gateway with the given id.
+func authorizePod(pod *corev1.Pod, id string) error {
+	gw := store.GetNameFromKey(id)
+	labels := pod.GetLabels()
+	if pod.GetNamespace() != gw.Namespace ||
+		labels[opdefault.AppLabelKey] != opdefault.AppLabelValue ||
+		labels[opdefault.RelatedGatewayKey] != gw.Name ||
+		labels[opdefault.RelatedGatewayNamespace] != gw.Namespace {
+		return fmt.Errorf("pod %s is not authorized to access config %q",
+			store.GetObjectKey(pod), id)
+	}
+
+	return nil
+}
+
+func getBearerToken(r *http.Request) (string, error) {
+	h := r.Header.Get("Authorization")
+	if h == "" {
+		return "", errors.New("no Authorization header in request")
+	}
+
+	ss := strings.SplitN(h, " ", 2)
+	if len(ss) != 2 || !strings.EqualFold(ss[0], "bearer") || strings.TrimSpace(ss[1]) == "" {
+		return "", errors.New("malformed Authorization header in request")
+	}
+
+	return strings.TrimSpace(ss[1]), nil
+}
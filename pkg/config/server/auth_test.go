@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
+)
+
+var testPod = corev1.Pod{
+	ObjectMeta: metav1.ObjectMeta{
+		Namespace: "testnamespace",
+		Name:      "gateway-1-pod",
+		UID:       "pod-uid",
+		Labels: map[string]string{
+			opdefault.AppLabelKey:             opdefault.AppLabelValue,
+			opdefault.RelatedGatewayKey:       "gateway-1",
+			opdefault.RelatedGatewayNamespace: "testnamespace",
+		},
+	},
+}
+
+func newTestCert(t *testing.T, cn string) ([]byte, []byte, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err, "generate key")
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err, "create certificate")
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err, "parse certificate")
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err, "marshal key")
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), cert
+}
+
+func newTokenReviewClient(pod *corev1.Pod, username string, extra map[string]authnv1.ExtraValue) client.Client {
+	return fake.NewClientBuilder().WithObjects(pod).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			review, ok := obj.(*authnv1.TokenReview)
+			if !ok {
+				return c.Create(ctx, obj, opts...)
+			}
+			if review.Spec.Token != "valid-token" {
+				review.Status = authnv1.TokenReviewStatus{Error: "invalid token"}
+				return nil
+			}
+			review.Status = authnv1.TokenReviewStatus{
+				Authenticated: true,
+				Audiences:     review.Spec.Audiences,
+				User:          authnv1.UserInfo{Username: username, Extra: extra},
+			}
+			return nil
+		},
+	}).Build()
+}
+
+func TestTokenAuthenticator(t *testing.T) {
+	extra := map[string]authnv1.ExtraValue{
+		podNameExtraKey: {"gateway-1-pod"},
+		podUIDExtraKey:  {"pod-uid"},
+	}
+	c := newTokenReviewClient(&testPod, "system:serviceaccount:testnamespace:default", extra)
+	auth := NewTokenAuthenticator(c, c, opdefault.DefaultConfigDiscoveryTokenAudience)
+
+	newReq := func(token string) *http.Request {
+		req := httptest.NewRequest("GET", opdefault.DefaultConfigDiscoveryEndpoint, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return req
+	}
+
+	assert.NoError(t, auth.Authorize(newReq("valid-token"), "testnamespace/gateway-1"),
+		"authorized")
+	assert.Error(t, auth.Authorize(newReq("valid-token"), "testnamespace/gateway-2"),
+		"other gateway")
+	assert.Error(t, auth.Authorize(newReq("valid-token"), "dummynamespace/gateway-1"),
+		"other namespace")
+	assert.Error(t, auth.Authorize(newReq("invalid-token"), "testnamespace/gateway-1"),
+		"invalid token")
+	assert.Error(t, auth.Authorize(newReq(""), "testnamespace/gateway-1"), "no token")
+
+	// token not bound to a pod
+	c = newTokenReviewClient(&testPod, "system:serviceaccount:testnamespace:default", nil)
+	auth = NewTokenAuthenticator(c, c, opdefault.DefaultConfigDiscoveryTokenAudience)
+	assert.Error(t, auth.Authorize(newReq("valid-token"), "testnamespace/gateway-1"),
+		"unbound token")
+
+	// stale pod
+	c = newTokenReviewClient(&testPod, "system:serviceaccount:testnamespace:default",
+		map[string]authnv1.ExtraValue{
+			podNameExtraKey: {"gateway-1-pod"},
+			podUIDExtraKey:  {"old-pod-uid"},
+		})
+	auth = NewTokenAuthenticator(c, c, opdefault.DefaultConfigDiscoveryTokenAudience)
+	assert.Error(t, auth.Authorize(newReq("valid-token"), "testnamespace/gateway-1"),
+		"stale pod")
+
+	// not a service account
+	c = newTokenReviewClient(&testPod, "dummy-user", extra)
+	auth = NewTokenAuthenticator(c, c, opdefault.DefaultConfigDiscoveryTokenAudience)
+	assert.Error(t, auth.Authorize(newReq("valid-token"), "testnamespace/gateway-1"),
+		"non service account user")
+}
+
+func TestCertAuthenticator(t *testing.T) {
+	_, _, cert := newTestCert(t, "testnamespace/gateway-1")
+	auth := NewCertAuthenticator()
+
+	req := httptest.NewRequest("GET", opdefault.DefaultConfigDiscoveryEndpoint, nil)
+	assert.Error(t, auth.Authorize(req, "testnamespace/gateway-1"), "no TLS")
+
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	assert.NoError(t, auth.Authorize(req, "testnamespace/gateway-1"), "authorized")
+	assert.Error(t, auth.Authorize(req, "testnamespace/gateway-2"), "other gateway")
+}
+
+func TestWatchRejected(t *testing.T) {
+	srv := NewConfigDiscoveryServer(ConfigDiscoveryConfig{
+		Authenticator: NewCertAuthenticator(),
+		Logger:        logr.Discard(),
+	})
+	mux := srv.newServeMux(context.Background())
+	watch := opdefault.DefaultConfigDiscoveryEndpoint + "/watch"
+
+	// invalid client ids are rejected before the WebSocket upgrade
+	for _, query := range []string{"", "?id=", "?id=gateway-1"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", watch+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "invalid id %q", query)
+	}
+
+	// unauthorized
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", watch+"?id=testnamespace/gateway-1", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code, "unauthorized")
+}
+
+func TestTLSConfigFromSecret(t *testing.T) {
+	certPEM, keyPEM, _ := newTestCert(t, "stunner-gateway-operator")
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "testnamespace", Name: "cds-tls"},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+		},
+	}
+	c := fake.NewClientBuilder().WithObjects(secret).Build()
+	name := types.NamespacedName{Namespace: "testnamespace", Name: "cds-tls"}
+
+	conf, err := NewTLSConfigFromSecret(context.Background(), c, name, logr.Discard())
+	assert.NoError(t, err, "TLS config")
+	assert.Nil(t, conf.ClientCAs, "no client CAs")
+	assert.Equal(t, tls.NoClientCert, conf.ClientAuth, "no client auth")
+	cert, err := conf.GetCertificate(nil)
+	assert.NoError(t, err, "get certificate")
+	assert.NotNil(t, cert, "certificate")
+
+	// add a CA
+	secret.Data[caCertKey] = certPEM
+	assert.NoError(t, c.Update(context.Background(), secret), "update secret")
+	conf, err = NewTLSConfigFromSecret(context.Background(), c, name, logr.Discard())
+	assert.NoError(t, err, "TLS config")
+	assert.NotNil(t, conf.ClientCAs, "client CAs")
+	assert.Equal(t, tls.VerifyClientCertIfGiven, conf.ClientAuth, "client auth")
+
+	// missing key
+	delete(secret.Data, corev1.TLSPrivateKeyKey)
+	assert.NoError(t, c.Update(context.Background(), secret), "update secret")
+	_, err = NewTLSConfigFromSecret(context.Background(), c, name, logr.Discard())
+	assert.Error(t, err, "missing key")
+
+	// missing secret
+	_, err = NewTLSConfigFromSecret(context.Background(), c,
+		types.NamespacedName{Namespace: "testnamespace", Name: "dummy"}, logr.Discard())
+	assert.Error(t, err, "missing secret")
+}
@@ -3,10 +3,13 @@ package server
 // updater uploads client updates
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -16,9 +19,11 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 
+	stnrconfv1a1 "github.com/l7mp/stunner/pkg/apis/v1alpha1"
 	cdsclient "github.com/l7mp/stunner/pkg/config/client"
 
 	"github.com/l7mp/stunner-gateway-operator/internal/event"
+	"github.com/l7mp/stunner-gateway-operator/internal/metrics"
 	"github.com/l7mp/stunner-gateway-operator/internal/store"
 	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
 )
@@ -26,14 +31,26 @@ import (
 // ConfigWriteDeadline defines the deadline after which we consider a write event failed.
 const ConfigWriteDeadline = 100 * time.Millisecond
 
+// ShutdownTimeout defines the deadline for the active requests to finish when the server is shut
+// down.
+var ShutdownTimeout = 5 * time.Second
+
 type ConfigDiscoveryConfig struct {
-	Addr   string
-	Logger logr.Logger
+	Addr string
+	// TLSConfig, if set, makes the server serve HTTPS/WSS.
+	TLSConfig *tls.Config
+	// Authenticator, if set, is used to authorize clients to access configs.
+	Authenticator Authenticator
+	Logger        logr.Logger
 }
 
 type Client struct {
 	*websocket.Conn
-	mu sync.Mutex
+	id          string
+	connectedAt time.Time
+	lastSentAt  time.Time
+	lastConfig  []byte
+	mu          sync.Mutex
 }
 
 // Concurrency message writer
@@ -43,9 +60,63 @@ func (c *Client) WriteMessage(messageType int, data []byte) error {
 	return c.Conn.WriteMessage(messageType, data)
 }
 
+// sendConfig writes a config to the client and remembers it for debugging.
+func (c *Client) sendConfig(conf []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.Conn.WriteMessage(websocket.TextMessage, conf); err != nil {
+		return err
+	}
+
+	c.lastSentAt = time.Now()
+	c.lastConfig = conf
+
+	return nil
+}
+
+// ClientInfo describes a client connected to the config discovery server.
+type ClientInfo struct {
+	// ID is the id of the client in the form "namespace/name".
+	ID string `json:"id"`
+	// Address is the remote address of the client.
+	Address string `json:"address"`
+	// ConnectedAt is the time when the client connected.
+	ConnectedAt time.Time `json:"connectedAt"`
+	// LastConfigSentAt is the time when the last config was sent to the client.
+	LastConfigSentAt *time.Time `json:"lastConfigSentAt,omitempty"`
+	// LastConfig is the last config sent to the client.
+	LastConfig *stnrconfv1a1.StunnerConfig `json:"lastConfig,omitempty"`
+}
+
+// info returns a snapshot of the client state.
+func (c *Client) info() ClientInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info := ClientInfo{
+		ID:          c.id,
+		Address:     c.RemoteAddr().String(),
+		ConnectedAt: c.connectedAt,
+	}
+
+	if c.lastConfig != nil {
+		t := c.lastSentAt
+		info.LastConfigSentAt = &t
+		if conf, err := cdsclient.ParseConfig(c.lastConfig); err == nil {
+			info.LastConfig = conf
+		}
+	}
+
+	return info
+}
+
 type ConfigDiscoveryServer struct {
 	ctx      context.Context
 	addr     string
+	listener net.Listener
+	tls      *tls.Config
+	auth     Authenticator
 	configCh chan event.Event
 	conns    map[string]*Client
 	lock     sync.RWMutex
@@ -57,56 +128,56 @@ func NewConfigDiscoveryServer(cfg ConfigDiscoveryConfig) *ConfigDiscoveryServer
 	return &ConfigDiscoveryServer{
 		configCh: make(chan event.Event, 10),
 		addr:     cfg.Addr,
+		tls:      cfg.TLSConfig,
+		auth:     cfg.Authenticator,
 		conns:    make(map[string]*Client),
 		store:    store.NewConfigMapStore(),
 		log:      cfg.Logger.WithName("cds-server"),
 	}
 }
 
+// Start starts the config discovery server. The server runs until the context is cancelled, at
+// which point it is shut down gracefully: active requests are given ShutdownTimeout to finish and
+// all WebSocket clients are disconnected.
 func (c *ConfigDiscoveryServer) Start(ctx context.Context) error {
 	c.ctx = ctx
 
-	// init server
-	s := &http.Server{Addr: c.addr}
-
-	// config watcher API
-	http.HandleFunc(opdefault.DefaultConfigDiscoveryEndpoint+"/watch",
-		func(w http.ResponseWriter, r *http.Request) {
-			upgrader := websocket.Upgrader{
-				ReadBufferSize:  1024,
-				WriteBufferSize: 1024,
-			}
-
-			conn, err := upgrader.Upgrade(w, r, nil)
-			if err != nil {
-				c.log.Error(err, "could not upgrade HTTP connection", "client",
-					r.RemoteAddr)
-			}
+	// listen first so that we can report the actual address (e.g., for ephemeral ports)
+	ln, err := net.Listen("tcp", c.addr)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %q: %w", c.addr, err)
+	}
 
-			c.HandleConn(ctx, conn, r)
-		})
+	c.lock.Lock()
+	c.listener = ln
+	c.lock.Unlock()
 
-	// config API
-	http.HandleFunc(opdefault.DefaultConfigDiscoveryEndpoint,
-		func(w http.ResponseWriter, r *http.Request) {
-			c.HandleReq(w, r)
-		})
+	// init server
+	s := &http.Server{Handler: c.newServeMux(ctx), TLSConfig: c.tls}
 
 	// serve
 	go func() {
-		if err := s.ListenAndServe(); err != nil {
+		var err error
+		if c.tls != nil {
+			// certificates are provided by the TLS config
+			err = s.ServeTLS(ln, "", "")
+		} else {
+			err = s.Serve(ln)
+		}
+		if err != nil {
 			c.log.Info("closing config discovery server", "event", err.Error())
 			return
 		}
 	}()
 
-	c.log.Info("config discovery server running", "address", c.addr,
-		"config-path", opdefault.DefaultConfigDiscoveryEndpoint)
+	c.log.Info("config discovery server running", "address", ln.Addr().String(),
+		"config-path", opdefault.DefaultConfigDiscoveryEndpoint, "tls", c.tls != nil,
+		"authentication", c.auth != nil)
 
 	// listen to config update events and cancel requests
 	go func() {
 		defer close(c.configCh)
-		defer s.Close()
+		defer c.shutdown(s)
 
 		for {
 			select {
@@ -131,6 +202,93 @@ func (c *ConfigDiscoveryServer) Start(ctx context.Context) error {
 	return nil
 }
 
+// Addr returns the address the server listens on. This is the actual address once the server
+// has been started, which is useful when the server is configured with an ephemeral port.
+func (c *ConfigDiscoveryServer) Addr() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.listener == nil {
+		return c.addr
+	}
+
+	return c.listener.Addr().String()
+}
+
+// newServeMux creates the HTTP request multiplexer serving the config discovery API.
+func (c *ConfigDiscoveryServer) newServeMux(ctx context.Context) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// config watcher API
+	mux.HandleFunc(opdefault.DefaultConfigDiscoveryEndpoint+"/watch",
+		func(w http.ResponseWriter, r *http.Request) {
+			id, err := c.getClientId(r)
+			if err != nil {
+				c.log.V(2).Error(err, "invalid client id", "client", r.RemoteAddr)
+				http.Error(w, "Invalid client id", http.StatusBadRequest)
+				return
+			}
+
+			if !c.authorize(w, r, id) {
+				return
+			}
+
+			upgrader := websocket.Upgrader{
+				ReadBufferSize:  1024,
+				WriteBufferSize: 1024,
+			}
+
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				c.log.Error(err, "could not upgrade HTTP connection", "client",
+					r.RemoteAddr)
+				return
+			}
+
+			c.HandleConn(ctx, conn, r)
+		})
+
+	// config API
+	mux.HandleFunc(opdefault.DefaultConfigDiscoveryEndpoint,
+		func(w http.ResponseWriter, r *http.Request) {
+			c.HandleReq(w, r)
+		})
+
+	// debug API
+	mux.HandleFunc(opdefault.DefaultConfigDiscoveryListEndpoint, c.HandleListReq)
+	mux.HandleFunc(opdefault.DefaultConfigDiscoveryListEndpoint+"/", c.HandleListReq)
+	mux.HandleFunc(opdefault.DefaultConfigDiscoveryClientsEndpoint, c.HandleClientsReq)
+
+	return mux
+}
+
+// shutdown gracefully shuts down the HTTP server: it stops accepting new requests, closes all
+// WebSocket connections (these are hijacked so the HTTP server does not track them) and waits
+// at most ShutdownTimeout for the active requests to finish.
+func (c *ConfigDiscoveryServer) shutdown(s *http.Server) {
+	c.log.Info("shutting down config discovery server", "address", c.Addr())
+
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	// the HTTP server does not wait for hijacked connections
+	c.lock.RLock()
+	clients := make(map[string]*Client, len(c.conns))
+	for id, client := range c.conns {
+		clients[id] = client
+	}
+	c.lock.RUnlock()
+
+	for id, client := range clients {
+		c.closeConn(client, id)
+	}
+
+	if err := s.Shutdown(ctx); err != nil {
+		c.log.Error(err, "config discovery server shutdown: forcing close")
+		s.Close() //nolint:errcheck
+	}
+}
+
 // GetConfigUpdateChannel returns the channel on which the config discovery server listenens to
 // update resuests.
 func (c *ConfigDiscoveryServer) GetConfigUpdateChannel() chan event.Event {
@@ -146,6 +304,10 @@ func (c *ConfigDiscoveryServer) HandleReq(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if !c.authorize(w, r, id) {
+		return
+	}
+
 	c.log.V(1).Info("received new client request", "id", id, "config-store", c.store.String())
 
 	namespacedName := store.GetNameFromKey(id)
@@ -175,6 +337,99 @@ func (c *ConfigDiscoveryServer) HandleReq(w http.ResponseWriter, r *http.Request
 	}
 }
 
+// HandleListReq lists all configs held by the server, or the configs in a single namespace if
+// the request path is of the form `<DefaultConfigDiscoveryListEndpoint>/<namespace>`.
+func (c *ConfigDiscoveryServer) HandleListReq(w http.ResponseWriter, r *http.Request) {
+	if !c.checkDebugReq(w, r) {
+		return
+	}
+
+	namespace := strings.TrimPrefix(r.URL.Path, opdefault.DefaultConfigDiscoveryListEndpoint)
+	namespace = strings.Trim(namespace, "/")
+	if strings.Contains(namespace, "/") {
+		http.Error(w, "Invalid namespace", http.StatusNotFound)
+		return
+	}
+
+	c.log.V(1).Info("received config list request", "namespace", namespace)
+
+	cms := c.store.GetAll()
+	sort.Slice(cms, func(i, j int) bool {
+		return store.GetObjectKey(cms[i]) < store.GetObjectKey(cms[j])
+	})
+
+	confs := []stnrconfv1a1.StunnerConfig{}
+	for _, cm := range cms {
+		if namespace != "" && cm.GetNamespace() != namespace {
+			continue
+		}
+
+		conf, err := store.UnpackConfigMap(cm)
+		if err != nil {
+			c.log.Error(err, "cannot unpack config", "id", store.GetObjectKey(cm))
+			continue
+		}
+		confs = append(confs, conf)
+	}
+
+	c.writeJSON(w, confs)
+}
+
+// HandleClientsReq lists the clients connected to the server.
+func (c *ConfigDiscoveryServer) HandleClientsReq(w http.ResponseWriter, r *http.Request) {
+	if !c.checkDebugReq(w, r) {
+		return
+	}
+
+	c.log.V(1).Info("received client list request")
+
+	c.lock.RLock()
+	clients := make([]*Client, 0, len(c.conns))
+	for _, client := range c.conns {
+		clients = append(clients, client)
+	}
+	c.lock.RUnlock()
+
+	infos := make([]ClientInfo, 0, len(clients))
+	for _, client := range clients {
+		infos = append(infos, client.info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+
+	c.writeJSON(w, infos)
+}
+
+// checkDebugReq checks whether a debug request is allowed. Debug endpoints expose the configs
+// of all clients, so they are disabled when client authentication is enabled.
+func (c *ConfigDiscoveryServer) checkDebugReq(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+
+	if c.auth != nil {
+		http.Error(w, "Debug endpoints are disabled when client authentication is enabled",
+			http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+func (c *ConfigDiscoveryServer) writeJSON(w http.ResponseWriter, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		c.log.Error(err, "could not marshal response")
+		http.Error(w, "Could not marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(body); err != nil {
+		c.log.Error(err, "could not write response")
+	}
+}
+
 // HandleConn handles a new client WebSocket connection.
 func (c *ConfigDiscoveryServer) HandleConn(ctx context.Context, conn *websocket.Conn, req *http.Request) {
 	id, err := c.getClientId(req)
@@ -186,21 +441,13 @@ func (c *ConfigDiscoveryServer) HandleConn(ctx context.Context, conn *websocket.
 	c.log.V(1).Info("received new client connection", "client", conn.RemoteAddr().String(), "id", id,
 		"config-store", c.store.String())
 
-	c.lock.RLock()
-	client, ok := c.conns[id]
-	c.lock.RUnlock()
-
-	if ok {
+	client := &Client{Conn: conn, id: id, connectedAt: time.Now()}
+	if old := c.addConn(id, client); old != nil {
 		c.log.V(1).Info("client connection already exists, dropping old connection",
 			"client", conn.RemoteAddr().String(), "id", id)
-		client.Close()
+		old.Close()
 	}
 
-	client = &Client{Conn: conn}
-	c.lock.Lock()
-	c.conns[id] = client
-	c.lock.Unlock()
-
 	// a dummy reader that drops everything it receives: this must be there for the WebSocket
 	// server to call our pong-handler: conn.Close() will kill this goroutine
 	go func() {
@@ -233,12 +480,14 @@ func (c *ConfigDiscoveryServer) HandleConn(ctx context.Context, conn *websocket.
 
 	c.log.V(1).Info("client connection closed", "client", conn.LocalAddr().String(), "id", id)
 
+	c.removeConn(id, client)
 	conn.Close()
 }
 
-// ProcessUpdate processes new config events. If first takes all locally stored configmaps
-// that do not appear in the update and wipes to config from the corresponding clients, then stores
-// the new configmaps, checks is anything has changed, and if yes, sends an update.
+// ProcessUpdate processes new config events. For a full update it first takes all locally stored
+// configmaps that do not appear in the update and wipes to config from the corresponding clients,
+// for an incremental update it wipes only the configmaps in the delete queue. Then it stores the
+// new configmaps, checks is anything has changed, and if yes, sends an update.
 func (c *ConfigDiscoveryServer) ProcessUpdate(e *event.EventUpdate) error {
 	c.log.Info("processing config update event", "generation", e.Generation,
 		"update", e.String())
@@ -248,22 +497,26 @@ func (c *ConfigDiscoveryServer) ProcessUpdate(e *event.EventUpdate) error {
 	// wipe all old configs that have disappeared
 	for _, cm := range c.store.GetAll() {
 		nsName := store.GetNamespacedName(cm)
-		o := q.ConfigMaps.Get(nsName)
-		if o == nil {
-			c.log.V(4).Info("removing config", "generation", e.Generation,
-				"client", nsName.String())
-
-			// config has disappeared: remove from local store
-			c.store.Remove(nsName)
-			// this will send an empty config to the client, if online
-			id := nsName.String()
-			if err := c.sendConfig(id); err != nil {
-				c.log.V(1).Info("cannot send config (client has gone?)", "client", id,
-					"config-map", store.DumpObject(cm), "error", err)
+		if e.Incremental {
+			if e.DeleteQueue.ConfigMaps.Get(nsName) == nil {
 				continue
 			}
+		} else if q.ConfigMaps.Get(nsName) != nil {
+			continue
 		}
 
+		c.log.V(4).Info("removing config", "generation", e.Generation,
+			"client", nsName.String())
+
+		// config has disappeared: remove from local store
+		c.store.Remove(nsName)
+		// this will send an empty config to the client, if online
+		id := nsName.String()
+		if err := c.sendConfig(id); err != nil {
+			c.log.V(1).Info("cannot send config (client has gone?)", "client", id,
+				"config-map", store.DumpObject(cm), "error", err)
+			continue
+		}
 	}
 
 	// store and send each new configmap if something has changed
@@ -291,8 +544,6 @@ func (c *ConfigDiscoveryServer) ProcessUpdate(e *event.EventUpdate) error {
 		}
 	}
 
-	// configmaps are never deleted so the delete queue is always empty
-
 	return nil
 }
 
@@ -336,11 +587,13 @@ func (c *ConfigDiscoveryServer) sendConfig(id string) error {
 	}
 
 	// and send it along
-	if err := client.WriteMessage(websocket.TextMessage, conf); err != nil {
+	if err := client.sendConfig(conf); err != nil {
+		metrics.CDSConfigPushFailuresTotal.Inc()
 		c.closeConn(client, id)
 
 		return fmt.Errorf("could not send config: %w", err)
 	}
+	metrics.CDSConfigPushesTotal.Inc()
 
 	return nil
 }
@@ -348,12 +601,53 @@ func (c *ConfigDiscoveryServer) sendConfig(id string) error {
 func (c *ConfigDiscoveryServer) closeConn(client *Client, id string) {
 	c.log.V(1).Info("closing connection", "client", client.RemoteAddr().String(), "id", id)
 	client.WriteMessage(websocket.CloseMessage, []byte{}) //nolint:errcheck
-	c.lock.Lock()
-	delete(c.conns, id)
-	c.lock.Unlock()
+	c.removeConn(id, client)
 	client.Close()
 }
 
+// addConn registers a new client connection and returns the connection it replaces, if any.
+func (c *ConfigDiscoveryServer) addConn(id string, client *Client) *Client {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	old, ok := c.conns[id]
+	c.conns[id] = client
+	if !ok {
+		metrics.CDSClients.Inc()
+	}
+
+	return old
+}
+
+// removeConn removes a client connection, unless it has already been replaced by a new
+// connection from the same client.
+func (c *ConfigDiscoveryServer) removeConn(id string, client *Client) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if cur, ok := c.conns[id]; ok && cur == client {
+		delete(c.conns, id)
+		metrics.CDSClients.Dec()
+	}
+}
+
+// authorize checks whether the client is allowed to access the config with the given id and
+// writes an error response if not.
+func (c *ConfigDiscoveryServer) authorize(w http.ResponseWriter, r *http.Request, id string) bool {
+	if c.auth == nil {
+		return true
+	}
+
+	if err := c.auth.Authorize(r, id); err != nil {
+		c.log.V(1).Info("client authorization failed", "client", r.RemoteAddr, "id", id,
+			"error", err.Error())
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
 func (c *ConfigDiscoveryServer) getClientId(req *http.Request) (string, error) {
 	u := req.URL
 	if u == nil {
@@ -3,10 +3,13 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/go-logr/zapr"
@@ -22,6 +25,7 @@ import (
 	"github.com/l7mp/stunner/pkg/logger"
 
 	"github.com/l7mp/stunner-gateway-operator/internal/event"
+	"github.com/l7mp/stunner-gateway-operator/internal/metrics"
 	"github.com/l7mp/stunner-gateway-operator/internal/store"
 	opdefault "github.com/l7mp/stunner-gateway-operator/pkg/config"
 )
@@ -369,6 +373,42 @@ func TestConfigDiscovery(t *testing.T) {
 	assert.NotNil(t, c3)
 	assert.True(t, c3.DeepEqual(c3Ok), "config ok")
 
+	log.Info("incremental update for the loader", "id", "ns/gw1")
+	e = event.NewEventUpdate(0)
+	e.Incremental = true
+	e.UpsertQueue.ConfigMaps.Reset([]client.Object{packConfig(c1Ok)})
+	ch <- e
+
+	time.Sleep(50 * time.Millisecond)
+
+	// watcher2 should not receive anything
+	_, ok = tryControlCh(controlCh2)
+	assert.False(t, ok)
+
+	// configs missing from an incremental update are kept
+	assert.Equal(t, 2, cds.store.Len())
+
+	log.Info("incremental removal of the config of the loader", "id", "ns/gw1")
+	e = event.NewEventUpdate(0)
+	e.Incremental = true
+	e.DeleteQueue.ConfigMaps.Reset([]client.Object{packConfig(c1Ok)})
+	ch <- e
+
+	time.Sleep(50 * time.Millisecond)
+
+	_, err = cdsc1.Load()
+	assert.Error(t, err, "loading client config errs")
+
+	// watcher2 should not receive anything
+	_, ok = tryControlCh(controlCh2)
+	assert.False(t, ok)
+
+	assert.Equal(t, 1, cds.store.Len())
+	c3m = cds.store.GetObject(store.GetNameFromKey("ns/gw3"))
+	c3s, err = store.UnpackConfigMap(c3m)
+	assert.NoError(t, err)
+	assert.True(t, c3s.DeepEqual(c3Ok), "config ok")
+
 	log.Info("removing all configs")
 	e = event.NewEventUpdate(0)
 	e.UpsertQueue.ConfigMaps.Reset([]client.Object{})
@@ -389,6 +429,209 @@ func TestConfigDiscovery(t *testing.T) {
 	assert.Equal(t, 0, cds.store.Len())
 }
 
+// Steps:
+// - starting two CDS servers on ephemeral ports in the same process
+// - loading a config from both servers
+// - shutting down the 1st server, checking that a watcher is disconnected and the port is released
+// - restarting a server on the same address
+func TestConfigDiscoveryMultiServer(t *testing.T) {
+	zc := zap.NewProductionConfig()
+	zc.Level = zap.NewAtomicLevelAt(testerLogLevel)
+	z, err := zc.Build()
+	assert.NoError(t, err, "logger created")
+	zlogger := zapr.NewLogger(z)
+	logger := logger.NewLoggerFactory(stunnerLogLevel)
+
+	ShutdownTimeout = 500 * time.Millisecond
+
+	cds1 := NewConfigDiscoveryServer(ConfigDiscoveryConfig{Addr: "127.0.0.1:0", Logger: zlogger})
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	defer cancel1()
+	assert.NoError(t, cds1.Start(ctx1), "cds server 1 start")
+
+	cds2 := NewConfigDiscoveryServer(ConfigDiscoveryConfig{Addr: "127.0.0.1:0", Logger: zlogger})
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	assert.NoError(t, cds2.Start(ctx2), "cds server 2 start")
+
+	addr1, addr2 := cds1.Addr(), cds2.Addr()
+	assert.NotEqual(t, "127.0.0.1:0", addr1, "ephemeral port 1 resolved")
+	assert.NotEqual(t, "127.0.0.1:0", addr2, "ephemeral port 2 resolved")
+	assert.NotEqual(t, addr1, addr2, "addresses differ")
+
+	c1Ok := zeroConfig("ns", "gw1", "realm1")
+	e := event.NewEventUpdate(0)
+	e.UpsertQueue.ConfigMaps.Reset([]client.Object{packConfig(c1Ok)})
+	cds1.GetConfigUpdateChannel() <- e
+
+	c2Ok := zeroConfig("ns", "gw1", "realm2")
+	e = event.NewEventUpdate(0)
+	e.UpsertQueue.ConfigMaps.Reset([]client.Object{packConfig(c2Ok)})
+	cds2.GetConfigUpdateChannel() <- e
+
+	time.Sleep(50 * time.Millisecond)
+
+	cdsc1, err := cdsclient.NewClient("http://"+addr1, "ns/gw1", logger)
+	assert.NoError(t, err, "cds client 1 setup")
+	c1, err := cdsc1.Load()
+	assert.NoError(t, err, "loading config from server 1")
+	assert.True(t, c1Ok.DeepEqual(c1), "config 1 ok")
+
+	cdsc2, err := cdsclient.NewClient("http://"+addr2, "ns/gw1", logger)
+	assert.NoError(t, err, "cds client 2 setup")
+	c2, err := cdsc2.Load()
+	assert.NoError(t, err, "loading config from server 2")
+	assert.True(t, c2Ok.DeepEqual(c2), "config 2 ok")
+
+	// watcher on server 1
+	cdsw, err := cdsclient.NewClient("ws://"+addr1, "ns/gw1", logger)
+	assert.NoError(t, err, "cds watcher setup")
+	controlCh := make(chan stnrconfv1a1.StunnerConfig, 10)
+	wctx, wcancel := context.WithCancel(context.Background())
+	defer wcancel()
+	assert.NoError(t, cdsw.Watch(wctx, controlCh), "watcher setup")
+
+	time.Sleep(50 * time.Millisecond)
+
+	cds1.lock.RLock()
+	assert.Len(t, cds1.conns, 1, "watcher connected")
+	cds1.lock.RUnlock()
+
+	// shut down server 1
+	cancel1()
+	time.Sleep(100 * time.Millisecond)
+
+	cds1.lock.RLock()
+	assert.Len(t, cds1.conns, 0, "watcher disconnected")
+	cds1.lock.RUnlock()
+
+	_, err = cdsc1.Load()
+	assert.Error(t, err, "server 1 is down")
+
+	// server 2 still runs
+	c2, err = cdsc2.Load()
+	assert.NoError(t, err, "loading config from server 2")
+	assert.True(t, c2Ok.DeepEqual(c2), "config 2 ok")
+
+	// restart on the same address
+	cds3 := NewConfigDiscoveryServer(ConfigDiscoveryConfig{Addr: addr1, Logger: zlogger})
+	ctx3, cancel3 := context.WithCancel(context.Background())
+	defer cancel3()
+	assert.NoError(t, cds3.Start(ctx3), "cds server restart")
+	assert.Equal(t, addr1, cds3.Addr(), "restarted on the same address")
+
+	// the address is in use
+	cds4 := NewConfigDiscoveryServer(ConfigDiscoveryConfig{Addr: addr2, Logger: zlogger})
+	assert.Error(t, cds4.Start(context.Background()), "address in use")
+}
+
+type denyAuthenticator struct{}
+
+func (a *denyAuthenticator) Authorize(_ *http.Request, _ string) error {
+	return errors.New("denied")
+}
+
+func TestConfigDiscoveryDebugAPI(t *testing.T) {
+	zc := zap.NewProductionConfig()
+	zc.Level = zap.NewAtomicLevelAt(testerLogLevel)
+	z, err := zc.Build()
+	assert.NoError(t, err, "logger created")
+	zlogger := zapr.NewLogger(z)
+	logger := logger.NewLoggerFactory(stunnerLogLevel)
+
+	cds := NewConfigDiscoveryServer(ConfigDiscoveryConfig{Addr: "127.0.0.1:0", Logger: zlogger})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, cds.Start(ctx), "cds server start")
+	base := "http://" + cds.Addr()
+
+	get := func(path string, v any) int {
+		resp, err := http.Get(base + path)
+		assert.NoError(t, err, "GET %s", path)
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusOK && v != nil {
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(v), "decode %s", path)
+		}
+		return resp.StatusCode
+	}
+
+	// empty server
+	confs := []stnrconfv1a1.StunnerConfig{}
+	assert.Equal(t, http.StatusOK, get(opdefault.DefaultConfigDiscoveryListEndpoint, &confs))
+	assert.Len(t, confs, 0, "no configs")
+	clients := []ClientInfo{}
+	assert.Equal(t, http.StatusOK, get(opdefault.DefaultConfigDiscoveryClientsEndpoint, &clients))
+	assert.Len(t, clients, 0, "no clients")
+
+	c1 := zeroConfig("ns1", "gw1", "realm1")
+	c2 := zeroConfig("ns1", "gw2", "realm2")
+	c3 := zeroConfig("ns2", "gw1", "realm3")
+	e := event.NewEventUpdate(0)
+	e.UpsertQueue.ConfigMaps.Reset([]client.Object{packConfig(c3), packConfig(c2), packConfig(c1)})
+	cds.GetConfigUpdateChannel() <- e
+
+	time.Sleep(50 * time.Millisecond)
+
+	// all configs, sorted by id
+	assert.Equal(t, http.StatusOK, get(opdefault.DefaultConfigDiscoveryListEndpoint, &confs))
+	assert.Len(t, confs, 3, "configs")
+	assert.True(t, c1.DeepEqual(&confs[0]), "config 1")
+	assert.True(t, c2.DeepEqual(&confs[1]), "config 2")
+	assert.True(t, c3.DeepEqual(&confs[2]), "config 3")
+
+	// per-namespace
+	confs = []stnrconfv1a1.StunnerConfig{}
+	assert.Equal(t, http.StatusOK, get(opdefault.DefaultConfigDiscoveryListEndpoint+"/ns1", &confs))
+	assert.Len(t, confs, 2, "configs in ns1")
+	assert.True(t, c1.DeepEqual(&confs[0]), "config 1")
+	assert.True(t, c2.DeepEqual(&confs[1]), "config 2")
+
+	confs = []stnrconfv1a1.StunnerConfig{}
+	assert.Equal(t, http.StatusOK, get(opdefault.DefaultConfigDiscoveryListEndpoint+"/ns3", &confs))
+	assert.Len(t, confs, 0, "configs in ns3")
+
+	assert.Equal(t, http.StatusNotFound, get(opdefault.DefaultConfigDiscoveryListEndpoint+"/ns1/gw1", nil))
+
+	// connect a watcher
+	pushes := promtestutil.ToFloat64(metrics.CDSConfigPushesTotal)
+	clientNum := promtestutil.ToFloat64(metrics.CDSClients)
+	cdsw, err := cdsclient.NewClient("ws://"+cds.Addr(), "ns1/gw2", logger)
+	assert.NoError(t, err, "cds watcher setup")
+	controlCh := make(chan stnrconfv1a1.StunnerConfig, 10)
+	wctx, wcancel := context.WithCancel(context.Background())
+	defer wcancel()
+	assert.NoError(t, cdsw.Watch(wctx, controlCh), "watcher setup")
+
+	time.Sleep(50 * time.Millisecond)
+
+	// the initial config has been pushed
+	assert.Equal(t, pushes+1, promtestutil.ToFloat64(metrics.CDSConfigPushesTotal), "config pushes")
+	assert.Equal(t, clientNum+1, promtestutil.ToFloat64(metrics.CDSClients), "connected clients")
+
+	assert.Equal(t, http.StatusOK, get(opdefault.DefaultConfigDiscoveryClientsEndpoint, &clients))
+	assert.Len(t, clients, 1, "clients")
+	assert.Equal(t, "ns1/gw2", clients[0].ID, "client id")
+	assert.NotEmpty(t, clients[0].Address, "client address")
+	assert.False(t, clients[0].ConnectedAt.IsZero(), "connect time")
+	assert.NotNil(t, clients[0].LastConfigSentAt, "last config sent time")
+	assert.NotNil(t, clients[0].LastConfig, "last config")
+	assert.True(t, c2.DeepEqual(clients[0].LastConfig), "last config")
+
+	// only GET is allowed
+	resp, err := http.Post(base+opdefault.DefaultConfigDiscoveryListEndpoint, "application/json", nil)
+	assert.NoError(t, err, "POST")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode, "POST not allowed")
+
+	// debug endpoints are disabled with authentication
+	cds2 := NewConfigDiscoveryServer(ConfigDiscoveryConfig{Addr: "127.0.0.1:0",
+		Authenticator: &denyAuthenticator{}, Logger: zlogger})
+	assert.NoError(t, cds2.Start(ctx), "cds server 2 start")
+	base = "http://" + cds2.Addr()
+	assert.Equal(t, http.StatusForbidden, get(opdefault.DefaultConfigDiscoveryListEndpoint, nil))
+	assert.Equal(t, http.StatusForbidden, get(opdefault.DefaultConfigDiscoveryClientsEndpoint, nil))
+}
+
 func zeroConfig(namespace, name, realm string) *stnrconfv1a1.StunnerConfig {
 	id := fmt.Sprintf("%s/%s", namespace, name)
 	c := cdsclient.ZeroConfig(id)
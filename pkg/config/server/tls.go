@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CertReloadPeriod defines how often the TLS certificate of the config discovery server is
+// reloaded from the Secret.
+var CertReloadPeriod = 1 * time.Minute
+
+// caCertKey is the key in a TLS Secret that holds the CA certificate.
+const caCertKey = "ca.crt"
+
+// certLoader loads and caches a TLS certificate from a Kubernetes Secret.
+type certLoader struct {
+	reader   client.Reader
+	name     types.NamespacedName
+	cert     *tls.Certificate
+	loadedAt time.Time
+	lock     sync.Mutex
+	log      logr.Logger
+}
+
+// NewTLSConfigFromSecret creates a TLS config for the config discovery server using the
+// certificate and the private key stored in a Secret of type kubernetes.io/tls. The Secret is
+// reloaded periodically so that a rotated certificate is picked up without a restart. If the
+// Secret contains a CA certificate (under the key "ca.crt") then client certificates are verified
+// against this CA.
+func NewTLSConfigFromSecret(ctx context.Context, reader client.Reader, name types.NamespacedName, log logr.Logger) (*tls.Config, error) {
+	l := &certLoader{
+		reader: reader,
+		name:   name,
+		log:    log.WithName("cds-cert-loader"),
+	}
+
+	secret, err := l.getSecret(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := parseCertificate(secret)
+	if err != nil {
+		return nil, err
+	}
+	l.cert, l.loadedAt = cert, time.Now()
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: l.getCertificate,
+	}
+
+	ca, ok := secret.Data[caCertKey]
+	if ok && len(ca) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("invalid CA certificate in Secret %s", name.String())
+		}
+		tlsConfig.ClientCAs = pool
+		// client certs are optional at the TLS layer, the Authenticator enforces them
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsConfig, nil
+}
+
+func (l *certLoader) getCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if time.Since(l.loadedAt) < CertReloadPeriod {
+		return l.cert, nil
+	}
+
+	// keep using the old certificate if reload fails
+	l.loadedAt = time.Now()
+	secret, err := l.getSecret(context.Background())
+	if err != nil {
+		l.log.Error(err, "cannot reload TLS certificate, using the last known certificate",
+			"secret", l.name.String())
+		return l.cert, nil
+	}
+
+	cert, err := parseCertificate(secret)
+	if err != nil {
+		l.log.Error(err, "invalid TLS certificate, using the last known certificate",
+			"secret", l.name.String())
+		return l.cert, nil
+	}
+	l.cert = cert
+
+	return l.cert, nil
+}
+
+func (l *certLoader) getSecret(ctx context.Context) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := l.reader.Get(ctx, l.name, secret); err != nil {
+		return nil, fmt.Errorf("cannot load TLS Secret %s: %w", l.name.String(), err)
+	}
+
+	return secret, nil
+}
+
+func parseCertificate(secret *corev1.Secret) (*tls.Certificate, error) {
+	certPEM, certOk := secret.Data[corev1.TLSCertKey]
+	keyPEM, keyOk := secret.Data[corev1.TLSPrivateKeyKey]
+	if !certOk || !keyOk {
+		return nil, errors.New("TLS Secret must contain both a certificate and a private key")
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS certificate/key pair: %w", err)
+	}
+
+	return &cert, nil
+}
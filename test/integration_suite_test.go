@@ -31,6 +31,7 @@ import (
 
 	"go.uber.org/zap/zapcore"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -76,7 +77,7 @@ var (
 	testGw         *gwapiv1b1.Gateway
 	testUDPRoute   *gwapiv1a2.UDPRoute
 	testSvc        *corev1.Service
-	testEndpoint   *corev1.Endpoints
+	testEndpoint   *discoveryv1.EndpointSlice
 	testNode       *corev1.Node
 	testSecret     *corev1.Secret
 	testAuthSecret *corev1.Secret
@@ -114,7 +115,7 @@ func InitResources() {
 	testGw = testutils.TestGw.DeepCopy()
 	testUDPRoute = testutils.TestUDPRoute.DeepCopy()
 	testSvc = testutils.TestSvc.DeepCopy()
-	testEndpoint = testutils.TestEndpoint.DeepCopy()
+	testEndpoint = testutils.TestEndpointSlice.DeepCopy()
 	testNode = testutils.TestNode.DeepCopy()
 	testSecret = testutils.TestSecret.DeepCopy()
 	testAuthSecret = testutils.TestAuthSecret.DeepCopy()